@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+// adminClient sends requests to the administration API of a running UDR.
+type adminClient struct {
+	uri    string
+	client *http.Client
+}
+
+// initAdminCommand loads the configuration given to the udr command itself and
+// returns a client of the administration API it serves, unless the command
+// gives the API root with --uri. The API has its own listener, which must be
+// configured.
+func initAdminCommand(c *cli.Context) (*adminClient, error) {
+	app := c.Parent().Parent()
+	if err := initLogFile(app.String("log"), app.String("log5gc")); err != nil {
+		return nil, err
+	}
+	if err := UDR.Initialize(app); err != nil {
+		return nil, err
+	}
+
+	self := udr_context.UDR_Self()
+	util.InitUdrContext(self)
+	admin := &adminClient{
+		uri:    self.GetAdminUri(),
+		client: &http.Client{},
+	}
+	if uri := c.String("uri"); uri != "" {
+		admin.uri = strings.TrimSuffix(uri, "/")
+	} else if self.AdminPort == 0 {
+		return nil, fmt.Errorf("the administration API is not configured, set configuration.admin or give --uri")
+	}
+
+	if strings.HasPrefix(admin.uri, "https:") {
+		pemPath := util.UdrDefaultPemPath
+		if sbi := factory.UdrConfig.Configuration.Sbi; sbi != nil && sbi.Tls != nil {
+			pemPath = sbi.Tls.Pem
+		}
+		pem, err := ioutil.ReadFile(pemPath)
+		if err != nil {
+			return nil, err
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", pemPath)
+		}
+		admin.client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: rootCAs},
+		}
+	}
+	return admin, nil
+}
+
+// do sends body as JSON to path and returns the response body when the UDR
+// answers with one of expected, or the problem it reports otherwise.
+func (a *adminClient) do(method string, path string, body interface{}, expected ...int) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, a.uri+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rsp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	rspBody, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range expected {
+		if rsp.StatusCode == status {
+			return rspBody, nil
+		}
+	}
+
+	var problem models.ProblemDetails
+	if json.Unmarshal(rspBody, &problem) == nil && problem.Title != "" {
+		if problem.Detail != "" {
+			return nil, fmt.Errorf("%s %s: %s: %s", method, path, problem.Title, problem.Detail)
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, path, problem.Title)
+	}
+	return nil, fmt.Errorf("%s %s: %s", method, path, rsp.Status)
+}
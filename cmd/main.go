@@ -30,6 +30,7 @@ func main() {
 	app.Usage = "5G Unified Data Repository (UDR)"
 	app.Action = action
 	app.Flags = UDR.GetCliCmd()
+	app.Commands = []cli.Command{migrateCommand, subscriberCommand}
 	if err := app.Run(os.Args); err != nil {
 		fmt.Printf("UDR Run error: %v\n", err)
 	}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/free5gc/udr/internal/migration"
+)
+
+var migrateCommand = cli.Command{
+	Name:  "migrate",
+	Usage: "Manage the MongoDB schema version of the UDR data model",
+	Subcommands: []cli.Command{
+		{
+			Name:   "up",
+			Usage:  "Apply pending migrations",
+			Action: migrateUpAction,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "to",
+					Usage: "Migrate up to schema `VERSION` (default: latest)",
+				},
+			},
+		},
+		{
+			Name:   "down",
+			Usage:  "Revert applied migrations",
+			Action: migrateDownAction,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "to",
+					Value: -1,
+					Usage: "Migrate down to schema `VERSION` (default: one step)",
+				},
+			},
+		},
+		{
+			Name:   "status",
+			Usage:  "Show the schema version and registered migrations",
+			Action: migrateStatusAction,
+		},
+	},
+}
+
+// initDBCommand loads the configuration given to the udr command itself and
+// connects to the configured MongoDB.
+func initDBCommand(c *cli.Context) error {
+	app := c.Parent().Parent()
+	if err := initLogFile(app.String("log"), app.String("log5gc")); err != nil {
+		return err
+	}
+	if err := UDR.Initialize(app); err != nil {
+		return err
+	}
+	return UDR.ConnectMongoDB()
+}
+
+func migrateUpAction(c *cli.Context) error {
+	if err := initDBCommand(c); err != nil {
+		return err
+	}
+	if err := migration.Up(c.Int("to")); err != nil {
+		return err
+	}
+	return printMigrateStatus()
+}
+
+func migrateDownAction(c *cli.Context) error {
+	if err := initDBCommand(c); err != nil {
+		return err
+	}
+	if err := migration.Down(c.Int("to")); err != nil {
+		return err
+	}
+	return printMigrateStatus()
+}
+
+func migrateStatusAction(c *cli.Context) error {
+	if err := initDBCommand(c); err != nil {
+		return err
+	}
+	return printMigrateStatus()
+}
+
+func printMigrateStatus() error {
+	steps, current, err := migration.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema version: %d (latest %d)\n", current, migration.LatestVersion())
+	for _, step := range steps {
+		state := "pending"
+		if step.Applied {
+			state = "applied"
+		}
+		fmt.Printf("  %3d  %-7s  %s\n", step.Version, state, step.Description)
+	}
+	return nil
+}
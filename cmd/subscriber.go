@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/urfave/cli"
+
+	"github.com/free5gc/udr/internal/subscriber"
+)
+
+var subscriberCommand = cli.Command{
+	Name:  "subscriber",
+	Usage: "Create, delete, import or export subscribers",
+	Subcommands: []cli.Command{
+		{
+			Name:      "import",
+			Usage:     "Store the subscribers read from a JSON Lines or CSV file",
+			ArgsUsage: "FILE",
+			Action:    subscriberImportAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "Input `FORMAT`, jsonl or csv (default: guessed from the file name)",
+				},
+				cli.StringFlag{
+					Name:  "mode",
+					Value: subscriber.MODE_UPSERT,
+					Usage: "upsert overwrites stored data sets, skip-existing leaves stored UEs untouched",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Validate the input without writing to the database",
+				},
+				cli.StringFlag{
+					Name:  "report",
+					Usage: "Write the per-line error report to `FILE` (default: stderr)",
+				},
+			},
+		},
+		{
+			Name:      "export",
+			Usage:     "Write stored subscribers as JSON Lines or CSV",
+			ArgsUsage: "[UEID...]",
+			Action:    subscriberExportAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "output, o",
+					Usage: "Write to `FILE` (default: stdout)",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "Output `FORMAT`, jsonl or csv (default: guessed from the file name)",
+				},
+			},
+		},
+		{
+			Name:      "create",
+			Usage:     "Create a subscriber with the data sets read from a JSON file through a running UDR",
+			ArgsUsage: "FILE",
+			Action:    subscriberCreateAction,
+			Flags:     []cli.Flag{adminUriFlag},
+		},
+		{
+			Name:      "delete",
+			Usage:     "Delete subscribers with all their data and subscriptions through a running UDR",
+			ArgsUsage: "UEID...",
+			Action:    subscriberDeleteAction,
+			Flags:     []cli.Flag{adminUriFlag},
+		},
+	},
+}
+
+var adminUriFlag = cli.StringFlag{
+	Name:  "uri",
+	Usage: "Administration API root `URI` of the UDR (default: derived from the admin configuration)",
+}
+
+func subscriberImportAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected exactly one input file")
+	}
+	path := c.Args().First()
+	format, err := subscriber.FormatOf(c.String("format"), path)
+	if err != nil {
+		return err
+	}
+	opts := subscriber.ImportOptions{
+		Mode:   c.String("mode"),
+		DryRun: c.Bool("dry-run"),
+	}
+	if err = subscriber.CheckMode(opts.Mode); err != nil {
+		return err
+	}
+
+	input, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := input.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "close %s: %v\n", path, closeErr)
+		}
+	}()
+
+	var report io.Writer = os.Stderr
+	if reportPath := c.String("report"); reportPath != "" {
+		reportFile, createErr := os.Create(reportPath)
+		if createErr != nil {
+			return createErr
+		}
+		defer func() {
+			if closeErr := reportFile.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "close %s: %v\n", reportPath, closeErr)
+			}
+		}()
+		report = reportFile
+	}
+
+	if err = initDBCommand(c); err != nil {
+		return err
+	}
+	result, err := subscriber.Import(subscriber.NewReader(format, input), opts, report)
+	verb := "Imported"
+	if opts.DryRun {
+		verb = "Validated"
+	}
+	fmt.Printf("%s %d, skipped %d, failed %d\n", verb, result.Imported, result.Skipped, result.Failed)
+	if err != nil {
+		return err
+	}
+	if result.Failed != 0 {
+		return fmt.Errorf("%d records failed", result.Failed)
+	}
+	return nil
+}
+
+func subscriberExportAction(c *cli.Context) error {
+	path := c.String("output")
+	format, err := subscriber.FormatOf(c.String("format"), path)
+	if err != nil {
+		return err
+	}
+	if err = initDBCommand(c); err != nil {
+		return err
+	}
+
+	output := os.Stdout
+	if path != "" {
+		if output, err = os.Create(path); err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := output.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "close %s: %v\n", path, closeErr)
+			}
+		}()
+	}
+
+	count, err := subscriber.Export(subscriber.NewWriter(format, output), c.Args())
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		fmt.Printf("Exported %d subscribers to %s\n", count, path)
+	}
+	return nil
+}
+
+func subscriberCreateAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected exactly one input file")
+	}
+	path := c.Args().First()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var s subscriber.Subscriber
+	if err = json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if err = s.Validate(); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	admin, err := initAdminCommand(c)
+	if err != nil {
+		return err
+	}
+	if _, err = admin.do(http.MethodPost, "/subscribers", &s, http.StatusCreated); err != nil {
+		return err
+	}
+	fmt.Printf("Created %s\n", s.UeId)
+	return nil
+}
+
+func subscriberDeleteAction(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return fmt.Errorf("expected at least one ueId")
+	}
+	admin, err := initAdminCommand(c)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, ueId := range c.Args() {
+		if _, err = admin.do(http.MethodDelete, "/subscribers/"+url.PathEscape(ueId), nil,
+			http.StatusNoContent); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Deleted %s\n", ueId)
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d subscribers failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/free5gc/udr/internal/repository"
+)
+
+const (
+	OUTCOME_SUCCESS = "SUCCESS"
+	OUTCOME_FAILURE = "FAILURE"
+
+	redactedValue = "***"
+)
+
+// Record is the audit trail entry of one write operation.
+type Record struct {
+	Timestamp          string      `json:"timestamp"`
+	CallerNfType       string      `json:"callerNfType,omitempty"`
+	CallerNfInstanceId string      `json:"callerNfInstanceId,omitempty"`
+	ClientIp           string      `json:"clientIp"`
+	Method             string      `json:"method"`
+	ResourceUri        string      `json:"resourceUri"`
+	UeId               string      `json:"ueId,omitempty"`
+	Status             int         `json:"status"`
+	Outcome            string      `json:"outcome"`
+	Request            interface{} `json:"request,omitempty"`
+}
+
+// Sink is where audit records are delivered. Implementations must be safe for
+// concurrent use.
+type Sink interface {
+	Write(record *Record) error
+	Close() error
+}
+
+var (
+	sink    Sink
+	sinkMtx sync.RWMutex
+)
+
+// SetSink installs the audit sink, closing the previous one. A nil sink turns
+// auditing off.
+func SetSink(s Sink) error {
+	sinkMtx.Lock()
+	defer sinkMtx.Unlock()
+
+	var err error
+	if sink != nil {
+		err = sink.Close()
+	}
+	sink = s
+	return err
+}
+
+// Enabled reports whether a sink is installed.
+func Enabled() bool {
+	sinkMtx.RLock()
+	defer sinkMtx.RUnlock()
+	return sink != nil
+}
+
+// Write delivers record to the installed sink, if any.
+func Write(record *Record) error {
+	sinkMtx.RLock()
+	defer sinkMtx.RUnlock()
+
+	if sink == nil {
+		return nil
+	}
+	return sink.Write(record)
+}
+
+// FileSink appends audit records to a file as JSON lines.
+type FileSink struct {
+	mtx  sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (s *FileSink) Write(record *Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	_, err = s.file.Write(line)
+	return err
+}
+
+func (s *FileSink) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.file.Close()
+}
+
+// Attribute names containing one of these words hold credentials other than
+// the key material of the subscription data.
+var secretWords = []string{"secret", "password", "token"}
+
+// isSecret reports whether the attribute name, or a segment of the JSON
+// pointer name, holds key material or credentials.
+func isSecret(name string) bool {
+	for _, segment := range strings.Split(name, "/") {
+		for _, attr := range repository.AuthSubsSecretAttrs {
+			if segment == attr {
+				return true
+			}
+		}
+		segment = strings.ToLower(segment)
+		for _, word := range secretWords {
+			if strings.Contains(segment, word) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of a decoded JSON value in which the values of secret
+// attributes are replaced. PatchItems whose path points into a secret
+// attribute have their value replaced as well.
+func Redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			if isSecret(name) {
+				redacted[name] = redactedValue
+			} else {
+				redacted[name] = Redact(item)
+			}
+		}
+		if path, ok := v["path"].(string); ok && isSecret(path) {
+			if _, ok := v["value"]; ok {
+				redacted["value"] = redactedValue
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = Redact(item)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+)
+
+// HandlerFunc returns a middleware that writes an audit record for every
+// request that may modify the stored data, after the request has been handled.
+// ueIdOf returns the UE the request is about, or "" when there is none.
+func HandlerFunc(ueIdOf func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || !Enabled() {
+			c.Next()
+			return
+		}
+
+		var requestBody interface{}
+		if c.Request.Body != nil {
+			body, err := ioutil.ReadAll(c.Request.Body)
+			if err != nil {
+				logger.HandlerLog.Errorf("Read request body for audit err: %+v", err)
+			}
+			c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if len(body) != 0 {
+				if err := json.Unmarshal(body, &requestBody); err != nil {
+					requestBody = nil
+				}
+			}
+		}
+
+		c.Next()
+
+		callerNfType, callerNfInstanceId := util.CallerNf(c.Request.Header)
+		record := &Record{
+			Timestamp:          time.Now().UTC().Format(time.RFC3339Nano),
+			CallerNfType:       callerNfType,
+			CallerNfInstanceId: callerNfInstanceId,
+			ClientIp:           c.ClientIP(),
+			Method:             c.Request.Method,
+			ResourceUri:        c.Request.URL.Path,
+			Status:             c.Writer.Status(),
+			Outcome:            OUTCOME_SUCCESS,
+			Request:            Redact(requestBody),
+			UeId:               ueIdOf(c),
+		}
+		if record.Status >= http.StatusBadRequest {
+			record.Outcome = OUTCOME_FAILURE
+		}
+		if err := Write(record); err != nil {
+			logger.HandlerLog.Errorf("Write audit record err: %+v", err)
+		}
+	}
+}
+
+// UeIdParam returns the :ueId of the request.
+func UeIdParam(c *gin.Context) string {
+	return c.Param("ueId")
+}
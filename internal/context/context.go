@@ -15,8 +15,12 @@ type UDRServiceType int
 
 const (
 	NUDR_DR UDRServiceType = iota
+	NUDR_GROUP_ID_MAP
 )
 
+// The openapi models have no service name for Nudr_GroupIDmap.
+const ServiceName_NUDR_GROUP_ID_MAP models.ServiceName = "nudr-group-id-map"
+
 func init() {
 	UDR_Self().Name = "udr"
 	UDR_Self().EeSubscriptionIDGenerator = 1
@@ -43,7 +47,14 @@ type UDRContext struct {
 	UEGroupCollection                       sync.Map // map[ueGroupId]*UEGroupSubsData
 	SubscriptionDataSubscriptionIDGenerator int
 	SubscriptionDataSubscriptions           map[subsId]*models.SubscriptionDataSubscriptions
+	// SubscriptionDataSubscriptionsMtx guards SubscriptionDataSubscriptions and
+	// SubscriptionDataSubscriptionIDGenerator.
+	SubscriptionDataSubscriptionsMtx        sync.RWMutex
 	PolicyDataSubscriptions                 map[subsId]*models.PolicyDataSubscription
+	EnableHistory                           bool
+	ArchiveExpiredBdtData                   bool
+	AdminBindingIPv4                        string
+	AdminPort                               int // 0 when the administration API is not served
 	appDataInfluDataSubscriptionIdGenerator uint64
 	mtx                                     sync.RWMutex
 }
@@ -51,15 +62,26 @@ type UDRContext struct {
 type UESubsData struct {
 	EeSubscriptionCollection map[subsId]*EeSubscriptionCollection
 	SdmSubscriptions         map[subsId]*models.SdmSubscription
+	// EeMtx guards EeSubscriptionCollection and its entries, which the EE
+	// subscription handlers and the reporting of monitoring events share.
+	EeMtx sync.Mutex
 }
 
 type UEGroupSubsData struct {
 	EeSubscriptions map[subsId]*models.EeSubscription
+	// NumOfReports counts the monitoring reports sent for each member SUPI, as
+	// the maxNumOfReports of a group subscription applies to every member.
+	NumOfReports map[subsId]map[string]int32
+	// EeMtx guards EeSubscriptions and NumOfReports.
+	EeMtx sync.Mutex
 }
 
 type EeSubscriptionCollection struct {
 	EeSubscriptions      *models.EeSubscription
 	AmfSubscriptionInfos []models.AmfSubscriptionInfo
+	// NumOfReports counts the monitoring reports sent for the subscription,
+	// which ends when it reaches the maxNumOfReports of its reporting options.
+	NumOfReports int32
 }
 
 // Reset UDR Context
@@ -72,15 +94,17 @@ func (context *UDRContext) Reset() {
 		context.UEGroupCollection.Delete(key)
 		return true
 	})
+	context.SubscriptionDataSubscriptionsMtx.Lock()
 	for key := range context.SubscriptionDataSubscriptions {
 		delete(context.SubscriptionDataSubscriptions, key)
 	}
+	context.SubscriptionDataSubscriptionIDGenerator = 1
+	context.SubscriptionDataSubscriptionsMtx.Unlock()
 	for key := range context.PolicyDataSubscriptions {
 		delete(context.PolicyDataSubscriptions, key)
 	}
 	context.EeSubscriptionIDGenerator = 1
 	context.SdmSubscriptionIDGenerator = 1
-	context.SubscriptionDataSubscriptionIDGenerator = 1
 	context.PolicyDataSubscriptionIDGenerator = 1
 	context.UriScheme = models.UriScheme_HTTPS
 	context.Name = "udr"
@@ -90,12 +114,20 @@ func (context *UDRContext) GetIPv4Uri() string {
 	return fmt.Sprintf("%s://%s:%d", context.UriScheme, context.RegisterIPv4, context.SBIPort)
 }
 
+// GetAdminUri returns the root of the administration API, which is served over
+// plain HTTP on its own listener.
+func (context *UDRContext) GetAdminUri() string {
+	return fmt.Sprintf("http://%s:%d/nudr-admin/v1", context.AdminBindingIPv4, context.AdminPort)
+}
+
 func (context *UDRContext) GetIPv4GroupUri(udrServiceType UDRServiceType) string {
 	var serviceUri string
 
 	switch udrServiceType {
 	case NUDR_DR:
 		serviceUri = "/nudr-dr/v1"
+	case NUDR_GROUP_ID_MAP:
+		serviceUri = "/nudr-group-id-map/v1"
 	default:
 		serviceUri = ""
 	}
@@ -0,0 +1,234 @@
+// Package database offers the MongoDB operations of the UDR with an explicit
+// context, so that several of them can run in one transaction.
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/pkg/factory"
+	"github.com/free5gc/util/mongoapi"
+)
+
+var (
+	transactionsMu        sync.Mutex
+	transactionsSupported *bool
+)
+
+// Collection returns the collection collName of the UDR database.
+func Collection(collName string) *mongo.Collection {
+	return mongoapi.Client.Database(factory.UdrConfig.Configuration.Mongodb.Name).Collection(collName)
+}
+
+// supportsTransactions reports whether the deployment is a replica set or a
+// sharded cluster, on which MongoDB offers multi-document transactions.
+func supportsTransactions(ctx context.Context) bool {
+	transactionsMu.Lock()
+	defer transactionsMu.Unlock()
+	if transactionsSupported != nil {
+		return *transactionsSupported
+	}
+
+	var hello bson.M
+	if err := mongoapi.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).
+		Decode(&hello); err != nil {
+		logger.UtilLog.Warnf("Check MongoDB topology err: %+v", err)
+		return false
+	}
+	_, isReplicaSet := hello["setName"]
+	supported := isReplicaSet || hello["msg"] == "isdbgrid"
+	if !supported {
+		logger.UtilLog.Warnln("MongoDB is a standalone server, composite writes are not atomic")
+	}
+	transactionsSupported = &supported
+	return supported
+}
+
+// WithTransaction runs fn in a transaction with snapshot reads when the
+// deployment supports it, committing when fn returns nil and rolling back
+// otherwise. On a standalone server fn runs with no transaction. fn can be
+// retried on transient errors, so it must do nothing but database operations
+// with the context it is given.
+func WithTransaction(fn func(ctx context.Context) error) error {
+	ctx := context.TODO()
+	if !supportsTransactions(ctx) {
+		return fn(ctx)
+	}
+
+	session, err := mongoapi.Client.StartSession()
+	if err != nil {
+		return fmt.Errorf("StartSession err: %+v", err)
+	}
+	defer session.EndSession(ctx)
+
+	opts := options.Transaction().
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	}, opts)
+	return err
+}
+
+// GetOne returns the document matching filter without its _id, or nil when
+// there is none.
+func GetOne(ctx context.Context, collName string, filter bson.M) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := Collection(collName).FindOne(ctx, filter).Decode(&result); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetOne %s err: %+v", collName, err)
+	}
+	delete(result, "_id")
+	return result, nil
+}
+
+// GetMany returns the documents matching filter without their _id.
+func GetMany(ctx context.Context, collName string, filter bson.M) ([]map[string]interface{}, error) {
+	cur, err := Collection(collName).Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("GetMany %s err: %+v", collName, err)
+	}
+	var results []map[string]interface{}
+	if err = cur.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("GetMany %s err: %+v", collName, err)
+	}
+	for _, result := range results {
+		delete(result, "_id")
+	}
+	return results, nil
+}
+
+// PutOne sets the attributes of data in the document matching filter, or
+// inserts data when there is none. It reports whether a document matched.
+func PutOne(ctx context.Context, collName string, filter bson.M, data map[string]interface{}) (bool, error) {
+	result, err := Collection(collName).UpdateOne(ctx, filter, bson.M{"$set": data},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		return false, fmt.Errorf("PutOne %s err: %+v", collName, err)
+	}
+	return result.MatchedCount != 0, nil
+}
+
+// ReplaceOne replaces the whole document matching filter, so that attributes
+// absent from data are removed, or inserts data when there is none. It reports
+// whether a document matched.
+func ReplaceOne(ctx context.Context, collName string, filter bson.M, data map[string]interface{}) (bool, error) {
+	result, err := Collection(collName).ReplaceOne(ctx, filter, data, options.Replace().SetUpsert(true))
+	if err != nil {
+		return false, fmt.Errorf("ReplaceOne %s err: %+v", collName, err)
+	}
+	return result.MatchedCount != 0, nil
+}
+
+// MergePatch applies patch as a JSON merge patch to the document matching
+// filter. It reports whether a document matched.
+func MergePatch(ctx context.Context, collName string, filter bson.M, patch map[string]interface{}) (bool, error) {
+	original, err := GetOne(ctx, collName, filter)
+	if err != nil {
+		return false, err
+	}
+	if original == nil {
+		return false, nil
+	}
+	originalJson, err := json.Marshal(original)
+	if err != nil {
+		return false, fmt.Errorf("MergePatch %s err: %+v", collName, err)
+	}
+	patchJson, err := json.Marshal(patch)
+	if err != nil {
+		return false, fmt.Errorf("MergePatch %s err: %+v", collName, err)
+	}
+	modifiedJson, err := jsonpatch.MergePatch(originalJson, patchJson)
+	if err != nil {
+		return false, fmt.Errorf("MergePatch %s err: %+v", collName, err)
+	}
+	var modified map[string]interface{}
+	if err = json.Unmarshal(modifiedJson, &modified); err != nil {
+		return false, fmt.Errorf("MergePatch %s err: %+v", collName, err)
+	}
+	if _, err = Collection(collName).ReplaceOne(ctx, filter, modified); err != nil {
+		return false, fmt.Errorf("MergePatch %s err: %+v", collName, err)
+	}
+	return true, nil
+}
+
+// Increment atomically adds the values of inc to the numeric attributes they
+// are keyed by, in dot notation, of the document matching filter. It returns
+// the document without its _id after the update, or nil when none matched.
+func Increment(ctx context.Context, collName string, filter bson.M,
+	inc map[string]interface{},
+) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := Collection(collName).FindOneAndUpdate(ctx, filter, bson.M{"$inc": inc},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Increment %s err: %+v", collName, err)
+	}
+	delete(result, "_id")
+	return result, nil
+}
+
+// InsertMany inserts every document of data.
+func InsertMany(ctx context.Context, collName string, data []interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if _, err := Collection(collName).InsertMany(ctx, data); err != nil {
+		return fmt.Errorf("InsertMany %s err: %+v", collName, err)
+	}
+	return nil
+}
+
+// DeleteMany deletes the documents matching filter and returns their number.
+func DeleteMany(ctx context.Context, collName string, filter bson.M) (int64, error) {
+	result, err := Collection(collName).DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteMany %s err: %+v", collName, err)
+	}
+	return result.DeletedCount, nil
+}
+
+// Pull removes the array elements matching the conditions of pull, keyed by
+// the array attribute, from the documents matching filter. It returns the
+// number of documents modified.
+func Pull(ctx context.Context, collName string, filter bson.M, pull map[string]interface{}) (int64, error) {
+	result, err := Collection(collName).UpdateMany(ctx, filter, bson.M{"$pull": pull})
+	if err != nil {
+		return 0, fmt.Errorf("Pull %s err: %+v", collName, err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// Count returns the number of documents matching filter.
+func Count(ctx context.Context, collName string, filter bson.M) (int64, error) {
+	count, err := Collection(collName).CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("Count %s err: %+v", collName, err)
+	}
+	return count, nil
+}
+
+// Distinct returns the distinct values of field in the documents matching
+// filter.
+func Distinct(ctx context.Context, collName string, field string, filter bson.M) ([]interface{}, error) {
+	values, err := Collection(collName).Distinct(ctx, field, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Distinct %s err: %+v", collName, err)
+	}
+	return values, nil
+}
@@ -0,0 +1,165 @@
+// Package identity resolves the SUPI and GPSI forms of a UE identity to the
+// SUPI the data of the UE is stored under.
+package identity
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/repository"
+)
+
+// MapCollName maps every known GPSI, as "identity", to the SUPI of its UE.
+const MapCollName = "subscriptionData.identityMap"
+
+// ErrUnknown reports a UE identity that no UE is known by.
+var ErrUnknown = errors.New("unknown UE identity")
+
+func IsSupi(ueId string) bool {
+	return strings.HasPrefix(ueId, "imsi-") || strings.HasPrefix(ueId, "nai-")
+}
+
+func IsGpsi(ueId string) bool {
+	return strings.HasPrefix(ueId, "msisdn-") || strings.HasPrefix(ueId, "extid-")
+}
+
+// Resolve returns the SUPI ueId stands for. A SUPI, like an identity of no
+// known form, stands for itself, and is known when it has an authentication
+// subscription or GPSIs in the identity map. A GPSI is looked up in the
+// identity map and then in the GPSIs of the stored subscription data, which
+// completes the map for data provisioned without the UDR. It returns
+// ErrUnknown for a SUPI or a GPSI that is not known.
+func Resolve(ctx context.Context, ueId string) (string, error) {
+	if IsSupi(ueId) {
+		known, err := isKnownSupi(ctx, ueId)
+		if err != nil {
+			return "", err
+		}
+		if !known {
+			return "", ErrUnknown
+		}
+		return ueId, nil
+	}
+	if !IsGpsi(ueId) {
+		return ueId, nil
+	}
+
+	doc, err := database.GetOne(ctx, MapCollName, bson.M{"identity": ueId})
+	if err != nil {
+		return "", err
+	}
+	if supi, ok := doc["supi"].(string); ok {
+		return supi, nil
+	}
+
+	for _, source := range []struct {
+		collName string
+		field    string
+	}{
+		{repository.AmDataCollName, "gpsis"},
+		{repository.IdentityDataCollName, "gpsiList"},
+	} {
+		doc, err = database.GetOne(ctx, source.collName, bson.M{source.field: ueId})
+		if err != nil {
+			return "", err
+		}
+		if supi, ok := doc["ueId"].(string); ok {
+			if _, err = database.ReplaceOne(ctx, MapCollName, bson.M{"identity": ueId},
+				bson.M{"identity": ueId, "supi": supi}); err != nil {
+				logger.DataRepoLog.Warnf("Record identity %s err: %+v", ueId, err)
+			}
+			return supi, nil
+		}
+	}
+	return "", ErrUnknown
+}
+
+func isKnownSupi(ctx context.Context, supi string) (bool, error) {
+	count, err := database.Count(ctx, repository.AuthSubsCollName, bson.M{"ueId": supi})
+	if err != nil || count != 0 {
+		return count != 0, err
+	}
+	count, err = database.Count(ctx, MapCollName, bson.M{"supi": supi})
+	return count != 0, err
+}
+
+// Record maps gpsis to supi, replacing the GPSIs mapped to supi before. A GPSI
+// mapped to another SUPI is moved to supi.
+func Record(ctx context.Context, supi string, gpsis []string) error {
+	filter := bson.M{"supi": supi}
+	if len(gpsis) != 0 {
+		filter["identity"] = bson.M{"$nin": gpsis}
+	}
+	if _, err := database.DeleteMany(ctx, MapCollName, filter); err != nil {
+		return err
+	}
+	for _, gpsi := range gpsis {
+		if _, err := database.ReplaceOne(ctx, MapCollName, bson.M{"identity": gpsi},
+			bson.M{"identity": gpsi, "supi": supi}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordAmData maps the GPSIs of the stored access and mobility subscription
+// data of supi, for every serving PLMN, to supi, replacing the GPSIs mapped to
+// supi before.
+func RecordAmData(ctx context.Context, supi string) error {
+	docs, err := database.GetMany(ctx, repository.AmDataCollName, bson.M{"ueId": supi})
+	if err != nil {
+		return err
+	}
+	var gpsis []string
+	for _, doc := range docs {
+		gpsis = append(gpsis, GpsisOf(doc)...)
+	}
+	return Record(ctx, supi, gpsis)
+}
+
+// Forget removes the GPSIs mapped to supi.
+func Forget(ctx context.Context, supi string) error {
+	_, err := database.DeleteMany(ctx, MapCollName, bson.M{"supi": supi})
+	return err
+}
+
+// Gpsis returns the GPSIs mapped to supi, sorted.
+func Gpsis(ctx context.Context, supi string) ([]string, error) {
+	values, err := database.Distinct(ctx, MapCollName, "identity", bson.M{"supi": supi})
+	if err != nil {
+		return nil, err
+	}
+	gpsis := make([]string, 0, len(values))
+	for _, value := range values {
+		if gpsi, ok := value.(string); ok {
+			gpsis = append(gpsis, gpsi)
+		}
+	}
+	sort.Strings(gpsis)
+	return gpsis, nil
+}
+
+// GpsisOf returns the GPSIs of an access and mobility subscription data
+// document.
+func GpsisOf(amData map[string]interface{}) []string {
+	var values []interface{}
+	switch v := amData["gpsis"].(type) {
+	case []interface{}:
+		values = v
+	case bson.A:
+		values = v
+	}
+	var gpsis []string
+	for _, value := range values {
+		if gpsi, ok := value.(string); ok {
+			gpsis = append(gpsis, gpsi)
+		}
+	}
+	return gpsis
+}
@@ -0,0 +1,50 @@
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	bdtDataCollName = "policyData.bdtData"
+	// Same field as producer.BDTDATA_WINDOW_END_FIELD.
+	bdtDataWindowEndField = "recTimeIntStopDate"
+)
+
+var bdtDataWindowEndIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: bdtDataWindowEndField, Value: 1}},
+	Options: options.Index().SetName(bdtDataWindowEndField),
+}
+
+func bdtDataWindowUp() error {
+	if err := rewriteCollection(bdtDataCollName, func(doc map[string]interface{}) bson.M {
+		transPolicy, ok := doc["transPolicy"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		recTimeInt, ok := transPolicy["recTimeInt"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if t, ok := validityDate(recTimeInt["stopTime"]); ok {
+			return bson.M{bdtDataWindowEndField: t}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	_, err := collection(bdtDataCollName).Indexes().CreateOne(context.TODO(), bdtDataWindowEndIndex)
+	return err
+}
+
+func bdtDataWindowDown() error {
+	coll := collection(bdtDataCollName)
+	if _, err := coll.Indexes().DropOne(context.TODO(), *bdtDataWindowEndIndex.Options.Name); err != nil {
+		return err
+	}
+	_, err := coll.UpdateMany(context.TODO(), bson.M{}, bson.M{"$unset": bson.M{bdtDataWindowEndField: ""}})
+	return err
+}
@@ -0,0 +1,109 @@
+package migration
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/udr/internal/util"
+)
+
+// Layout version 1 stored DNN keys with every '.' replaced by '_', which cannot
+// be told apart from a DNN that really contains '_'. Such keys are decoded the
+// way the UDR always read them.
+func legacyEscapeDnn(dnn string) string {
+	return strings.ReplaceAll(dnn, ".", "_")
+}
+
+func legacyUnescapeDnn(dnnKey string) string {
+	return strings.ReplaceAll(dnnKey, "_", ".")
+}
+
+// legacyToEscapedDnn leaves keys that hold percent-encodings as they are, so
+// that keys written in the current layout before the database was migrated are
+// not escaped twice.
+func legacyToEscapedDnn(dnnKey string) string {
+	if util.UnescapeDnn(dnnKey) != dnnKey {
+		return dnnKey
+	}
+	return util.EscapeDnn(legacyUnescapeDnn(dnnKey))
+}
+
+func escapedToLegacyDnn(dnnKey string) string {
+	return legacyEscapeDnn(util.UnescapeDnn(dnnKey))
+}
+
+// renameKeys returns a copy of m with every key converted by conv, and whether
+// any key changed.
+func renameKeys(m map[string]interface{}, conv func(string) string) (map[string]interface{}, bool) {
+	renamed := make(map[string]interface{}, len(m))
+	changed := false
+	for k, v := range m {
+		newKey := conv(k)
+		if newKey != k {
+			changed = true
+		}
+		renamed[newKey] = v
+	}
+	return renamed, changed
+}
+
+func rewriteSmDataDnnKeys(conv func(string) string) func(doc map[string]interface{}) bson.M {
+	return func(doc map[string]interface{}) bson.M {
+		dnnConfigurations, ok := doc["dnnConfigurations"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		renamed, changed := renameKeys(dnnConfigurations, conv)
+		if !changed {
+			return nil
+		}
+		return bson.M{"dnnConfigurations": renamed}
+	}
+}
+
+func rewriteSmPolicyDataDnnKeys(conv func(string) string) func(doc map[string]interface{}) bson.M {
+	return func(doc map[string]interface{}) bson.M {
+		smPolicySnssaiData, ok := doc["smPolicySnssaiData"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		changed := false
+		for snssai, value := range smPolicySnssaiData {
+			snssaiData, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			dnnData, ok := snssaiData["smPolicyDnnData"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			renamed, dnnChanged := renameKeys(dnnData, conv)
+			if dnnChanged {
+				snssaiData["smPolicyDnnData"] = renamed
+				smPolicySnssaiData[snssai] = snssaiData
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		return bson.M{"smPolicySnssaiData": smPolicySnssaiData}
+	}
+}
+
+func rewriteDnnKeys(conv func(string) string) error {
+	if err := rewriteCollection("subscriptionData.provisionedData.smData",
+		rewriteSmDataDnnKeys(conv)); err != nil {
+		return err
+	}
+	return rewriteCollection("policyData.ues.smData", rewriteSmPolicyDataDnnKeys(conv))
+}
+
+func dnnKeyUp() error {
+	return rewriteDnnKeys(legacyToEscapedDnn)
+}
+
+func dnnKeyDown() error {
+	return rewriteDnnKeys(escapedToLegacyDnn)
+}
@@ -0,0 +1,27 @@
+package migration
+
+import "testing"
+
+func TestLegacyToEscapedDnn(t *testing.T) {
+	testCases := []struct {
+		dnnKey string
+		want   string
+	}{
+		{"internet", "internet"},
+		{"ims_mnc001_gprs", "ims%2Emnc001%2Egprs"},
+		{"a$b", "a%24b"},
+		// Keys already in the current layout are left as they are
+		{"ims%2Emnc001", "ims%2Emnc001"},
+		{"a_b%2Ec", "a_b%2Ec"},
+		{"100%25", "100%25"},
+	}
+	for _, tc := range testCases {
+		once := legacyToEscapedDnn(tc.dnnKey)
+		if once != tc.want {
+			t.Errorf("legacyToEscapedDnn(%q) = %q, want %q", tc.dnnKey, once, tc.want)
+		}
+		if twice := legacyToEscapedDnn(once); twice != once {
+			t.Errorf("legacyToEscapedDnn(%q) = %q, want it unchanged", once, twice)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Same collection as producer.HISTORY_DB_COLLECTION_NAME.
+const (
+	historyCollName            = "udr.history"
+	historyIndexName           = "ueId_resourceUri_timestamp"
+	sharedDataHistoryIndexName = "sharedDataId_resourceUri_timestamp"
+)
+
+func historyIndexUp() error {
+	_, err := collection(historyCollName).Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "ueId", Value: 1},
+			{Key: "resourceUri", Value: 1},
+			{Key: "timestamp", Value: 1},
+		},
+		Options: options.Index().SetName(historyIndexName),
+	})
+	return err
+}
+
+func historyIndexDown() error {
+	_, err := collection(historyCollName).Indexes().DropOne(context.TODO(), historyIndexName)
+	return err
+}
+
+// The changes of shared data are recorded under the sharedDataId instead of a
+// ueId. The index is sparse as the changes of UE data have no sharedDataId.
+func sharedDataHistoryIndexUp() error {
+	_, err := collection(historyCollName).Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "sharedDataId", Value: 1},
+			{Key: "resourceUri", Value: 1},
+			{Key: "timestamp", Value: 1},
+		},
+		Options: options.Index().SetName(sharedDataHistoryIndexName).SetSparse(true),
+	})
+	return err
+}
+
+func sharedDataHistoryIndexDown() error {
+	_, err := collection(historyCollName).Indexes().DropOne(context.TODO(), sharedDataHistoryIndexName)
+	return err
+}
@@ -0,0 +1,76 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/free5gc/udr/internal/logger"
+)
+
+// Same collections as identity.MapCollName and repository.AmDataCollName.
+const (
+	identityMapCollName          = "subscriptionData.identityMap"
+	amDataCollName               = "subscriptionData.provisionedData.amData"
+	identityMapIdentityIndexName = "identity"
+	identityMapSupiIndexName     = "supi"
+)
+
+// identityMapUp indexes the identity map and fills it with the GPSIs of the
+// stored access and mobility subscription data.
+func identityMapUp() error {
+	ctx := context.TODO()
+	coll := collection(identityMapCollName)
+	if _, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "identity", Value: 1}},
+			Options: options.Index().SetName(identityMapIdentityIndexName).SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "supi", Value: 1}},
+			Options: options.Index().SetName(identityMapSupiIndexName),
+		},
+	}); err != nil {
+		return err
+	}
+
+	cur, err := collection(amDataCollName).Find(ctx, bson.M{"gpsis": bson.M{"$exists": true}})
+	if err != nil {
+		return fmt.Errorf("identityMapUp err: %+v", err)
+	}
+	defer func() {
+		if err := cur.Close(ctx); err != nil {
+			logger.MigrateLog.Warnf("identityMapUp close cursor err: %+v", err)
+		}
+	}()
+
+	count := 0
+	for cur.Next(ctx) {
+		var doc struct {
+			UeId  string   `bson:"ueId"`
+			Gpsis []string `bson:"gpsis"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return fmt.Errorf("identityMapUp err: %+v", err)
+		}
+		for _, gpsi := range doc.Gpsis {
+			if _, err := coll.ReplaceOne(ctx, bson.M{"identity": gpsi}, bson.M{"identity": gpsi, "supi": doc.UeId},
+				options.Replace().SetUpsert(true)); err != nil {
+				return fmt.Errorf("identityMapUp ReplaceOne err: %+v", err)
+			}
+			count++
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return fmt.Errorf("identityMapUp err: %+v", err)
+	}
+	logger.MigrateLog.Infof("Mapped %d GPSIs in %s", count, identityMapCollName)
+	return nil
+}
+
+func identityMapDown() error {
+	return collection(identityMapCollName).Drop(context.TODO())
+}
@@ -0,0 +1,50 @@
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Same collection as producer.APPDATA_INFLUDATA_DB_COLLECTION_NAME.
+const influenceDataCollName = "applicationData.influenceData"
+
+// Indexes backing the query parameters of GET /application-data/influenceData.
+var influenceDataIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "influenceId", Value: 1}},
+		Options: options.Index().SetName("influenceId"),
+	},
+	{
+		Keys:    bson.D{{Key: "dnn", Value: 1}},
+		Options: options.Index().SetName("dnn"),
+	},
+	{
+		Keys:    bson.D{{Key: "snssai.sst", Value: 1}, {Key: "snssai.sd", Value: 1}},
+		Options: options.Index().SetName("snssai"),
+	},
+	{
+		Keys:    bson.D{{Key: "interGroupId", Value: 1}},
+		Options: options.Index().SetName("interGroupId"),
+	},
+	{
+		Keys:    bson.D{{Key: "supi", Value: 1}},
+		Options: options.Index().SetName("supi"),
+	},
+}
+
+func influenceDataIndexUp() error {
+	_, err := collection(influenceDataCollName).Indexes().CreateMany(context.TODO(), influenceDataIndexes)
+	return err
+}
+
+func influenceDataIndexDown() error {
+	for _, index := range influenceDataIndexes {
+		if _, err := collection(influenceDataCollName).Indexes().DropOne(context.TODO(), *index.Options.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
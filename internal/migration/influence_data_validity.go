@@ -0,0 +1,76 @@
+package migration
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Same fields as producer.INFLUDATA_VALID_START_FIELD and
+// producer.INFLUDATA_VALID_END_FIELD.
+const (
+	influenceDataValidStartField = "validStartDate"
+	influenceDataValidEndField   = "validEndDate"
+)
+
+var influenceDataValidityIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: influenceDataValidStartField, Value: 1}},
+		Options: options.Index().SetName(influenceDataValidStartField),
+	},
+	{
+		Keys:    bson.D{{Key: influenceDataValidEndField, Value: 1}},
+		Options: options.Index().SetName(influenceDataValidEndField),
+	},
+}
+
+// validityDate converts a stored validStartTime or validEndTime to a date.
+func validityDate(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		return t.UTC(), err == nil
+	case primitive.DateTime:
+		return v.Time().UTC(), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func influenceDataValidityUp() error {
+	if err := rewriteCollection(influenceDataCollName, func(doc map[string]interface{}) bson.M {
+		setData := bson.M{}
+		if t, ok := validityDate(doc["validStartTime"]); ok {
+			setData[influenceDataValidStartField] = t
+		}
+		if t, ok := validityDate(doc["validEndTime"]); ok {
+			setData[influenceDataValidEndField] = t
+		}
+		if len(setData) == 0 {
+			return nil
+		}
+		return setData
+	}); err != nil {
+		return err
+	}
+	_, err := collection(influenceDataCollName).Indexes().CreateMany(context.TODO(), influenceDataValidityIndexes)
+	return err
+}
+
+func influenceDataValidityDown() error {
+	coll := collection(influenceDataCollName)
+	for _, index := range influenceDataValidityIndexes {
+		if _, err := coll.Indexes().DropOne(context.TODO(), *index.Options.Name); err != nil {
+			return err
+		}
+	}
+	_, err := coll.UpdateMany(context.TODO(), bson.M{}, bson.M{"$unset": bson.M{
+		influenceDataValidStartField: "",
+		influenceDataValidEndField:   "",
+	}})
+	return err
+}
@@ -0,0 +1,327 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/pkg/factory"
+	"github.com/free5gc/util/mongoapi"
+)
+
+const (
+	SCHEMA_VERSION_DB_COLLECTION_NAME = "udr.schemaVersion"
+	SCHEMA_VERSION_COMPONENT          = "udr"
+)
+
+// Migration is one step of the stored document layout. Up converts data written
+// with layout Version-1 into layout Version, Down reverts it.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func() error
+	Down        func() error
+}
+
+// Step is the status of a registered migration against the connected database.
+type Step struct {
+	Version     int
+	Description string
+	Applied     bool
+}
+
+// migrations must be kept sorted by Version, starting at 1 without gaps.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Description: "baseline layout: ueId/servingPlmnId embedded in documents, " +
+			"snssai hex keys and '.'->'_' escaped DNN keys",
+		Up:   noop,
+		Down: noop,
+	},
+	{
+		Version:     2,
+		Description: "percent-encoded DNN keys in sm-data dnnConfigurations and sm policy smPolicyDnnData",
+		Up:          dnnKeyUp,
+		Down:        dnnKeyDown,
+	},
+	{
+		Version:     3,
+		Description: "index on ueId, resourceUri and timestamp of the change history",
+		Up:          historyIndexUp,
+		Down:        historyIndexDown,
+	},
+	{
+		Version:     4,
+		Description: "indexes on the traffic influence data query attributes",
+		Up:          influenceDataIndexUp,
+		Down:        influenceDataIndexDown,
+	},
+	{
+		Version:     5,
+		Description: "queryable validity window dates in the traffic influence data",
+		Up:          influenceDataValidityUp,
+		Down:        influenceDataValidityDown,
+	},
+	{
+		Version:     6,
+		Description: "queryable transfer window end dates in the BDT data",
+		Up:          bdtDataWindowUp,
+		Down:        bdtDataWindowDown,
+	},
+	{
+		Version:     7,
+		Description: "indexed map of the GPSIs to the SUPI of their UE",
+		Up:          identityMapUp,
+		Down:        identityMapDown,
+	},
+	{
+		Version:     8,
+		Description: "index on sharedDataId, resourceUri and timestamp of the change history",
+		Up:          sharedDataHistoryIndexUp,
+		Down:        sharedDataHistoryIndexDown,
+	},
+}
+
+func noop() error {
+	return nil
+}
+
+func collection(collName string) *mongo.Collection {
+	return mongoapi.Client.Database(factory.UdrConfig.Configuration.Mongodb.Name).Collection(collName)
+}
+
+// rewriteCollection passes every document of collName to rewrite and stores the
+// returned fields with $set. Documents for which rewrite returns nil are skipped.
+func rewriteCollection(collName string, rewrite func(doc map[string]interface{}) bson.M) error {
+	coll := collection(collName)
+	ctx := context.TODO()
+
+	cur, err := coll.Find(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("rewriteCollection %s err: %+v", collName, err)
+	}
+	defer func() {
+		if err := cur.Close(ctx); err != nil {
+			logger.MigrateLog.Warnf("rewriteCollection %s close cursor err: %+v", collName, err)
+		}
+	}()
+
+	count := 0
+	for cur.Next(ctx) {
+		var doc map[string]interface{}
+		if err := cur.Decode(&doc); err != nil {
+			return fmt.Errorf("rewriteCollection %s err: %+v", collName, err)
+		}
+		setData := rewrite(doc)
+		if setData == nil {
+			continue
+		}
+		if _, err := coll.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, bson.M{"$set": setData}); err != nil {
+			return fmt.Errorf("rewriteCollection %s UpdateOne err: %+v", collName, err)
+		}
+		count++
+	}
+	if err := cur.Err(); err != nil {
+		return fmt.Errorf("rewriteCollection %s err: %+v", collName, err)
+	}
+	logger.MigrateLog.Infof("Rewrote %d documents in %s", count, collName)
+	return nil
+}
+
+func schemaVersionFilter() bson.M {
+	return bson.M{"component": SCHEMA_VERSION_COMPONENT}
+}
+
+// LatestVersion returns the layout version this UDR release reads and writes.
+func LatestVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// CurrentVersion returns the layout version recorded in the database, or 0 when
+// the database has never been migrated.
+func CurrentVersion() (int, error) {
+	data, err := mongoapi.RestfulAPIGetOne(SCHEMA_VERSION_DB_COLLECTION_NAME, schemaVersionFilter())
+	if err != nil {
+		return 0, err
+	}
+	if data == nil {
+		return 0, nil
+	}
+	switch v := data["version"].(type) {
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	default:
+		return 0, fmt.Errorf("invalid schema version document: %+v", data)
+	}
+}
+
+func setCurrentVersion(version int) error {
+	putData := bson.M{
+		"component": SCHEMA_VERSION_COMPONENT,
+		"version":   version,
+		"updatedAt": time.Now().UTC().Format(time.RFC3339),
+	}
+	_, err := mongoapi.RestfulAPIPutOne(SCHEMA_VERSION_DB_COLLECTION_NAME, schemaVersionFilter(), putData)
+	return err
+}
+
+func checkMigrations() error {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return fmt.Errorf("migration %q has version %d, expected %d", m.Description, m.Version, i+1)
+		}
+		if m.Up == nil || m.Down == nil {
+			return fmt.Errorf("migration %d lacks an up or down step", m.Version)
+		}
+	}
+	return nil
+}
+
+// Up applies pending migrations until the database reaches target. A target of
+// 0 or less means the latest version.
+func Up(target int) error {
+	if err := checkMigrations(); err != nil {
+		return err
+	}
+	if target <= 0 {
+		target = LatestVersion()
+	}
+	if target > LatestVersion() {
+		return fmt.Errorf("unknown schema version %d, latest is %d", target, LatestVersion())
+	}
+
+	current, err := CurrentVersion()
+	if err != nil {
+		return err
+	}
+	if current > target {
+		return fmt.Errorf("schema version %d is newer than target %d, use down instead", current, target)
+	}
+
+	for _, m := range migrations[current:target] {
+		logger.MigrateLog.Infof("Migrating up to version %d: %s", m.Version, m.Description)
+		if err := m.Up(); err != nil {
+			return fmt.Errorf("migration %d up: %w", m.Version, err)
+		}
+		if err := setCurrentVersion(m.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down reverts applied migrations until the database reaches target. A negative
+// target means one step below the current version.
+func Down(target int) error {
+	if err := checkMigrations(); err != nil {
+		return err
+	}
+
+	current, err := CurrentVersion()
+	if err != nil {
+		return err
+	}
+	if current > LatestVersion() {
+		return fmt.Errorf("schema version %d is unknown to this release, latest is %d", current, LatestVersion())
+	}
+	if target < 0 {
+		target = current - 1
+	}
+	if target < 0 || target > current {
+		return fmt.Errorf("cannot migrate down from version %d to %d", current, target)
+	}
+
+	for i := current; i > target; i-- {
+		m := migrations[i-1]
+		logger.MigrateLog.Infof("Migrating down from version %d: %s", m.Version, m.Description)
+		if err := m.Down(); err != nil {
+			return fmt.Errorf("migration %d down: %w", m.Version, err)
+		}
+		if err := setCurrentVersion(m.Version - 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status lists every registered migration and whether it has been applied.
+func Status() ([]Step, int, error) {
+	current, err := CurrentVersion()
+	if err != nil {
+		return nil, 0, err
+	}
+	steps := make([]Step, 0, len(migrations))
+	for _, m := range migrations {
+		steps = append(steps, Step{
+			Version:     m.Version,
+			Description: m.Description,
+			Applied:     m.Version <= current,
+		})
+	}
+	return steps, current, nil
+}
+
+// isEmptyDatabase reports whether the database holds no collection besides the
+// schema version.
+func isEmptyDatabase() (bool, error) {
+	collNames, err := mongoapi.Client.Database(factory.UdrConfig.Configuration.Mongodb.Name).
+		ListCollectionNames(context.TODO(), bson.M{})
+	if err != nil {
+		return false, err
+	}
+	for _, collName := range collNames {
+		if collName != SCHEMA_VERSION_DB_COLLECTION_NAME {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// CheckOnStartup makes sure the database layout matches this release before
+// the SBI server starts. Every migration is applied to an empty database, on
+// which the data rewrites are no-ops but the indexes are created. Pending
+// migrations are applied to other databases when autoMigrate is set.
+func CheckOnStartup(autoMigrate bool) error {
+	current, err := CurrentVersion()
+	if err != nil {
+		return err
+	}
+	latest := LatestVersion()
+
+	if current == 0 {
+		empty, err := isEmptyDatabase()
+		if err != nil {
+			return err
+		}
+		if empty {
+			logger.MigrateLog.Infof("Empty database, migrating up to schema version [%d]", latest)
+			return Up(latest)
+		}
+	}
+
+	switch {
+	case current > latest:
+		return fmt.Errorf("database schema version %d is newer than supported version %d", current, latest)
+	case current == latest:
+		logger.MigrateLog.Infof("Database schema version [%d]", current)
+		return nil
+	case autoMigrate:
+		return Up(latest)
+	default:
+		logger.MigrateLog.Warnf("Database schema version is [%d] but expected [%d], run \"udr migrate up\"",
+			current, latest)
+		return nil
+	}
+}
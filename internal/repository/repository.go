@@ -0,0 +1,892 @@
+// Package repository reads the data sets stored by the UDR as their openapi
+// models.
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/util"
+)
+
+const (
+	AuthSubsCollName               = "subscriptionData.authenticationData.authenticationSubscription"
+	AuthStatusCollName             = "subscriptionData.authenticationData.authenticationStatus"
+	AmDataCollName                 = "subscriptionData.provisionedData.amData"
+	SmfSelDataCollName             = "subscriptionData.provisionedData.smfSelectionSubscriptionData"
+	SmDataCollName                 = "subscriptionData.provisionedData.smData"
+	SmsDataCollName                = "subscriptionData.provisionedData.smsData"
+	SmsMngDataCollName             = "subscriptionData.provisionedData.smsMngData"
+	TraceDataCollName              = "subscriptionData.provisionedData.traceData"
+	EeProfileDataCollName          = "subscriptionData.eeProfileData"
+	OdbDataCollName                = "subscriptionData.operatorDeterminedBarringData"
+	OperatorSpecDataCollName       = "subscriptionData.operatorSpecificData"
+	PpDataCollName                 = "subscriptionData.ppData"
+	SorDataCollName                = "subscriptionData.ueUpdateConfirmationData.sorData"
+	SmfRegistrationsCollName       = "subscriptionData.contextData.smfRegistrations"
+	Amf3gppAccessCollName          = "subscriptionData.contextData.amf3gppAccess"
+	AmfNon3gppAccessCollName       = "subscriptionData.contextData.amfNon3gppAccess"
+	Smsf3gppAccessCollName         = "subscriptionData.contextData.smsf3gppAccess"
+	SmsfNon3gppAccessCollName      = "subscriptionData.contextData.smsfNon3gppAccess"
+	AmPolicyDataCollName           = "policyData.ues.amData"
+	UePolicySetCollName            = "policyData.ues.uePolicySet"
+	SmPolicyDataCollName           = "policyData.ues.smData"
+	UsageMonDataCollName           = "policyData.ues.smData.usageMonData"
+	PolicyOperatorSpecDataCollName = "policyData.ues.operatorSpecificData"
+	SponsorConnDataCollName        = "policyData.sponsorConnectivityData"
+	PlmnUePolicySetCollName        = "policyData.plmns.uePolicySet"
+	GroupAmPolicyDataCollName      = "policyData.groups.amData"
+	GroupSmPolicyDataCollName      = "policyData.groups.smData"
+	GroupIdentifiersCollName       = "subscriptionData.groupData.groupIdentifiers"
+	NfGroupIdMapCollName           = "groupIdMap.nfGroupIds"
+	IdentityDataCollName           = "subscriptionData.identityData"
+	SharedDataCollName             = "subscriptionData.sharedData"
+)
+
+// AuthSubsSecretAttrs are the attributes of an authentication subscription that
+// hold key material. They are kept out of the change history, the audit trail
+// and the data change notifications.
+var AuthSubsSecretAttrs = []string{
+	"permanentKey", "encPermanentKey", "opc", "encOpcKey", "topc", "encTopcKey", "sequenceNumber", "milenage",
+	"tuak",
+}
+
+// secretAttrs lists, by collection, the attributes that hold key material.
+var secretAttrs = map[string][]string{
+	AuthSubsCollName: AuthSubsSecretAttrs,
+}
+
+// WithoutSecrets returns doc, a document of collName, or a copy of it without
+// the attributes that hold key material.
+func WithoutSecrets(collName string, doc map[string]interface{}) map[string]interface{} {
+	attrs := secretAttrs[collName]
+	if doc == nil || len(attrs) == 0 {
+		return doc
+	}
+	stripped := make(map[string]interface{}, len(doc))
+	for k, v := range doc {
+		stripped[k] = v
+	}
+	for _, attr := range attrs {
+		delete(stripped, attr)
+	}
+	return stripped
+}
+
+// internalFields are the attributes the UDR adds to stored documents to look
+// them up, which are not part of any data set.
+var internalFields = []string{"_id", "ueId", "servingPlmnId", "influenceId"}
+
+// DecodeError reports a stored document that does not match its model.
+type DecodeError struct {
+	CollName string
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode %s err: %+v", e.CollName, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// StripInternalFields removes the internal attributes from doc in place.
+func StripInternalFields(doc map[string]interface{}) map[string]interface{} {
+	for _, field := range internalFields {
+		delete(doc, field)
+	}
+	return doc
+}
+
+func decode(collName string, data interface{}, v interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return &DecodeError{CollName: collName, Err: err}
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		return &DecodeError{CollName: collName, Err: err}
+	}
+	return nil
+}
+
+// findOne decodes the document matching filter into v. It reports false when
+// there is none.
+func findOne(ctx context.Context, collName string, filter bson.M, v interface{}) (bool, error) {
+	doc, err := database.GetOne(ctx, collName, filter)
+	if err != nil || doc == nil {
+		return false, err
+	}
+	return true, decode(collName, StripInternalFields(doc), v)
+}
+
+// findMany decodes the documents matching filter into v, which points to a
+// slice.
+func findMany(ctx context.Context, collName string, filter bson.M, v interface{}) error {
+	docs, err := database.GetMany(ctx, collName, filter)
+	if err != nil {
+		return err
+	}
+	if docs == nil {
+		docs = []map[string]interface{}{}
+	}
+	for _, doc := range docs {
+		StripInternalFields(doc)
+	}
+	return decode(collName, docs, v)
+}
+
+func ueFilter(ueId string) bson.M {
+	return bson.M{"ueId": ueId}
+}
+
+func plmnFilter(ueId string, servingPlmnId string) bson.M {
+	return bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
+}
+
+func unescapeDnnKeys(escaped map[string]models.DnnConfiguration) map[string]models.DnnConfiguration {
+	if escaped == nil {
+		return nil
+	}
+	dnnConfigurations := make(map[string]models.DnnConfiguration, len(escaped))
+	for escapedDnn, dnnConf := range escaped {
+		dnnConfigurations[util.UnescapeDnn(escapedDnn)] = dnnConf
+	}
+	return dnnConfigurations
+}
+
+func unescapeDnnConfigurations(smData *models.SessionManagementSubscriptionData) {
+	smData.DnnConfigurations = unescapeDnnKeys(smData.DnnConfigurations)
+}
+
+func unescapeSmPolicyDnnData(smPolicyData *models.SmPolicyData) {
+	for snssai, snssaiData := range smPolicyData.SmPolicySnssaiData {
+		if snssaiData.SmPolicyDnnData == nil {
+			continue
+		}
+		dnnData := make(map[string]models.SmPolicyDnnData, len(snssaiData.SmPolicyDnnData))
+		for escapedDnn, data := range snssaiData.SmPolicyDnnData {
+			dnnData[util.UnescapeDnn(escapedDnn)] = data
+		}
+		snssaiData.SmPolicyDnnData = dnnData
+		smPolicyData.SmPolicySnssaiData[snssai] = snssaiData
+	}
+}
+
+// The functions below return nil when the data set is not stored.
+
+func GetAuthenticationSubscription(ctx context.Context, ueId string) (*models.AuthenticationSubscription, error) {
+	var data models.AuthenticationSubscription
+	if found, err := findOne(ctx, AuthSubsCollName, ueFilter(ueId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetAuthenticationStatus(ctx context.Context, ueId string) (*models.AuthEvent, error) {
+	var data models.AuthEvent
+	if found, err := findOne(ctx, AuthStatusCollName, ueFilter(ueId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetAmData(ctx context.Context, ueId string,
+	servingPlmnId string,
+) (*models.AccessAndMobilitySubscriptionData, error) {
+	var data models.AccessAndMobilitySubscriptionData
+	if found, err := findOne(ctx, AmDataCollName, plmnFilter(ueId, servingPlmnId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetSmfSelectionData(ctx context.Context, ueId string,
+	servingPlmnId string,
+) (*models.SmfSelectionSubscriptionData, error) {
+	var data models.SmfSelectionSubscriptionData
+	if found, err := findOne(ctx, SmfSelDataCollName, plmnFilter(ueId, servingPlmnId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetSmData returns the session management subscription data of the UE in the
+// serving PLMN for singleNssai and dnn, when they are given, with unescaped
+// DNN keys. It returns an empty list when nothing matches.
+func GetSmData(ctx context.Context, ueId string, servingPlmnId string, singleNssai *models.Snssai,
+	dnn string,
+) ([]models.SessionManagementSubscriptionData, error) {
+	filter := plmnFilter(ueId, servingPlmnId)
+	if singleNssai != nil {
+		filter["singleNssai.sst"] = singleNssai.Sst
+		if singleNssai.Sd != "" {
+			filter["singleNssai.sd"] = singleNssai.Sd
+		}
+	}
+	if dnn != "" {
+		filter["dnnConfigurations."+util.EscapeDnn(dnn)] = bson.M{"$exists": true}
+	}
+
+	var data []models.SessionManagementSubscriptionData
+	if err := findMany(ctx, SmDataCollName, filter, &data); err != nil {
+		return nil, err
+	}
+	for i := range data {
+		unescapeDnnConfigurations(&data[i])
+	}
+	return data, nil
+}
+
+func GetSmsData(ctx context.Context, ueId string, servingPlmnId string) (*models.SmsSubscriptionData, error) {
+	var data models.SmsSubscriptionData
+	if found, err := findOne(ctx, SmsDataCollName, plmnFilter(ueId, servingPlmnId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetSmsMngData(ctx context.Context, ueId string,
+	servingPlmnId string,
+) (*models.SmsManagementSubscriptionData, error) {
+	var data models.SmsManagementSubscriptionData
+	if found, err := findOne(ctx, SmsMngDataCollName, plmnFilter(ueId, servingPlmnId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetTraceData(ctx context.Context, ueId string, servingPlmnId string) (*models.TraceData, error) {
+	var data models.TraceData
+	if found, err := findOne(ctx, TraceDataCollName, plmnFilter(ueId, servingPlmnId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetIdentityData(ctx context.Context, ueId string) (*models.IdentityData, error) {
+	var data models.IdentityData
+	if found, err := findOne(ctx, IdentityDataCollName, ueFilter(ueId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetAmf3GppAccessRegistration(ctx context.Context, ueId string) (*models.Amf3GppAccessRegistration, error) {
+	var data models.Amf3GppAccessRegistration
+	if found, err := findOne(ctx, Amf3gppAccessCollName, ueFilter(ueId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetAmfNon3GppAccessRegistration(ctx context.Context,
+	ueId string,
+) (*models.AmfNon3GppAccessRegistration, error) {
+	var data models.AmfNon3GppAccessRegistration
+	if found, err := findOne(ctx, AmfNon3gppAccessCollName, ueFilter(ueId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetSmfRegistration(ctx context.Context, ueId string, pduSessionId int64) (*models.SmfRegistration, error) {
+	var data models.SmfRegistration
+	filter := bson.M{"ueId": ueId, "pduSessionId": pduSessionId}
+	if found, err := findOne(ctx, SmfRegistrationsCollName, filter, &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetSmfRegistrations returns every SMF registration of the UE, or an empty
+// list when there is none.
+func GetSmfRegistrations(ctx context.Context, ueId string) ([]models.SmfRegistration, error) {
+	var data []models.SmfRegistration
+	if err := findMany(ctx, SmfRegistrationsCollName, ueFilter(ueId), &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func GetSmsf3GppAccessRegistration(ctx context.Context, ueId string) (*models.SmsfRegistration, error) {
+	var data models.SmsfRegistration
+	if found, err := findOne(ctx, Smsf3gppAccessCollName, ueFilter(ueId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetSmsfNon3GppAccessRegistration(ctx context.Context, ueId string) (*models.SmsfRegistration, error) {
+	var data models.SmsfRegistration
+	if found, err := findOne(ctx, SmsfNon3gppAccessCollName, ueFilter(ueId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetAmPolicyData(ctx context.Context, ueId string) (*models.AmPolicyData, error) {
+	var data models.AmPolicyData
+	if found, err := findOne(ctx, AmPolicyDataCollName, ueFilter(ueId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetUePolicySet(ctx context.Context, ueId string) (*models.UePolicySet, error) {
+	var data models.UePolicySet
+	if found, err := findOne(ctx, UePolicySetCollName, ueFilter(ueId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetSmPolicyData returns the SM policy data of the UE with unescaped DNN keys
+// and without its usage monitoring data. filter further restricts the match
+// when it is not nil.
+func GetSmPolicyData(ctx context.Context, ueId string, filter bson.M) (*models.SmPolicyData, error) {
+	smPolicyFilter := ueFilter(ueId)
+	for k, v := range filter {
+		smPolicyFilter[k] = v
+	}
+	var data models.SmPolicyData
+	if found, err := findOne(ctx, SmPolicyDataCollName, smPolicyFilter, &data); err != nil || !found {
+		return nil, err
+	}
+	unescapeSmPolicyDnnData(&data)
+	return &data, nil
+}
+
+func GetUsageMonData(ctx context.Context, ueId string, usageMonId string) (*models.UsageMonData, error) {
+	var data models.UsageMonData
+	filter := bson.M{"ueId": ueId, "usageMonId": usageMonId}
+	if found, err := findOne(ctx, UsageMonDataCollName, filter, &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// FindUsageMonData returns the usage monitoring data matching filter.
+func FindUsageMonData(ctx context.Context, filter bson.M) ([]models.UsageMonData, error) {
+	var data []models.UsageMonData
+	if err := findMany(ctx, UsageMonDataCollName, filter, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetUsageMonDataMap returns every usage monitoring data of the UE keyed by its
+// limit id, or nil when there is none.
+func GetUsageMonDataMap(ctx context.Context, ueId string) (map[string]models.UsageMonData, error) {
+	data, err := FindUsageMonData(ctx, ueFilter(ueId))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	umData := make(map[string]models.UsageMonData, len(data))
+	for _, usageMonData := range data {
+		umData[usageMonData.LimitId] = usageMonData
+	}
+	return umData, nil
+}
+
+func GetSponsorConnectivityData(ctx context.Context, sponsorId string) (*models.SponsorConnectivityData, error) {
+	var data models.SponsorConnectivityData
+	filter := bson.M{"sponsorId": sponsorId}
+	if found, err := findOne(ctx, SponsorConnDataCollName, filter, &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetPlmnUePolicySet(ctx context.Context, plmnId string) (*models.UePolicySet, error) {
+	var data models.UePolicySet
+	if found, err := findOne(ctx, PlmnUePolicySetCollName, bson.M{"plmnId": plmnId}, &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// UsageMonCounters is a usage monitoring data together with the usage the PCF
+// counted against it, which the UDR keeps in the same document.
+type UsageMonCounters struct {
+	models.UsageMonData
+	UsageMonId string                 `json:"usageMonId,omitempty"`
+	UsedUsage  *models.UsageThreshold `json:"usedUsage,omitempty"`
+}
+
+func addUsageIncrement(inc bson.M, prefix string, usage *models.UsageThreshold) {
+	if usage == nil {
+		return
+	}
+	if usage.Duration != 0 {
+		inc[prefix+".duration"] = usage.Duration
+	}
+	if usage.TotalVolume != 0 {
+		inc[prefix+".totalVolume"] = usage.TotalVolume
+	}
+	if usage.DownlinkVolume != 0 {
+		inc[prefix+".downlinkVolume"] = usage.DownlinkVolume
+	}
+	if usage.UplinkVolume != 0 {
+		inc[prefix+".uplinkVolume"] = usage.UplinkVolume
+	}
+}
+
+// IncrementUsageMonCounters atomically adds allowed to the allowed usage and
+// used to the used usage of the usage monitoring data of limitId, and returns
+// the counters after the update. Either increment can be nil.
+func IncrementUsageMonCounters(ctx context.Context, ueId string, limitId string,
+	allowed *models.UsageThreshold, used *models.UsageThreshold,
+) (*UsageMonCounters, error) {
+	inc := bson.M{}
+	addUsageIncrement(inc, "allowedUsage", allowed)
+	addUsageIncrement(inc, "usedUsage", used)
+	filter := bson.M{"ueId": ueId, "limitId": limitId}
+
+	var doc map[string]interface{}
+	var err error
+	if len(inc) == 0 {
+		doc, err = database.GetOne(ctx, UsageMonDataCollName, filter)
+	} else {
+		doc, err = database.Increment(ctx, UsageMonDataCollName, filter, inc)
+	}
+	if err != nil || doc == nil {
+		return nil, err
+	}
+	var counters UsageMonCounters
+	if err = decode(UsageMonDataCollName, StripInternalFields(doc), &counters); err != nil {
+		return nil, err
+	}
+	return &counters, nil
+}
+
+// GetInternalGroupIds returns the sorted internal group identifiers the UE is
+// a member of, taken from its am-data of every serving PLMN.
+func GetInternalGroupIds(ctx context.Context, ueId string) ([]string, error) {
+	values, err := database.Distinct(ctx, AmDataCollName, "internalGroupIds", ueFilter(ueId))
+	if err != nil {
+		return nil, err
+	}
+	intGroupIds := make([]string, 0, len(values))
+	for _, value := range values {
+		if intGroupId, ok := value.(string); ok {
+			intGroupIds = append(intGroupIds, intGroupId)
+		}
+	}
+	sort.Strings(intGroupIds)
+	return intGroupIds, nil
+}
+
+func groupFilter(intGroupId string) bson.M {
+	return bson.M{"intGroupId": intGroupId}
+}
+
+func GetGroupAmPolicyData(ctx context.Context, intGroupId string) (*models.AmPolicyData, error) {
+	var data models.AmPolicyData
+	if found, err := findOne(ctx, GroupAmPolicyDataCollName, groupFilter(intGroupId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetGroupSmPolicyData returns the SM policy data of the group with unescaped
+// DNN keys.
+func GetGroupSmPolicyData(ctx context.Context, intGroupId string) (*models.SmPolicyData, error) {
+	var data models.SmPolicyData
+	if found, err := findOne(ctx, GroupSmPolicyDataCollName, groupFilter(intGroupId), &data); err != nil || !found {
+		return nil, err
+	}
+	unescapeSmPolicyDnnData(&data)
+	return &data, nil
+}
+
+// mergeDocs returns base with the attributes of over set on it. Objects present
+// in both are merged recursively, any other value of over replaces the one of
+// base.
+func mergeDocs(base map[string]interface{}, over map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(over))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range over {
+		if v == nil {
+			continue
+		}
+		overObject, isObject := v.(map[string]interface{})
+		baseObject, baseIsObject := merged[k].(map[string]interface{})
+		if isObject && baseIsObject {
+			merged[k] = mergeDocs(baseObject, overObject)
+		} else {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// docRef refers to the document matching filter in collName.
+type docRef struct {
+	collName string
+	filter   bson.M
+}
+
+// findEffective decodes into v the merge of the group documents of the groups
+// of the UE, in the order of their identifiers, with the UE document on top.
+// It reports false when none of them is stored.
+func findEffective(ctx context.Context, collName string, groupCollName string, ueId string,
+	v interface{},
+) (bool, error) {
+	intGroupIds, err := GetInternalGroupIds(ctx, ueId)
+	if err != nil {
+		return false, err
+	}
+	refs := make([]docRef, 0, len(intGroupIds)+1)
+	for _, intGroupId := range intGroupIds {
+		refs = append(refs, docRef{groupCollName, groupFilter(intGroupId)})
+	}
+	refs = append(refs, docRef{collName, ueFilter(ueId)})
+
+	var merged map[string]interface{}
+	for _, ref := range refs {
+		doc, err := database.GetOne(ctx, ref.collName, ref.filter)
+		if err != nil {
+			return false, err
+		}
+		if doc == nil {
+			continue
+		}
+		// Nested documents are plain maps only after a JSON round-trip
+		var plain map[string]interface{}
+		if err = decode(ref.collName, StripInternalFields(doc), &plain); err != nil {
+			return false, err
+		}
+		delete(plain, "intGroupId")
+		merged = mergeDocs(merged, plain)
+	}
+	if merged == nil {
+		return false, nil
+	}
+	return true, decode(collName, merged, v)
+}
+
+// GetEffectiveAmPolicyData returns the AM policy data of the UE merged over
+// the AM policy data of its groups.
+func GetEffectiveAmPolicyData(ctx context.Context, ueId string) (*models.AmPolicyData, error) {
+	var data models.AmPolicyData
+	if found, err := findEffective(ctx, AmPolicyDataCollName, GroupAmPolicyDataCollName, ueId,
+		&data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetEffectiveSmPolicyData returns the SM policy data of the UE merged over
+// the SM policy data of its groups, with unescaped DNN keys and without its
+// usage monitoring data.
+func GetEffectiveSmPolicyData(ctx context.Context, ueId string) (*models.SmPolicyData, error) {
+	var data models.SmPolicyData
+	if found, err := findEffective(ctx, SmPolicyDataCollName, GroupSmPolicyDataCollName, ueId,
+		&data); err != nil || !found {
+		return nil, err
+	}
+	unescapeSmPolicyDnnData(&data)
+	return &data, nil
+}
+
+// UeId identifies a member UE of a group by its SUPI and GPSIs.
+type UeId struct {
+	Supi     string   `json:"supi"`
+	GpsiList []string `json:"gpsiList,omitempty"`
+}
+
+// GroupIdentifiers is the membership of a group, which is known by its
+// internal group identifier and optionally by an external one.
+type GroupIdentifiers struct {
+	ExtGroupId string `json:"extGroupId,omitempty"`
+	IntGroupId string `json:"intGroupId,omitempty"`
+	UeIdList   []UeId `json:"ueIdList,omitempty"`
+}
+
+// Member returns the member of the group identified by ueId, which is a SUPI
+// or a GPSI.
+func (g *GroupIdentifiers) Member(ueId string) *UeId {
+	for i := range g.UeIdList {
+		if g.UeIdList[i].Supi == ueId {
+			return &g.UeIdList[i]
+		}
+		for _, gpsi := range g.UeIdList[i].GpsiList {
+			if gpsi == ueId {
+				return &g.UeIdList[i]
+			}
+		}
+	}
+	return nil
+}
+
+func GetGroupIdentifiers(ctx context.Context, intGroupId string) (*GroupIdentifiers, error) {
+	var data GroupIdentifiers
+	if found, err := findOne(ctx, GroupIdentifiersCollName, groupFilter(intGroupId), &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func GetGroupIdentifiersByExtGroupId(ctx context.Context, extGroupId string) (*GroupIdentifiers, error) {
+	var data GroupIdentifiers
+	filter := bson.M{"extGroupId": extGroupId}
+	if found, err := findOne(ctx, GroupIdentifiersCollName, filter, &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetUeGroupIdentifiers returns the membership of every group the UE, given
+// by its SUPI or a GPSI, is a member of.
+func GetUeGroupIdentifiers(ctx context.Context, ueId string) ([]GroupIdentifiers, error) {
+	var data []GroupIdentifiers
+	filter := bson.M{"$or": []bson.M{
+		{"ueIdList.supi": ueId},
+		{"ueIdList.gpsiList": ueId},
+	}}
+	if err := findMany(ctx, GroupIdentifiersCollName, filter, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// NfGroupIdMapping provisions the NF group of type NfType that serves the
+// SUPIs and GPSIs of its ranges and the listed subscriber identities.
+type NfGroupIdMapping struct {
+	NfType        models.NfType          `json:"nfType"`
+	NfGroupId     string                 `json:"nfGroupId"`
+	SupiRanges    []models.SupiRange     `json:"supiRanges,omitempty"`
+	GpsiRanges    []models.IdentityRange `json:"gpsiRanges,omitempty"`
+	SubscriberIds []string               `json:"subscriberIds,omitempty"`
+}
+
+func NfGroupIdFilter(nfType models.NfType, nfGroupId string) bson.M {
+	return bson.M{"nfType": nfType, "nfGroupId": nfGroupId}
+}
+
+func GetNfGroupIdMapping(ctx context.Context, nfType models.NfType,
+	nfGroupId string,
+) (*NfGroupIdMapping, error) {
+	var data NfGroupIdMapping
+	filter := NfGroupIdFilter(nfType, nfGroupId)
+	if found, err := findOne(ctx, NfGroupIdMapCollName, filter, &data); err != nil || !found {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetNfGroupIdMappings returns the mappings of the NF groups of nfType, or of
+// every NF type when nfType is empty, sorted by NF type and NF group ID.
+func GetNfGroupIdMappings(ctx context.Context, nfType models.NfType) ([]NfGroupIdMapping, error) {
+	filter := bson.M{}
+	if nfType != "" {
+		filter["nfType"] = nfType
+	}
+	var data []NfGroupIdMapping
+	if err := findMany(ctx, NfGroupIdMapCollName, filter, &data); err != nil {
+		return nil, err
+	}
+	sort.Slice(data, func(i, j int) bool {
+		if data[i].NfType != data[j].NfType {
+			return data[i].NfType < data[j].NfType
+		}
+		return data[i].NfGroupId < data[j].NfGroupId
+	})
+	return data, nil
+}
+
+func SharedDataFilter(sharedDataId string) bson.M {
+	return bson.M{"sharedDataId": sharedDataId}
+}
+
+// GetSharedData returns the shared data sharedDataId with unescaped DNN keys.
+func GetSharedData(ctx context.Context, sharedDataId string) (*models.SharedData, error) {
+	var data models.SharedData
+	if found, err := findOne(ctx, SharedDataCollName, SharedDataFilter(sharedDataId), &data); err != nil || !found {
+		return nil, err
+	}
+	data.SharedDnnConfigurations = unescapeDnnKeys(data.SharedDnnConfigurations)
+	return &data, nil
+}
+
+// sharedDataRefs are the attributes by which the provisioned data sets refer
+// to shared data.
+var sharedDataRefs = []struct {
+	collName string
+	attr     string
+}{
+	{AmDataCollName, "sharedAmDataIds"},
+	{SmfSelDataCollName, "sharedSnssaiInfosId"},
+	{SmDataCollName, "sharedDnnConfigurationsIds"},
+	{SmsDataCollName, "sharedSmsSubsDataId"},
+	{SmsMngDataCollName, "sharedSmsMngDataIds"},
+}
+
+// GetSharedDataUeIds returns the UEs whose provisioned data refers to the
+// shared data sharedDataId, sorted.
+func GetSharedDataUeIds(ctx context.Context, sharedDataId string) ([]string, error) {
+	found := make(map[string]bool)
+	for _, ref := range sharedDataRefs {
+		values, err := database.Distinct(ctx, ref.collName, "ueId", bson.M{ref.attr: sharedDataId})
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			if ueId, ok := value.(string); ok {
+				found[ueId] = true
+			}
+		}
+	}
+	ueIds := make([]string, 0, len(found))
+	for ueId := range found {
+		ueIds = append(ueIds, ueId)
+	}
+	sort.Strings(ueIds)
+	return ueIds, nil
+}
+
+// sharedValues returns the merge of the attribute attr of the shared data
+// sharedDataIds, each on top of the ones before it, and the identifiers of the
+// shared data that is not stored.
+func sharedValues(ctx context.Context, sharedDataIds []string, attr string) (map[string]interface{},
+	[]string, error,
+) {
+	var merged map[string]interface{}
+	var missing []string
+	for _, sharedDataId := range sharedDataIds {
+		sharedData, err := GetSharedData(ctx, sharedDataId)
+		if err != nil {
+			return nil, nil, err
+		}
+		if sharedData == nil {
+			missing = append(missing, sharedDataId)
+			continue
+		}
+		var plain map[string]interface{}
+		if err = decode(SharedDataCollName, sharedData, &plain); err != nil {
+			return nil, nil, err
+		}
+		if value, ok := plain[attr].(map[string]interface{}); ok {
+			merged = mergeDocs(merged, value)
+		}
+	}
+	return merged, missing, nil
+}
+
+// dereference decodes into v the data set data with the attribute sharedAttr
+// of the shared data sharedDataIds merged under it, or under its attribute at
+// when at is given, so that the values of data take precedence. The
+// references in refAttr to the shared data that is stored are removed.
+func dereference(ctx context.Context, collName string, data interface{}, refAttr string,
+	sharedDataIds []string, sharedAttr string, at string, v interface{},
+) error {
+	shared, missing, err := sharedValues(ctx, sharedDataIds, sharedAttr)
+	if err != nil {
+		return err
+	}
+	var plain map[string]interface{}
+	if err = decode(collName, data, &plain); err != nil {
+		return err
+	}
+	if shared != nil {
+		if at == "" {
+			plain = mergeDocs(shared, plain)
+		} else {
+			own, _ := plain[at].(map[string]interface{})
+			plain[at] = mergeDocs(shared, own)
+		}
+	}
+	if len(missing) == 0 {
+		delete(plain, refAttr)
+	} else if _, isList := plain[refAttr].([]interface{}); isList {
+		plain[refAttr] = missing
+	}
+	return decode(collName, plain, v)
+}
+
+// The functions below return the data set with the shared data it refers to
+// merged in. The values of the UE take precedence over the shared ones.
+
+func DereferenceAmData(ctx context.Context,
+	data *models.AccessAndMobilitySubscriptionData,
+) (*models.AccessAndMobilitySubscriptionData, error) {
+	if data == nil || len(data.SharedAmDataIds) == 0 {
+		return data, nil
+	}
+	var merged models.AccessAndMobilitySubscriptionData
+	if err := dereference(ctx, AmDataCollName, data, "sharedAmDataIds", data.SharedAmDataIds,
+		"sharedAmData", "", &merged); err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
+
+func DereferenceSmfSelectionData(ctx context.Context,
+	data *models.SmfSelectionSubscriptionData,
+) (*models.SmfSelectionSubscriptionData, error) {
+	if data == nil || data.SharedSnssaiInfosId == "" {
+		return data, nil
+	}
+	var merged models.SmfSelectionSubscriptionData
+	if err := dereference(ctx, SmfSelDataCollName, data, "sharedSnssaiInfosId",
+		[]string{data.SharedSnssaiInfosId}, "sharedSnssaiInfos", "subscribedSnssaiInfos", &merged); err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
+
+func DereferenceSmData(ctx context.Context,
+	data []models.SessionManagementSubscriptionData,
+) ([]models.SessionManagementSubscriptionData, error) {
+	merged := make([]models.SessionManagementSubscriptionData, len(data))
+	for i := range data {
+		if data[i].SharedDnnConfigurationsIds == "" {
+			merged[i] = data[i]
+			continue
+		}
+		if err := dereference(ctx, SmDataCollName, data[i], "sharedDnnConfigurationsIds",
+			[]string{data[i].SharedDnnConfigurationsIds}, "sharedDnnConfigurations", "dnnConfigurations",
+			&merged[i]); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+func DereferenceSmsData(ctx context.Context, data *models.SmsSubscriptionData) (*models.SmsSubscriptionData, error) {
+	if data == nil || len(data.SharedSmsSubsDataId) == 0 {
+		return data, nil
+	}
+	var merged models.SmsSubscriptionData
+	if err := dereference(ctx, SmsDataCollName, data, "sharedSmsSubsDataId", data.SharedSmsSubsDataId,
+		"sharedSmsSubsData", "", &merged); err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
+
+func DereferenceSmsMngData(ctx context.Context,
+	data *models.SmsManagementSubscriptionData,
+) (*models.SmsManagementSubscriptionData, error) {
+	if data == nil || len(data.SharedSmsMngDataIds) == 0 {
+		return data, nil
+	}
+	var merged models.SmsManagementSubscriptionData
+	if err := dereference(ctx, SmsMngDataCollName, data, "sharedSmsMngDataIds", data.SharedSmsMngDataIds,
+		"sharedSmsMngSubsData", "", &merged); err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
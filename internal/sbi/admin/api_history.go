@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/sbi/producer"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+// HTTPQueryHistory - Retrieves the recorded changes of a UE's data
+func HTTPQueryHistory(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+
+	rsp := producer.HandleQueryHistory(req)
+	sendResponse(c, rsp)
+}
+
+// HTTPQueryHistorySnapshot - Reconstructs a resource of a UE as of a given timestamp
+func HTTPQueryHistorySnapshot(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+
+	rsp := producer.HandleQueryHistorySnapshot(req)
+	sendResponse(c, rsp)
+}
+
+// HTTPQuerySharedDataHistory - Retrieves the recorded changes of shared data
+func HTTPQuerySharedDataHistory(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["sharedDataId"] = c.Params.ByName("sharedDataId")
+
+	rsp := producer.HandleQueryHistory(req)
+	sendResponse(c, rsp)
+}
+
+// HTTPQuerySharedDataHistorySnapshot - Reconstructs shared data as of a given timestamp
+func HTTPQuerySharedDataHistorySnapshot(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["sharedDataId"] = c.Params.ByName("sharedDataId")
+
+	rsp := producer.HandleQueryHistorySnapshot(req)
+	sendResponse(c, rsp)
+}
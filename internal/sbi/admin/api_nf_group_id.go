@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/repository"
+	"github.com/free5gc/udr/internal/sbi/producer"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+// HTTPQueryNfGroupIdMappings - Retrieves the NF group ID mappings, optionally of one NF type
+func HTTPQueryNfGroupIdMappings(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+
+	rsp := producer.HandleQueryNfGroupIdMappings(req)
+	sendResponse(c, rsp)
+}
+
+// HTTPQueryNfGroupIdMapping - Retrieves the subscribers mapped to an NF group
+func HTTPQueryNfGroupIdMapping(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["nfType"] = c.Params.ByName("nfType")
+	req.Params["nfGroupId"] = c.Params.ByName("nfGroupId")
+
+	rsp := producer.HandleQueryNfGroupIdMapping(req)
+	sendResponse(c, rsp)
+}
+
+// HTTPPutNfGroupIdMapping - Creates or replaces the subscribers mapped to an NF group
+func HTTPPutNfGroupIdMapping(c *gin.Context) {
+	var mapping repository.NfGroupIdMapping
+	if err := getDataFromRequestBody(c, &mapping); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, mapping)
+	req.Params["nfType"] = c.Params.ByName("nfType")
+	req.Params["nfGroupId"] = c.Params.ByName("nfGroupId")
+
+	rsp := producer.HandlePutNfGroupIdMapping(req)
+	sendResponse(c, rsp)
+}
+
+// HTTPDeleteNfGroupIdMapping - Deletes the mapping of an NF group
+func HTTPDeleteNfGroupIdMapping(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["nfType"] = c.Params.ByName("nfType")
+	req.Params["nfGroupId"] = c.Params.ByName("nfGroupId")
+
+	rsp := producer.HandleDeleteNfGroupIdMapping(req)
+	sendResponse(c, rsp)
+}
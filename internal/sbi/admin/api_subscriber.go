@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/sbi/producer"
+	"github.com/free5gc/udr/internal/subscriber"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+// HTTPCreateSubscriber - Stores all data sets of a new subscriber
+func HTTPCreateSubscriber(c *gin.Context) {
+	var s subscriber.Subscriber
+	if err := getDataFromRequestBody(c, &s); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, s)
+
+	rsp := producer.HandleCreateSubscriber(req)
+	sendResponse(c, rsp)
+}
+
+// HTTPQuerySubscriber - Retrieves all data sets of a subscriber
+func HTTPQuerySubscriber(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+
+	rsp := producer.HandleQuerySubscriber(req)
+	sendResponse(c, rsp)
+}
+
+// HTTPDeleteSubscriber - Deletes a subscriber with all its data and subscriptions
+func HTTPDeleteSubscriber(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+
+	rsp := producer.HandleDeleteSubscriber(req)
+	sendResponse(c, rsp)
+}
@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/udr/internal/audit"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+// Route is the information for every URI.
+type Route struct {
+	// Name is the name of this Route.
+	Name string
+	// Method is the string for the HTTP method. ex) GET, POST etc..
+	Method string
+	// Pattern is the pattern of the URI.
+	Pattern string
+	// HandlerFunc is the handler function of this route.
+	HandlerFunc gin.HandlerFunc
+}
+
+// Routes is the list of the generated Route.
+type Routes []Route
+
+// AddService registers the UDR administration API, which is not part of any
+// 3GPP service and is meant for operators only.
+func AddService(engine *gin.Engine) *gin.RouterGroup {
+	group := engine.Group("/nudr-admin/v1")
+	group.Use(audit.HandlerFunc(audit.UeIdParam))
+
+	for _, route := range routes {
+		switch route.Method {
+		case "GET":
+			group.GET(route.Pattern, route.HandlerFunc)
+		case "PATCH":
+			group.PATCH(route.Pattern, route.HandlerFunc)
+		case "POST":
+			group.POST(route.Pattern, route.HandlerFunc)
+		case "PUT":
+			group.PUT(route.Pattern, route.HandlerFunc)
+		case "DELETE":
+			group.DELETE(route.Pattern, route.HandlerFunc)
+		}
+	}
+	return group
+}
+
+func getDataFromRequestBody(c *gin.Context, data interface{}) error {
+	reqBody, err := c.GetRawData()
+	if err != nil {
+		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
+		pd := util.ProblemDetailsSystemFailure(err.Error())
+		c.JSON(http.StatusInternalServerError, pd)
+		return err
+	}
+
+	err = openapi.Deserialize(data, reqBody, "application/json")
+	if err != nil {
+		logger.DataRepoLog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := util.ProblemDetailsMalformedReqSyntax(err.Error())
+		c.JSON(http.StatusBadRequest, pd)
+		return err
+	}
+	return err
+}
+
+func sendResponse(c *gin.Context, rsp *httpwrapper.Response) {
+	for k, v := range rsp.Header {
+		c.Header(k, v[0])
+	}
+	serializedBody, err := openapi.Serialize(rsp.Body, "application/json")
+	if err != nil {
+		logger.DataRepoLog.Errorf("Serialize Response Body error: %+v", err)
+		pd := util.ProblemDetailsSystemFailure(err.Error())
+		c.JSON(http.StatusInternalServerError, pd)
+	} else {
+		c.Data(rsp.Status, "application/json", serializedBody)
+	}
+}
+
+var routes = Routes{
+	{
+		"HTTPQueryHistory",
+		strings.ToUpper("Get"),
+		"/history/:ueId",
+		HTTPQueryHistory,
+	},
+
+	{
+		"HTTPQueryHistorySnapshot",
+		strings.ToUpper("Get"),
+		"/history/:ueId/snapshot",
+		HTTPQueryHistorySnapshot,
+	},
+
+	{
+		"HTTPQuerySharedDataHistory",
+		strings.ToUpper("Get"),
+		"/shared-data-history/:sharedDataId",
+		HTTPQuerySharedDataHistory,
+	},
+
+	{
+		"HTTPQuerySharedDataHistorySnapshot",
+		strings.ToUpper("Get"),
+		"/shared-data-history/:sharedDataId/snapshot",
+		HTTPQuerySharedDataHistorySnapshot,
+	},
+
+	{
+		"HTTPCreateSubscriber",
+		strings.ToUpper("Post"),
+		"/subscribers",
+		HTTPCreateSubscriber,
+	},
+
+	{
+		"HTTPQuerySubscriber",
+		strings.ToUpper("Get"),
+		"/subscribers/:ueId",
+		HTTPQuerySubscriber,
+	},
+
+	{
+		"HTTPDeleteSubscriber",
+		strings.ToUpper("Delete"),
+		"/subscribers/:ueId",
+		HTTPDeleteSubscriber,
+	},
+
+	{
+		"HTTPQueryNfGroupIdMappings",
+		strings.ToUpper("Get"),
+		"/nf-group-ids",
+		HTTPQueryNfGroupIdMappings,
+	},
+
+	{
+		"HTTPQueryNfGroupIdMapping",
+		strings.ToUpper("Get"),
+		"/nf-group-ids/:nfType/:nfGroupId",
+		HTTPQueryNfGroupIdMapping,
+	},
+
+	{
+		"HTTPPutNfGroupIdMapping",
+		strings.ToUpper("Put"),
+		"/nf-group-ids/:nfType/:nfGroupId",
+		HTTPPutNfGroupIdMapping,
+	},
+
+	{
+		"HTTPDeleteNfGroupIdMapping",
+		strings.ToUpper("Delete"),
+		"/nf-group-ids/:nfType/:nfGroupId",
+		HTTPDeleteNfGroupIdMapping,
+	},
+}
@@ -58,6 +58,26 @@ func BuildNFInstance(context *udr_context.UDRContext) models.NfProfile {
 				},
 			},
 		},
+		{
+			ServiceInstanceId: "groupidmap",
+			ServiceName:       udr_context.ServiceName_NUDR_GROUP_ID_MAP,
+			Versions: &[]models.NfServiceVersion{
+				{
+					ApiFullVersion:  "1.0.0",
+					ApiVersionInUri: "v1",
+				},
+			},
+			Scheme:          context.UriScheme,
+			NfServiceStatus: models.NfServiceStatus_REGISTERED,
+			ApiPrefix:       apiPrefix,
+			IpEndPoints: &[]models.IpEndPoint{
+				{
+					Ipv4Address: context.RegisterIPv4,
+					Transport:   models.TransportProtocol_TCP,
+					Port:        int32(context.SBIPort),
+				},
+			},
+		},
 	}
 
 	// TODO: finish the Udr Info
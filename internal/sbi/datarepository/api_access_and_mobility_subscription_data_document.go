@@ -42,3 +42,48 @@ func HTTPQueryAmData(c *gin.Context) {
 		c.Data(rsp.Status, "application/json", responseBody)
 	}
 }
+
+// HTTPCreateAmData - Creates or replaces the access and mobility subscription data of a UE
+func HTTPCreateAmData(c *gin.Context) {
+	var amData models.AccessAndMobilitySubscriptionData
+
+	if err := getDataFromRequestBody(c, &amData); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, amData)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleCreateAmData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPModifyAmData - Modifies the access and mobility subscription data of a UE
+func HTTPModifyAmData(c *gin.Context) {
+	var patchItemArray []models.PatchItem
+
+	if err := getDataFromRequestBody(c, &patchItemArray); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, patchItemArray)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleModifyAmData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPDeleteAmData - Deletes the access and mobility subscription data of a UE
+func HTTPDeleteAmData(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleDeleteAmData(req)
+
+	sendResponse(c, rsp)
+}
@@ -90,3 +90,29 @@ func HTTPQueryAuthSubsData(c *gin.Context) {
 		c.Data(rsp.Status, "application/json", responseBody)
 	}
 }
+
+// HTTPCreateAuthSubsData - Creates or replaces the authentication subscription data of a UE
+func HTTPCreateAuthSubsData(c *gin.Context) {
+	var authSubs models.AuthenticationSubscription
+
+	if err := getDataFromRequestBody(c, &authSubs); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, authSubs)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+
+	rsp := producer.HandleCreateAuthSubsData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPDeleteAuthSubsData - Deletes the authentication subscription data of a UE
+func HTTPDeleteAuthSubsData(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+
+	rsp := producer.HandleDeleteAuthSubsData(req)
+
+	sendResponse(c, rsp)
+}
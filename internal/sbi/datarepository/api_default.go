@@ -10,6 +10,7 @@
 package datarepository
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -56,6 +57,31 @@ func getDataFromRequestBody(c *gin.Context, data interface{}) error {
 	return err
 }
 
+// getMergePatchFromRequestBody checks the request body against the model data
+// points to and returns it as a JSON merge patch document, in which absent and
+// null attributes can be told apart.
+func getMergePatchFromRequestBody(c *gin.Context, data interface{}) (map[string]interface{}, error) {
+	reqBody, err := c.GetRawData()
+	if err != nil {
+		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
+		pd := util.ProblemDetailsSystemFailure(err.Error())
+		c.JSON(http.StatusInternalServerError, pd)
+		return nil, err
+	}
+
+	var patchData map[string]interface{}
+	if err = openapi.Deserialize(data, reqBody, "application/json"); err == nil {
+		err = json.Unmarshal(reqBody, &patchData)
+	}
+	if err != nil {
+		logger.DataRepoLog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := util.ProblemDetailsMalformedReqSyntax(err.Error())
+		c.JSON(http.StatusBadRequest, pd)
+		return nil, err
+	}
+	return patchData, nil
+}
+
 // HTTPApplicationDataInfluenceDataGet -
 func HTTPApplicationDataInfluenceDataGet(c *gin.Context) {
 	queryParams := c.Request.URL.Query()
@@ -73,12 +99,13 @@ func HTTPApplicationDataInfluenceDataInfluenceIdDelete(c *gin.Context) {
 func HTTPApplicationDataInfluenceDataInfluenceIdPatch(c *gin.Context) {
 	var trInfluDataPatch models.TrafficInfluDataPatch
 
-	if err := getDataFromRequestBody(c, &trInfluDataPatch); err != nil {
+	patchData, err := getMergePatchFromRequestBody(c, &trInfluDataPatch)
+	if err != nil {
 		return
 	}
 
 	rsp := producer.HandleApplicationDataInfluenceDataInfluenceIdPatch(c.Params.ByName("influenceId"),
-		&trInfluDataPatch)
+		patchData)
 
 	sendResponse(c, rsp)
 }
@@ -105,7 +132,7 @@ func HTTPApplicationDataInfluenceDataSubsToNotifyGet(c *gin.Context) {
 
 // HTTPApplicationDataInfluenceDataSubsToNotifyPost -
 func HTTPApplicationDataInfluenceDataSubsToNotifyPost(c *gin.Context) {
-	var trInfluSub models.TrafficInfluSub
+	var trInfluSub models.UdrTrafficInfluSub
 
 	if err := getDataFromRequestBody(c, &trInfluSub); err != nil {
 		return
@@ -132,7 +159,7 @@ func HTTPApplicationDataInfluenceDataSubsToNotifySubscriptionIdGet(c *gin.Contex
 
 // HTTPApplicationDataInfluenceDataSubsToNotifySubscriptionIdPut -
 func HTTPApplicationDataInfluenceDataSubsToNotifySubscriptionIdPut(c *gin.Context) {
-	var trInfluSub models.TrafficInfluSub
+	var trInfluSub models.UdrTrafficInfluSub
 
 	if err := getDataFromRequestBody(c, &trInfluSub); err != nil {
 		return
@@ -176,6 +203,184 @@ func HTTPApplicationDataPfdsGet(c *gin.Context) {
 	sendResponse(c, rsp)
 }
 
+// HTTPApplicationDataServiceParamDataGet -
+func HTTPApplicationDataServiceParamDataGet(c *gin.Context) {
+	queryParams := c.Request.URL.Query()
+	rsp := producer.HandleApplicationDataServiceParamDataGet(queryParams)
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataServiceParamDataServiceParamIdDelete -
+func HTTPApplicationDataServiceParamDataServiceParamIdDelete(c *gin.Context) {
+	rsp := producer.HandleApplicationDataServiceParamDataServiceParamIdDelete(c.Params.ByName("serviceParamId"))
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataServiceParamDataServiceParamIdGet -
+func HTTPApplicationDataServiceParamDataServiceParamIdGet(c *gin.Context) {
+	rsp := producer.HandleApplicationDataServiceParamDataServiceParamIdGet(c.Params.ByName("serviceParamId"))
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataServiceParamDataServiceParamIdPatch -
+func HTTPApplicationDataServiceParamDataServiceParamIdPatch(c *gin.Context) {
+	var serviceParameterDataPatch producer.ServiceParameterDataPatch
+
+	patchData, err := getMergePatchFromRequestBody(c, &serviceParameterDataPatch)
+	if err != nil {
+		return
+	}
+
+	rsp := producer.HandleApplicationDataServiceParamDataServiceParamIdPatch(c.Params.ByName("serviceParamId"), patchData)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataServiceParamDataServiceParamIdPut -
+func HTTPApplicationDataServiceParamDataServiceParamIdPut(c *gin.Context) {
+	var serviceParameterData producer.ServiceParameterData
+
+	if err := getDataFromRequestBody(c, &serviceParameterData); err != nil {
+		return
+	}
+
+	rsp := producer.HandleApplicationDataServiceParamDataServiceParamIdPut(
+		c.Params.ByName("serviceParamId"), &serviceParameterData)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataBdtPolicyDataGet -
+func HTTPApplicationDataBdtPolicyDataGet(c *gin.Context) {
+	queryParams := c.Request.URL.Query()
+	rsp := producer.HandleApplicationDataBdtPolicyDataGet(queryParams)
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataBdtPolicyDataBdtPolicyIdDelete -
+func HTTPApplicationDataBdtPolicyDataBdtPolicyIdDelete(c *gin.Context) {
+	rsp := producer.HandleApplicationDataBdtPolicyDataBdtPolicyIdDelete(c.Params.ByName("bdtPolicyId"))
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataBdtPolicyDataBdtPolicyIdGet -
+func HTTPApplicationDataBdtPolicyDataBdtPolicyIdGet(c *gin.Context) {
+	rsp := producer.HandleApplicationDataBdtPolicyDataBdtPolicyIdGet(c.Params.ByName("bdtPolicyId"))
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataBdtPolicyDataBdtPolicyIdPatch -
+func HTTPApplicationDataBdtPolicyDataBdtPolicyIdPatch(c *gin.Context) {
+	var bdtPolicyDataPatch models.BdtPolicyDataPatch
+
+	patchData, err := getMergePatchFromRequestBody(c, &bdtPolicyDataPatch)
+	if err != nil {
+		return
+	}
+
+	rsp := producer.HandleApplicationDataBdtPolicyDataBdtPolicyIdPatch(c.Params.ByName("bdtPolicyId"), patchData)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataBdtPolicyDataBdtPolicyIdPut -
+func HTTPApplicationDataBdtPolicyDataBdtPolicyIdPut(c *gin.Context) {
+	var bdtPolicyData producer.BdtPolicyData
+
+	if err := getDataFromRequestBody(c, &bdtPolicyData); err != nil {
+		return
+	}
+
+	rsp := producer.HandleApplicationDataBdtPolicyDataBdtPolicyIdPut(c.Params.ByName("bdtPolicyId"), &bdtPolicyData)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataIptvConfigDataGet -
+func HTTPApplicationDataIptvConfigDataGet(c *gin.Context) {
+	queryParams := c.Request.URL.Query()
+	rsp := producer.HandleApplicationDataIptvConfigDataGet(queryParams)
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataIptvConfigDataConfigurationIdDelete -
+func HTTPApplicationDataIptvConfigDataConfigurationIdDelete(c *gin.Context) {
+	rsp := producer.HandleApplicationDataIptvConfigDataConfigurationIdDelete(c.Params.ByName("configurationId"))
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataIptvConfigDataConfigurationIdGet -
+func HTTPApplicationDataIptvConfigDataConfigurationIdGet(c *gin.Context) {
+	rsp := producer.HandleApplicationDataIptvConfigDataConfigurationIdGet(c.Params.ByName("configurationId"))
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataIptvConfigDataConfigurationIdPatch -
+func HTTPApplicationDataIptvConfigDataConfigurationIdPatch(c *gin.Context) {
+	var iptvConfigDataPatch producer.IptvConfigDataPatch
+
+	patchData, err := getMergePatchFromRequestBody(c, &iptvConfigDataPatch)
+	if err != nil {
+		return
+	}
+
+	rsp := producer.HandleApplicationDataIptvConfigDataConfigurationIdPatch(c.Params.ByName("configurationId"), patchData)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataIptvConfigDataConfigurationIdPut -
+func HTTPApplicationDataIptvConfigDataConfigurationIdPut(c *gin.Context) {
+	var iptvConfigData producer.IptvConfigData
+
+	if err := getDataFromRequestBody(c, &iptvConfigData); err != nil {
+		return
+	}
+
+	rsp := producer.HandleApplicationDataIptvConfigDataConfigurationIdPut(
+		c.Params.ByName("configurationId"), &iptvConfigData)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataSubsToNotifyPost -
+func HTTPApplicationDataSubsToNotifyPost(c *gin.Context) {
+	var appDataSubs producer.ApplicationDataSubs
+
+	if err := getDataFromRequestBody(c, &appDataSubs); err != nil {
+		return
+	}
+
+	rsp := producer.HandleApplicationDataSubsToNotifyPost(&appDataSubs)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataSubsToNotifySubsIdDelete -
+func HTTPApplicationDataSubsToNotifySubsIdDelete(c *gin.Context) {
+	rsp := producer.HandleApplicationDataSubsToNotifySubsIdDelete(c.Params.ByName("subsId"))
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataSubsToNotifySubsIdGet -
+func HTTPApplicationDataSubsToNotifySubsIdGet(c *gin.Context) {
+	rsp := producer.HandleApplicationDataSubsToNotifySubsIdGet(c.Params.ByName("subsId"))
+	sendResponse(c, rsp)
+}
+
+// HTTPApplicationDataSubsToNotifySubsIdPut -
+func HTTPApplicationDataSubsToNotifySubsIdPut(c *gin.Context) {
+	var appDataSubs producer.ApplicationDataSubs
+
+	if err := getDataFromRequestBody(c, &appDataSubs); err != nil {
+		return
+	}
+
+	rsp := producer.HandleApplicationDataSubsToNotifySubsIdPut(c.Params.ByName("subsId"), &appDataSubs)
+
+	sendResponse(c, rsp)
+}
+
 // HTTPExposureDataSubsToNotifyPost -
 func HTTPExposureDataSubsToNotifyPost(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{})
@@ -227,6 +432,23 @@ func HTTPPolicyDataBdtDataBdtReferenceIdPut(c *gin.Context) {
 	sendResponse(c, rsp)
 }
 
+// HTTPPolicyDataBdtDataBdtReferenceIdPatch -
+func HTTPPolicyDataBdtDataBdtReferenceIdPatch(c *gin.Context) {
+	var bdtData models.BdtData
+
+	patchData, err := getMergePatchFromRequestBody(c, &bdtData)
+	if err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, patchData)
+	req.Params["bdtReferenceId"] = c.Params.ByName("bdtReferenceId")
+
+	rsp := producer.HandlePolicyDataBdtDataBdtReferenceIdPatch(req)
+
+	sendResponse(c, rsp)
+}
+
 // HTTPPolicyDataBdtDataGet -
 func HTTPPolicyDataBdtDataGet(c *gin.Context) {
 	req := httpwrapper.NewRequest(c.Request, nil)
@@ -236,6 +458,78 @@ func HTTPPolicyDataBdtDataGet(c *gin.Context) {
 	sendResponse(c, rsp)
 }
 
+// HTTPPolicyDataGroupsIntGroupIdAmDataGet -
+func HTTPPolicyDataGroupsIntGroupIdAmDataGet(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["intGroupId"] = c.Params.ByName("intGroupId")
+
+	rsp := producer.HandlePolicyDataGroupsIntGroupIdAmDataGet(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPolicyDataGroupsIntGroupIdAmDataPut -
+func HTTPPolicyDataGroupsIntGroupIdAmDataPut(c *gin.Context) {
+	var amPolicyData models.AmPolicyData
+
+	if err := getDataFromRequestBody(c, &amPolicyData); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, amPolicyData)
+	req.Params["intGroupId"] = c.Params.ByName("intGroupId")
+
+	rsp := producer.HandlePolicyDataGroupsIntGroupIdAmDataPut(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPolicyDataGroupsIntGroupIdAmDataDelete -
+func HTTPPolicyDataGroupsIntGroupIdAmDataDelete(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["intGroupId"] = c.Params.ByName("intGroupId")
+
+	rsp := producer.HandlePolicyDataGroupsIntGroupIdAmDataDelete(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPolicyDataGroupsIntGroupIdSmDataGet -
+func HTTPPolicyDataGroupsIntGroupIdSmDataGet(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["intGroupId"] = c.Params.ByName("intGroupId")
+
+	rsp := producer.HandlePolicyDataGroupsIntGroupIdSmDataGet(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPolicyDataGroupsIntGroupIdSmDataPut -
+func HTTPPolicyDataGroupsIntGroupIdSmDataPut(c *gin.Context) {
+	var smPolicyData models.SmPolicyData
+
+	if err := getDataFromRequestBody(c, &smPolicyData); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, smPolicyData)
+	req.Params["intGroupId"] = c.Params.ByName("intGroupId")
+
+	rsp := producer.HandlePolicyDataGroupsIntGroupIdSmDataPut(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPolicyDataGroupsIntGroupIdSmDataDelete -
+func HTTPPolicyDataGroupsIntGroupIdSmDataDelete(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["intGroupId"] = c.Params.ByName("intGroupId")
+
+	rsp := producer.HandlePolicyDataGroupsIntGroupIdSmDataDelete(req)
+
+	sendResponse(c, rsp)
+}
+
 // HTTPPolicyDataPlmnsPlmnIdUePolicySetGet -
 func HTTPPolicyDataPlmnsPlmnIdUePolicySetGet(c *gin.Context) {
 	req := httpwrapper.NewRequest(c.Request, nil)
@@ -246,6 +540,48 @@ func HTTPPolicyDataPlmnsPlmnIdUePolicySetGet(c *gin.Context) {
 	sendResponse(c, rsp)
 }
 
+// HTTPPolicyDataPlmnsPlmnIdUePolicySetPut -
+func HTTPPolicyDataPlmnsPlmnIdUePolicySetPut(c *gin.Context) {
+	var uePolicySet models.UePolicySet
+
+	if err := getDataFromRequestBody(c, &uePolicySet); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, uePolicySet)
+	req.Params["plmnId"] = c.Params.ByName("plmnId")
+
+	rsp := producer.HandlePolicyDataPlmnsPlmnIdUePolicySetPut(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPolicyDataPlmnsPlmnIdUePolicySetPatch -
+func HTTPPolicyDataPlmnsPlmnIdUePolicySetPatch(c *gin.Context) {
+	var uePolicySet models.UePolicySet
+
+	if err := getDataFromRequestBody(c, &uePolicySet); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, uePolicySet)
+	req.Params["plmnId"] = c.Params.ByName("plmnId")
+
+	rsp := producer.HandlePolicyDataPlmnsPlmnIdUePolicySetPatch(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPolicyDataPlmnsPlmnIdUePolicySetDelete -
+func HTTPPolicyDataPlmnsPlmnIdUePolicySetDelete(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["plmnId"] = c.Params.ByName("plmnId")
+
+	rsp := producer.HandlePolicyDataPlmnsPlmnIdUePolicySetDelete(req)
+
+	sendResponse(c, rsp)
+}
+
 // HTTPPolicyDataSponsorConnectivityDataSponsorIdGet -
 func HTTPPolicyDataSponsorConnectivityDataSponsorIdGet(c *gin.Context) {
 	req := httpwrapper.NewRequest(c.Request, nil)
@@ -256,6 +592,48 @@ func HTTPPolicyDataSponsorConnectivityDataSponsorIdGet(c *gin.Context) {
 	sendResponse(c, rsp)
 }
 
+// HTTPPolicyDataSponsorConnectivityDataSponsorIdPut -
+func HTTPPolicyDataSponsorConnectivityDataSponsorIdPut(c *gin.Context) {
+	var sponsorConnectivityData models.SponsorConnectivityData
+
+	if err := getDataFromRequestBody(c, &sponsorConnectivityData); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, sponsorConnectivityData)
+	req.Params["sponsorId"] = c.Params.ByName("sponsorId")
+
+	rsp := producer.HandlePolicyDataSponsorConnectivityDataSponsorIdPut(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPolicyDataSponsorConnectivityDataSponsorIdPatch -
+func HTTPPolicyDataSponsorConnectivityDataSponsorIdPatch(c *gin.Context) {
+	var sponsorConnectivityData models.SponsorConnectivityData
+
+	if err := getDataFromRequestBody(c, &sponsorConnectivityData); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, sponsorConnectivityData)
+	req.Params["sponsorId"] = c.Params.ByName("sponsorId")
+
+	rsp := producer.HandlePolicyDataSponsorConnectivityDataSponsorIdPatch(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPolicyDataSponsorConnectivityDataSponsorIdDelete -
+func HTTPPolicyDataSponsorConnectivityDataSponsorIdDelete(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["sponsorId"] = c.Params.ByName("sponsorId")
+
+	rsp := producer.HandlePolicyDataSponsorConnectivityDataSponsorIdDelete(req)
+
+	sendResponse(c, rsp)
+}
+
 // HTTPPolicyDataSubsToNotifyPost -
 func HTTPPolicyDataSubsToNotifyPost(c *gin.Context) {
 	var policyDataSubscription models.PolicyDataSubscription
@@ -419,6 +797,23 @@ func HTTPPolicyDataUesUeIdSmDataUsageMonIdPut(c *gin.Context) {
 	sendResponse(c, rsp)
 }
 
+// HTTPIncrementUsageMonCounters - Atomically adds to the usage monitoring counters of a limit
+func HTTPIncrementUsageMonCounters(c *gin.Context) {
+	var increment producer.UsageMonIncrement
+
+	if err := getDataFromRequestBody(c, &increment); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, increment)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["limitId"] = c.Params.ByName("limitId")
+
+	rsp := producer.HandleIncrementUsageMonCounters(req)
+
+	sendResponse(c, rsp)
+}
+
 // HTTPPolicyDataUesUeIdUePolicySetGet -
 func HTTPPolicyDataUesUeIdUePolicySetGet(c *gin.Context) {
 	req := httpwrapper.NewRequest(c.Request, nil)
@@ -0,0 +1,63 @@
+/*
+ * Nudr_DataRepository API OpenAPI file
+ *
+ * Unified Data Repository Service
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package datarepository
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/repository"
+	"github.com/free5gc/udr/internal/sbi/producer"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+// HTTPQueryGroupIdentifiers - Retrieve the group identifiers and optionally the members of a group
+func HTTPQueryGroupIdentifiers(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+
+	rsp := producer.HandleQueryGroupIdentifiers(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPQueryGroupMembers - Retrieve the members of a group
+func HTTPQueryGroupMembers(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["intGroupId"] = c.Params.ByName("intGroupId")
+
+	rsp := producer.HandleQueryGroupMembers(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPutGroupMembers - Create or replace the members of a group
+func HTTPPutGroupMembers(c *gin.Context) {
+	var groupIdentifiers repository.GroupIdentifiers
+
+	if err := getDataFromRequestBody(c, &groupIdentifiers); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, groupIdentifiers)
+	req.Params["intGroupId"] = c.Params.ByName("intGroupId")
+
+	rsp := producer.HandlePutGroupMembers(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPDeleteGroupMembers - Delete the members of a group
+func HTTPDeleteGroupMembers(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["intGroupId"] = c.Params.ByName("intGroupId")
+
+	rsp := producer.HandleDeleteGroupMembers(req)
+
+	sendResponse(c, rsp)
+}
@@ -0,0 +1,17 @@
+package datarepository
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/sbi/producer"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+// HTTPGetNfGroupIds - Retrieve the NF group IDs serving a subscriber
+func HTTPGetNfGroupIds(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+
+	rsp := producer.HandleQueryNfGroupIds(req)
+
+	sendResponse(c, rsp)
+}
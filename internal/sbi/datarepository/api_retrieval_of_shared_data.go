@@ -42,3 +42,55 @@ func HTTPGetSharedData(c *gin.Context) {
 		c.Data(rsp.Status, "application/json", responseBody)
 	}
 }
+
+// HTTPGetIndividualSharedData - retrieve an individual shared data
+func HTTPGetIndividualSharedData(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["sharedDataId"] = c.Params.ByName("sharedDataId")
+
+	rsp := producer.HandleGetIndividualSharedData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPutSharedData - create or replace an individual shared data
+func HTTPPutSharedData(c *gin.Context) {
+	var sharedData models.SharedData
+
+	if err := getDataFromRequestBody(c, &sharedData); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, sharedData)
+	req.Params["sharedDataId"] = c.Params.ByName("sharedDataId")
+
+	rsp := producer.HandlePutSharedData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPPatchSharedData - modify an individual shared data
+func HTTPPatchSharedData(c *gin.Context) {
+	var patchItemArray []models.PatchItem
+
+	if err := getDataFromRequestBody(c, &patchItemArray); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, patchItemArray)
+	req.Params["sharedDataId"] = c.Params.ByName("sharedDataId")
+
+	rsp := producer.HandlePatchSharedData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPDeleteSharedData - delete an individual shared data
+func HTTPDeleteSharedData(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["sharedDataId"] = c.Params.ByName("sharedDataId")
+
+	rsp := producer.HandleDeleteSharedData(req)
+
+	sendResponse(c, rsp)
+}
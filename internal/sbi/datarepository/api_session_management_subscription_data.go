@@ -42,3 +42,48 @@ func HTTPQuerySmData(c *gin.Context) {
 		c.Data(rsp.Status, "application/json", responseBody)
 	}
 }
+
+// HTTPCreateSmData - Creates or replaces the Session Management subscription data of a UE
+func HTTPCreateSmData(c *gin.Context) {
+	var smDatas []models.SessionManagementSubscriptionData
+
+	if err := getDataFromRequestBody(c, &smDatas); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, smDatas)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleCreateSmData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPModifySmData - Modifies the Session Management subscription data of a UE
+func HTTPModifySmData(c *gin.Context) {
+	var patchItemArray []models.PatchItem
+
+	if err := getDataFromRequestBody(c, &patchItemArray); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, patchItemArray)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleModifySmData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPDeleteSmData - Deletes the Session Management subscription data of a UE
+func HTTPDeleteSmData(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleDeleteSmData(req)
+
+	sendResponse(c, rsp)
+}
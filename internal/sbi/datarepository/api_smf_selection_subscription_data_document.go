@@ -42,3 +42,48 @@ func HTTPQuerySmfSelectData(c *gin.Context) {
 		c.Data(rsp.Status, "application/json", responseBody)
 	}
 }
+
+// HTTPCreateSmfSelectData - Creates or replaces the SMF selection subscription data of a UE
+func HTTPCreateSmfSelectData(c *gin.Context) {
+	var smfSelectData models.SmfSelectionSubscriptionData
+
+	if err := getDataFromRequestBody(c, &smfSelectData); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, smfSelectData)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleCreateSmfSelectData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPModifySmfSelectData - Modifies the SMF selection subscription data of a UE
+func HTTPModifySmfSelectData(c *gin.Context) {
+	var patchItemArray []models.PatchItem
+
+	if err := getDataFromRequestBody(c, &patchItemArray); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, patchItemArray)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleModifySmfSelectData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPDeleteSmfSelectData - Deletes the SMF selection subscription data of a UE
+func HTTPDeleteSmfSelectData(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleDeleteSmfSelectData(req)
+
+	sendResponse(c, rsp)
+}
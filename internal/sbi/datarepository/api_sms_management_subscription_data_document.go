@@ -42,3 +42,48 @@ func HTTPQuerySmsMngData(c *gin.Context) {
 		c.Data(rsp.Status, "application/json", responseBody)
 	}
 }
+
+// HTTPCreateSmsMngData - Creates or replaces the SMS management subscription data of a UE
+func HTTPCreateSmsMngData(c *gin.Context) {
+	var smsMngData models.SmsManagementSubscriptionData
+
+	if err := getDataFromRequestBody(c, &smsMngData); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, smsMngData)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleCreateSmsMngData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPModifySmsMngData - Modifies the SMS management subscription data of a UE
+func HTTPModifySmsMngData(c *gin.Context) {
+	var patchItemArray []models.PatchItem
+
+	if err := getDataFromRequestBody(c, &patchItemArray); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, patchItemArray)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleModifySmsMngData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPDeleteSmsMngData - Deletes the SMS management subscription data of a UE
+func HTTPDeleteSmsMngData(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleDeleteSmsMngData(req)
+
+	sendResponse(c, rsp)
+}
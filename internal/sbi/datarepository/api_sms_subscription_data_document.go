@@ -42,3 +42,48 @@ func HTTPQuerySmsData(c *gin.Context) {
 		c.Data(rsp.Status, "application/json", responseBody)
 	}
 }
+
+// HTTPCreateSmsData - Creates or replaces the SMS subscription data of a UE
+func HTTPCreateSmsData(c *gin.Context) {
+	var smsData models.SmsSubscriptionData
+
+	if err := getDataFromRequestBody(c, &smsData); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, smsData)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleCreateSmsData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPModifySmsData - Modifies the SMS subscription data of a UE
+func HTTPModifySmsData(c *gin.Context) {
+	var patchItemArray []models.PatchItem
+
+	if err := getDataFromRequestBody(c, &patchItemArray); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, patchItemArray)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleModifySmsData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPDeleteSmsData - Deletes the SMS subscription data of a UE
+func HTTPDeleteSmsData(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleDeleteSmsData(req)
+
+	sendResponse(c, rsp)
+}
@@ -42,3 +42,48 @@ func HTTPQueryTraceData(c *gin.Context) {
 		c.Data(rsp.Status, "application/json", responseBody)
 	}
 }
+
+// HTTPCreateTraceData - Creates or replaces the trace configuration data of a UE
+func HTTPCreateTraceData(c *gin.Context) {
+	var traceData models.TraceData
+
+	if err := getDataFromRequestBody(c, &traceData); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, traceData)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleCreateTraceData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPModifyTraceData - Modifies the trace configuration data of a UE
+func HTTPModifyTraceData(c *gin.Context) {
+	var patchItemArray []models.PatchItem
+
+	if err := getDataFromRequestBody(c, &patchItemArray); err != nil {
+		return
+	}
+
+	req := httpwrapper.NewRequest(c.Request, patchItemArray)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleModifyTraceData(req)
+
+	sendResponse(c, rsp)
+}
+
+// HTTPDeleteTraceData - Deletes the trace configuration data of a UE
+func HTTPDeleteTraceData(c *gin.Context) {
+	req := httpwrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["servingPlmnId"] = c.Params.ByName("servingPlmnId")
+
+	rsp := producer.HandleDeleteTraceData(req)
+
+	sendResponse(c, rsp)
+}
@@ -0,0 +1,21 @@
+package datarepository
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Values of the :ueId wildcard that name a collection rather than a UE.
+var nonUeIds = map[string]bool{
+	"subs-to-notify": true,
+	"shared-data":    true,
+	"group-data":     true,
+}
+
+// auditUeId returns the UE of a Nudr_DataRepository request for its audit
+// record.
+func auditUeId(c *gin.Context) string {
+	if ueId := c.Param("ueId"); !nonUeIds[ueId] {
+		return ueId
+	}
+	return ""
+}
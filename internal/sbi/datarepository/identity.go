@@ -0,0 +1,50 @@
+package datarepository
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/identity"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+)
+
+const subscriptionDataPath = "/nudr-dr/v1/subscription-data/"
+
+// identityHandlerFunc resolves the :ueId of the subscription data resources,
+// which can be any SUPI or GPSI of the UE, to the SUPI the data of the UE is
+// stored under, so that the handlers only see the SUPI. The existing
+// resources of an unknown UE are not found, while a SUPI can be written to by
+// a PUT or a POST that creates a resource.
+func identityHandlerFunc(c *gin.Context) {
+	ueId := c.Param("ueId")
+	if ueId == "" || nonUeIds[ueId] || !strings.HasPrefix(c.FullPath(), subscriptionDataPath) {
+		c.Next()
+		return
+	}
+	if identity.IsSupi(ueId) && (c.Request.Method == http.MethodPut || c.Request.Method == http.MethodPost) {
+		c.Next()
+		return
+	}
+
+	supi, err := identity.Resolve(context.TODO(), ueId)
+	if err != nil {
+		if errors.Is(err, identity.ErrUnknown) {
+			c.AbortWithStatusJSON(http.StatusNotFound, util.ProblemDetailsNotFound("USER_NOT_FOUND"))
+			return
+		}
+		logger.DataRepoLog.Errorf("Resolve UE identity %s err: %+v", ueId, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, util.ProblemDetailsSystemFailure(err.Error()))
+		return
+	}
+	for i := range c.Params {
+		if c.Params[i].Key == "ueId" {
+			c.Params[i].Value = supi
+		}
+	}
+	c.Next()
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/free5gc/udr/internal/audit"
 	"github.com/free5gc/udr/internal/logger"
 	logger_util "github.com/free5gc/util/logger"
 )
@@ -55,6 +56,10 @@ func subMsgShortDispatchHandlerFunc(c *gin.Context) {
 func subMsgDispatchHandlerFunc(c *gin.Context) {
 	op := c.Param("servingPlmnId")
 	subsToNotify := c.Param("ueId")
+	if subsToNotify == "shared-data" {
+		sharedDataMsgDispatchHandlerFunc(c)
+		return
+	}
 	for _, route := range subRoutes {
 		if strings.Contains(route.Pattern, op) && route.Method == c.Request.Method {
 			route.HandlerFunc(c)
@@ -106,6 +111,32 @@ func eeMsgDispatchHandlerFunc(c *gin.Context) {
 	c.String(http.StatusMethodNotAllowed, "Method Not Allowed")
 }
 
+func groupDataMsgDispatchHandlerFunc(c *gin.Context) {
+	if c.Param("ueId") == "group-data" {
+		for _, route := range groupDataRoutes {
+			if route.Method == c.Request.Method {
+				c.Params = append(c.Params, gin.Param{Key: "intGroupId", Value: c.Param("servingPlmnId")})
+				route.HandlerFunc(c)
+				return
+			}
+		}
+	}
+	c.String(http.StatusMethodNotAllowed, "Method Not Allowed")
+}
+
+// Handler of '/subscription-data/shared-data/:sharedDataId', which conflicts
+// with the '/subscription-data/:ueId/:servingPlmnId' pattern.
+func sharedDataMsgDispatchHandlerFunc(c *gin.Context) {
+	for _, route := range sharedDataRoutes {
+		if route.Method == c.Request.Method {
+			c.Params = append(c.Params, gin.Param{Key: "sharedDataId", Value: c.Param("servingPlmnId")})
+			route.HandlerFunc(c)
+			return
+		}
+	}
+	c.String(http.StatusMethodNotAllowed, "Method Not Allowed")
+}
+
 // Handler to distinguish "subs-to-notify" from ":influenceId".
 func appInfluDataMsgDispatchHandlerFunc(c *gin.Context) {
 	influID := c.Param("influenceId")
@@ -147,6 +178,8 @@ func expoMsgDispatchHandlerFunc(c *gin.Context) {
 
 func AddService(engine *gin.Engine) *gin.RouterGroup {
 	group := engine.Group("/nudr-dr/v1")
+	group.Use(audit.HandlerFunc(auditUeId))
+	group.Use(identityHandlerFunc)
 
 	for _, route := range routes {
 		switch route.Method {
@@ -175,6 +208,9 @@ func AddService(engine *gin.Engine) *gin.RouterGroup {
 	eePattern := "/subscription-data/:ueId/:servingPlmnId/ee-subscriptions/:subsId"
 	group.Any(eePattern, eeMsgDispatchHandlerFunc)
 
+	groupDataPattern := "/subscription-data/:ueId/:servingPlmnId/members"
+	group.Any(groupDataPattern, groupDataMsgDispatchHandlerFunc)
+
 	/*
 	 * GIN wildcard issue:
 	 * '/application-data/influenceData/:influenceId' and
@@ -191,6 +227,13 @@ func AddService(engine *gin.Engine) *gin.RouterGroup {
 	expoPattern := "/exposure-data/:ueId/:subId/:pduSessionId"
 	group.Any(expoPattern, expoMsgDispatchHandlerFunc)
 
+	// Nudr_GroupIDmap is served beside Nudr_DataRepository
+	groupIdMapGroup := engine.Group("/nudr-group-id-map/v1")
+	groupIdMapGroup.Use(audit.HandlerFunc(audit.UeIdParam))
+	for _, route := range groupIdMapRoutes {
+		groupIdMapGroup.Handle(route.Method, route.Pattern, route.HandlerFunc)
+	}
+
 	return group
 }
 
@@ -256,6 +299,27 @@ var routes = Routes{
 		HTTPQueryAmData,
 	},
 
+	{
+		"HTTPCreateAmData",
+		strings.ToUpper("Put"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/am-data",
+		HTTPCreateAmData,
+	},
+
+	{
+		"HTTPModifyAmData",
+		strings.ToUpper("Patch"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/am-data",
+		HTTPModifyAmData,
+	},
+
+	{
+		"HTTPDeleteAmData",
+		strings.ToUpper("Delete"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/am-data",
+		HTTPDeleteAmData,
+	},
+
 	{
 		"HTTPQueryAuthenticationStatus",
 		strings.ToUpper("Get"),
@@ -277,6 +341,20 @@ var routes = Routes{
 		HTTPQueryAuthSubsData,
 	},
 
+	{
+		"HTTPCreateAuthSubsData",
+		strings.ToUpper("Put"),
+		"/subscription-data/:ueId/:servingPlmnId/authentication-subscription",
+		HTTPCreateAuthSubsData,
+	},
+
+	{
+		"HTTPDeleteAuthSubsData",
+		strings.ToUpper("Delete"),
+		"/subscription-data/:ueId/:servingPlmnId/authentication-subscription",
+		HTTPDeleteAuthSubsData,
+	},
+
 	{
 		"HTTPCreateAuthenticationSoR",
 		strings.ToUpper("Put"),
@@ -361,6 +439,139 @@ var routes = Routes{
 		HTTPApplicationDataPfdsGet,
 	},
 
+	{
+		"HTTPApplicationDataServiceParamDataGet",
+		strings.ToUpper("Get"),
+		"/application-data/serviceParamData",
+		HTTPApplicationDataServiceParamDataGet,
+	},
+
+	{
+		"HTTPApplicationDataServiceParamDataServiceParamIdDelete",
+		strings.ToUpper("Delete"),
+		"/application-data/serviceParamData/:serviceParamId",
+		HTTPApplicationDataServiceParamDataServiceParamIdDelete,
+	},
+
+	{
+		"HTTPApplicationDataServiceParamDataServiceParamIdGet",
+		strings.ToUpper("Get"),
+		"/application-data/serviceParamData/:serviceParamId",
+		HTTPApplicationDataServiceParamDataServiceParamIdGet,
+	},
+
+	{
+		"HTTPApplicationDataServiceParamDataServiceParamIdPatch",
+		strings.ToUpper("Patch"),
+		"/application-data/serviceParamData/:serviceParamId",
+		HTTPApplicationDataServiceParamDataServiceParamIdPatch,
+	},
+
+	{
+		"HTTPApplicationDataServiceParamDataServiceParamIdPut",
+		strings.ToUpper("Put"),
+		"/application-data/serviceParamData/:serviceParamId",
+		HTTPApplicationDataServiceParamDataServiceParamIdPut,
+	},
+
+	{
+		"HTTPApplicationDataBdtPolicyDataGet",
+		strings.ToUpper("Get"),
+		"/application-data/bdtPolicyData",
+		HTTPApplicationDataBdtPolicyDataGet,
+	},
+
+	{
+		"HTTPApplicationDataBdtPolicyDataBdtPolicyIdDelete",
+		strings.ToUpper("Delete"),
+		"/application-data/bdtPolicyData/:bdtPolicyId",
+		HTTPApplicationDataBdtPolicyDataBdtPolicyIdDelete,
+	},
+
+	{
+		"HTTPApplicationDataBdtPolicyDataBdtPolicyIdGet",
+		strings.ToUpper("Get"),
+		"/application-data/bdtPolicyData/:bdtPolicyId",
+		HTTPApplicationDataBdtPolicyDataBdtPolicyIdGet,
+	},
+
+	{
+		"HTTPApplicationDataBdtPolicyDataBdtPolicyIdPatch",
+		strings.ToUpper("Patch"),
+		"/application-data/bdtPolicyData/:bdtPolicyId",
+		HTTPApplicationDataBdtPolicyDataBdtPolicyIdPatch,
+	},
+
+	{
+		"HTTPApplicationDataBdtPolicyDataBdtPolicyIdPut",
+		strings.ToUpper("Put"),
+		"/application-data/bdtPolicyData/:bdtPolicyId",
+		HTTPApplicationDataBdtPolicyDataBdtPolicyIdPut,
+	},
+
+	{
+		"HTTPApplicationDataIptvConfigDataGet",
+		strings.ToUpper("Get"),
+		"/application-data/iptvConfigData",
+		HTTPApplicationDataIptvConfigDataGet,
+	},
+
+	{
+		"HTTPApplicationDataIptvConfigDataConfigurationIdDelete",
+		strings.ToUpper("Delete"),
+		"/application-data/iptvConfigData/:configurationId",
+		HTTPApplicationDataIptvConfigDataConfigurationIdDelete,
+	},
+
+	{
+		"HTTPApplicationDataIptvConfigDataConfigurationIdGet",
+		strings.ToUpper("Get"),
+		"/application-data/iptvConfigData/:configurationId",
+		HTTPApplicationDataIptvConfigDataConfigurationIdGet,
+	},
+
+	{
+		"HTTPApplicationDataIptvConfigDataConfigurationIdPatch",
+		strings.ToUpper("Patch"),
+		"/application-data/iptvConfigData/:configurationId",
+		HTTPApplicationDataIptvConfigDataConfigurationIdPatch,
+	},
+
+	{
+		"HTTPApplicationDataIptvConfigDataConfigurationIdPut",
+		strings.ToUpper("Put"),
+		"/application-data/iptvConfigData/:configurationId",
+		HTTPApplicationDataIptvConfigDataConfigurationIdPut,
+	},
+
+	{
+		"HTTPApplicationDataSubsToNotifyPost",
+		strings.ToUpper("Post"),
+		"/application-data/subs-to-notify",
+		HTTPApplicationDataSubsToNotifyPost,
+	},
+
+	{
+		"HTTPApplicationDataSubsToNotifySubsIdDelete",
+		strings.ToUpper("Delete"),
+		"/application-data/subs-to-notify/:subsId",
+		HTTPApplicationDataSubsToNotifySubsIdDelete,
+	},
+
+	{
+		"HTTPApplicationDataSubsToNotifySubsIdGet",
+		strings.ToUpper("Get"),
+		"/application-data/subs-to-notify/:subsId",
+		HTTPApplicationDataSubsToNotifySubsIdGet,
+	},
+
+	{
+		"HTTPApplicationDataSubsToNotifySubsIdPut",
+		strings.ToUpper("Put"),
+		"/application-data/subs-to-notify/:subsId",
+		HTTPApplicationDataSubsToNotifySubsIdPut,
+	},
+
 	{
 		"HTTPPolicyDataBdtDataBdtReferenceIdDelete",
 		strings.ToUpper("Delete"),
@@ -382,6 +593,13 @@ var routes = Routes{
 		HTTPPolicyDataBdtDataBdtReferenceIdPut,
 	},
 
+	{
+		"HTTPPolicyDataBdtDataBdtReferenceIdPatch",
+		strings.ToUpper("Patch"),
+		"/policy-data/bdt-data/:bdtReferenceId",
+		HTTPPolicyDataBdtDataBdtReferenceIdPatch,
+	},
+
 	{
 		"HTTPPolicyDataBdtDataGet",
 		strings.ToUpper("Get"),
@@ -389,6 +607,48 @@ var routes = Routes{
 		HTTPPolicyDataBdtDataGet,
 	},
 
+	{
+		"HTTPPolicyDataGroupsIntGroupIdAmDataGet",
+		strings.ToUpper("Get"),
+		"/policy-data/groups/:intGroupId/am-data",
+		HTTPPolicyDataGroupsIntGroupIdAmDataGet,
+	},
+
+	{
+		"HTTPPolicyDataGroupsIntGroupIdAmDataPut",
+		strings.ToUpper("Put"),
+		"/policy-data/groups/:intGroupId/am-data",
+		HTTPPolicyDataGroupsIntGroupIdAmDataPut,
+	},
+
+	{
+		"HTTPPolicyDataGroupsIntGroupIdAmDataDelete",
+		strings.ToUpper("Delete"),
+		"/policy-data/groups/:intGroupId/am-data",
+		HTTPPolicyDataGroupsIntGroupIdAmDataDelete,
+	},
+
+	{
+		"HTTPPolicyDataGroupsIntGroupIdSmDataGet",
+		strings.ToUpper("Get"),
+		"/policy-data/groups/:intGroupId/sm-data",
+		HTTPPolicyDataGroupsIntGroupIdSmDataGet,
+	},
+
+	{
+		"HTTPPolicyDataGroupsIntGroupIdSmDataPut",
+		strings.ToUpper("Put"),
+		"/policy-data/groups/:intGroupId/sm-data",
+		HTTPPolicyDataGroupsIntGroupIdSmDataPut,
+	},
+
+	{
+		"HTTPPolicyDataGroupsIntGroupIdSmDataDelete",
+		strings.ToUpper("Delete"),
+		"/policy-data/groups/:intGroupId/sm-data",
+		HTTPPolicyDataGroupsIntGroupIdSmDataDelete,
+	},
+
 	{
 		"HTTPPolicyDataPlmnsPlmnIdUePolicySetGet",
 		strings.ToUpper("Get"),
@@ -396,6 +656,27 @@ var routes = Routes{
 		HTTPPolicyDataPlmnsPlmnIdUePolicySetGet,
 	},
 
+	{
+		"HTTPPolicyDataPlmnsPlmnIdUePolicySetPut",
+		strings.ToUpper("Put"),
+		"/policy-data/plmns/:plmnId/ue-policy-set",
+		HTTPPolicyDataPlmnsPlmnIdUePolicySetPut,
+	},
+
+	{
+		"HTTPPolicyDataPlmnsPlmnIdUePolicySetPatch",
+		strings.ToUpper("Patch"),
+		"/policy-data/plmns/:plmnId/ue-policy-set",
+		HTTPPolicyDataPlmnsPlmnIdUePolicySetPatch,
+	},
+
+	{
+		"HTTPPolicyDataPlmnsPlmnIdUePolicySetDelete",
+		strings.ToUpper("Delete"),
+		"/policy-data/plmns/:plmnId/ue-policy-set",
+		HTTPPolicyDataPlmnsPlmnIdUePolicySetDelete,
+	},
+
 	{
 		"HTTPPolicyDataSponsorConnectivityDataSponsorIdGet",
 		strings.ToUpper("Get"),
@@ -403,6 +684,27 @@ var routes = Routes{
 		HTTPPolicyDataSponsorConnectivityDataSponsorIdGet,
 	},
 
+	{
+		"HTTPPolicyDataSponsorConnectivityDataSponsorIdPut",
+		strings.ToUpper("Put"),
+		"/policy-data/sponsor-connectivity-data/:sponsorId",
+		HTTPPolicyDataSponsorConnectivityDataSponsorIdPut,
+	},
+
+	{
+		"HTTPPolicyDataSponsorConnectivityDataSponsorIdPatch",
+		strings.ToUpper("Patch"),
+		"/policy-data/sponsor-connectivity-data/:sponsorId",
+		HTTPPolicyDataSponsorConnectivityDataSponsorIdPatch,
+	},
+
+	{
+		"HTTPPolicyDataSponsorConnectivityDataSponsorIdDelete",
+		strings.ToUpper("Delete"),
+		"/policy-data/sponsor-connectivity-data/:sponsorId",
+		HTTPPolicyDataSponsorConnectivityDataSponsorIdDelete,
+	},
+
 	{
 		"HTTPPolicyDataSubsToNotifyPost",
 		strings.ToUpper("Post"),
@@ -487,6 +789,13 @@ var routes = Routes{
 		HTTPPolicyDataUesUeIdSmDataUsageMonIdPut,
 	},
 
+	{
+		"HTTPIncrementUsageMonCounters",
+		strings.ToUpper("Post"),
+		"/policy-data/ues/:ueId/usage-mon-counters/:limitId",
+		HTTPIncrementUsageMonCounters,
+	},
+
 	{
 		"HTTPPolicyDataUesUeIdUePolicySetGet",
 		strings.ToUpper("Get"),
@@ -578,6 +887,27 @@ var routes = Routes{
 		HTTPQuerySmfSelectData,
 	},
 
+	{
+		"HTTPCreateSmfSelectData",
+		strings.ToUpper("Put"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/smf-selection-subscription-data",
+		HTTPCreateSmfSelectData,
+	},
+
+	{
+		"HTTPModifySmfSelectData",
+		strings.ToUpper("Patch"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/smf-selection-subscription-data",
+		HTTPModifySmfSelectData,
+	},
+
+	{
+		"HTTPDeleteSmfSelectData",
+		strings.ToUpper("Delete"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/smf-selection-subscription-data",
+		HTTPDeleteSmfSelectData,
+	},
+
 	{
 		"HTTPCreateSmsfContext3gpp",
 		strings.ToUpper("Put"),
@@ -627,6 +957,27 @@ var routes = Routes{
 		HTTPQuerySmsMngData,
 	},
 
+	{
+		"HTTPCreateSmsMngData",
+		strings.ToUpper("Put"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/sms-mng-data",
+		HTTPCreateSmsMngData,
+	},
+
+	{
+		"HTTPModifySmsMngData",
+		strings.ToUpper("Patch"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/sms-mng-data",
+		HTTPModifySmsMngData,
+	},
+
+	{
+		"HTTPDeleteSmsMngData",
+		strings.ToUpper("Delete"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/sms-mng-data",
+		HTTPDeleteSmsMngData,
+	},
+
 	{
 		"HTTPQuerySmsData",
 		strings.ToUpper("Get"),
@@ -634,6 +985,27 @@ var routes = Routes{
 		HTTPQuerySmsData,
 	},
 
+	{
+		"HTTPCreateSmsData",
+		strings.ToUpper("Put"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/sms-data",
+		HTTPCreateSmsData,
+	},
+
+	{
+		"HTTPModifySmsData",
+		strings.ToUpper("Patch"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/sms-data",
+		HTTPModifySmsData,
+	},
+
+	{
+		"HTTPDeleteSmsData",
+		strings.ToUpper("Delete"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/sms-data",
+		HTTPDeleteSmsData,
+	},
+
 	{
 		"HTTPQuerySmData",
 		strings.ToUpper("Get"),
@@ -641,6 +1013,27 @@ var routes = Routes{
 		HTTPQuerySmData,
 	},
 
+	{
+		"HTTPCreateSmData",
+		strings.ToUpper("Put"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/sm-data",
+		HTTPCreateSmData,
+	},
+
+	{
+		"HTTPModifySmData",
+		strings.ToUpper("Patch"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/sm-data",
+		HTTPModifySmData,
+	},
+
+	{
+		"HTTPDeleteSmData",
+		strings.ToUpper("Delete"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/sm-data",
+		HTTPDeleteSmData,
+	},
+
 	{
 		"HTTPQueryTraceData",
 		strings.ToUpper("Get"),
@@ -648,6 +1041,27 @@ var routes = Routes{
 		HTTPQueryTraceData,
 	},
 
+	{
+		"HTTPCreateTraceData",
+		strings.ToUpper("Put"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/trace-data",
+		HTTPCreateTraceData,
+	},
+
+	{
+		"HTTPModifyTraceData",
+		strings.ToUpper("Patch"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/trace-data",
+		HTTPModifyTraceData,
+	},
+
+	{
+		"HTTPDeleteTraceData",
+		strings.ToUpper("Delete"),
+		"/subscription-data/:ueId/:servingPlmnId/provisioned-data/trace-data",
+		HTTPDeleteTraceData,
+	},
+
 	{
 		"HTTPCreateAMFSubscriptions",
 		strings.ToUpper("Put"),
@@ -727,6 +1141,13 @@ var subRoutes = Routes{
 		HTTPGetOdbData,
 	},
 
+	{
+		"HTTPQueryGroupIdentifiers",
+		strings.ToUpper("Get"),
+		"/subscription-data/group-data/group-identifiers",
+		HTTPQueryGroupIdentifiers,
+	},
+
 	// Sepcial case
 	{
 		"HTTPRemovesubscriptionDataSubscriptions",
@@ -812,6 +1233,68 @@ var eeRoutes = Routes{
 	},
 }
 
+var groupIdMapRoutes = Routes{
+	{
+		"HTTPGetNfGroupIds",
+		strings.ToUpper("Get"),
+		"/nf-group-ids",
+		HTTPGetNfGroupIds,
+	},
+}
+
+var groupDataRoutes = Routes{
+	{
+		"HTTPQueryGroupMembers",
+		strings.ToUpper("Get"),
+		"/subscription-data/group-data/:intGroupId/members",
+		HTTPQueryGroupMembers,
+	},
+
+	{
+		"HTTPPutGroupMembers",
+		strings.ToUpper("Put"),
+		"/subscription-data/group-data/:intGroupId/members",
+		HTTPPutGroupMembers,
+	},
+
+	{
+		"HTTPDeleteGroupMembers",
+		strings.ToUpper("Delete"),
+		"/subscription-data/group-data/:intGroupId/members",
+		HTTPDeleteGroupMembers,
+	},
+}
+
+var sharedDataRoutes = Routes{
+	{
+		"HTTPGetIndividualSharedData",
+		strings.ToUpper("Get"),
+		"/subscription-data/shared-data/:sharedDataId",
+		HTTPGetIndividualSharedData,
+	},
+
+	{
+		"HTTPPutSharedData",
+		strings.ToUpper("Put"),
+		"/subscription-data/shared-data/:sharedDataId",
+		HTTPPutSharedData,
+	},
+
+	{
+		"HTTPPatchSharedData",
+		strings.ToUpper("Patch"),
+		"/subscription-data/shared-data/:sharedDataId",
+		HTTPPatchSharedData,
+	},
+
+	{
+		"HTTPDeleteSharedData",
+		strings.ToUpper("Delete"),
+		"/subscription-data/shared-data/:sharedDataId",
+		HTTPDeleteSharedData,
+	},
+}
+
 var expoRoutes = Routes{
 	{
 		"HTTPCreateSessionManagementData",
@@ -0,0 +1,576 @@
+package producer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/sbi/producer/callback"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/httpwrapper"
+	"github.com/free5gc/util/mongoapi"
+)
+
+// ServiceParameterData holds the service specific parameters an AF provides
+// for a UE, a group of UEs or any UE.
+type ServiceParameterData struct {
+	AppId        string         `json:"appId,omitempty" bson:"appId"`
+	Dnn          string         `json:"dnn,omitempty" bson:"dnn"`
+	Snssai       *models.Snssai `json:"snssai,omitempty" bson:"snssai"`
+	InterGroupId string         `json:"interGroupId,omitempty" bson:"interGroupId"`
+	Supi         string         `json:"supi,omitempty" bson:"supi"`
+	UeIpv4       string         `json:"ueIpv4,omitempty" bson:"ueIpv4"`
+	UeIpv6       string         `json:"ueIpv6,omitempty" bson:"ueIpv6"`
+	UeMac        string         `json:"ueMac,omitempty" bson:"ueMac"`
+	AnyUeInd     bool           `json:"anyUeInd,omitempty" bson:"anyUeInd"`
+	ParamOverPc5 string         `json:"paramOverPc5,omitempty" bson:"paramOverPc5"`
+	ParamOverUu  string         `json:"paramOverUu,omitempty" bson:"paramOverUu"`
+	SuppFeat     string         `json:"suppFeat,omitempty" bson:"suppFeat"`
+	ResUri       string         `json:"resUri,omitempty" bson:"resUri"`
+}
+
+type ServiceParameterDataPatch struct {
+	ParamOverPc5 string `json:"paramOverPc5,omitempty" bson:"paramOverPc5"`
+	ParamOverUu  string `json:"paramOverUu,omitempty" bson:"paramOverUu"`
+}
+
+// Values of MulticastAccessControl.AccStatus
+const (
+	ACC_STATUS_ALLOWED     = "ALLOWED"
+	ACC_STATUS_NOT_ALLOWED = "NOT_ALLOWED"
+)
+
+type MulticastAccessControl struct {
+	SrcIpv4Addr    string `json:"srcIpv4Addr,omitempty" bson:"srcIpv4Addr"`
+	SrcIpv6Addr    string `json:"srcIpv6Addr,omitempty" bson:"srcIpv6Addr"`
+	MulGrpIpv4Addr string `json:"mulGrpIpv4Addr,omitempty" bson:"mulGrpIpv4Addr"`
+	MulGrpIpv6Addr string `json:"mulGrpIpv6Addr,omitempty" bson:"mulGrpIpv6Addr"`
+	AccStatus      string `json:"accStatus" bson:"accStatus"`
+}
+
+// IptvConfigData holds the multicast access control an AF provides for IPTV.
+type IptvConfigData struct {
+	Supi          string                            `json:"supi,omitempty" bson:"supi"`
+	InterGroupId  string                            `json:"interGroupId,omitempty" bson:"interGroupId"`
+	Dnn           string                            `json:"dnn,omitempty" bson:"dnn"`
+	Snssai        *models.Snssai                    `json:"snssai,omitempty" bson:"snssai"`
+	AfAppId       string                            `json:"afAppId" bson:"afAppId"`
+	MultiAccCtrls map[string]MulticastAccessControl `json:"multiAccCtrls" bson:"multiAccCtrls"`
+	SuppFeat      string                            `json:"suppFeat,omitempty" bson:"suppFeat"`
+	ResUri        string                            `json:"resUri,omitempty" bson:"resUri"`
+}
+
+// IptvConfigDataPatch replaces, adds or, with a null value, removes multicast
+// access controls.
+type IptvConfigDataPatch struct {
+	MultiAccCtrls map[string]*MulticastAccessControl `json:"multiAccCtrls,omitempty" bson:"multiAccCtrls"`
+}
+
+// BdtPolicyData is the BdtPolicyData of Rel-16, which also names the UEs the
+// background data transfer policy applies to.
+type BdtPolicyData struct {
+	models.BdtPolicyData `bson:",inline"`
+	InterGroupId         string `json:"interGroupId,omitempty" bson:"interGroupId"`
+	Supi                 string `json:"supi,omitempty" bson:"supi"`
+	ResUri               string `json:"resUri,omitempty" bson:"resUri"`
+}
+
+func validateIptvConfigData(iptvConfigData *IptvConfigData) *models.ProblemDetails {
+	if iptvConfigData.AfAppId == "" {
+		return util.ProblemDetailsMalformedReqSyntax("Missing afAppId")
+	}
+	if len(iptvConfigData.MultiAccCtrls) == 0 {
+		return util.ProblemDetailsMalformedReqSyntax("Missing multiAccCtrls")
+	}
+	for _, multiAccCtrl := range iptvConfigData.MultiAccCtrls {
+		switch multiAccCtrl.AccStatus {
+		case ACC_STATUS_ALLOWED, ACC_STATUS_NOT_ALLOWED:
+		default:
+			return util.ProblemDetailsMalformedReqSyntax("Invalid accStatus: " + multiAccCtrl.AccStatus)
+		}
+	}
+	return nil
+}
+
+func validateBdtPolicyData(bdtPolicyData *BdtPolicyData) *models.ProblemDetails {
+	if bdtPolicyData.BdtRefId == "" {
+		return util.ProblemDetailsMalformedReqSyntax("Missing bdtRefId")
+	}
+	if len(bdtPolicyData.TransfPolicies) == 0 {
+		return util.ProblemDetailsMalformedReqSyntax("Missing transfPolicies")
+	}
+	return nil
+}
+
+// appDataResource describes an application data collection whose individual
+// resources are stored one document each, keyed by the idName attribute.
+type appDataResource struct {
+	// name is the collection in the resource URI
+	name     string
+	collName string
+	idName   string
+	dataInd  string
+	// appIdName is the attribute holding the application identifier, if any
+	appIdName string
+	// patchAttrs are the attributes a PATCH may modify
+	patchAttrs []string
+	// check decodes and validates a whole resource
+	check func(raw []byte) *models.ProblemDetails
+}
+
+var serviceParamDataResource = &appDataResource{
+	name:       "serviceParamData",
+	collName:   APPDATA_SVCPARAM_DB_COLLECTION_NAME,
+	idName:     "serviceParamId",
+	dataInd:    DATA_IND_SVC_PARAM,
+	appIdName:  "appId",
+	patchAttrs: []string{"paramOverPc5", "paramOverUu"},
+	check: func(raw []byte) *models.ProblemDetails {
+		var serviceParamData ServiceParameterData
+		if err := json.Unmarshal(raw, &serviceParamData); err != nil {
+			return util.ProblemDetailsMalformedReqSyntax(err.Error())
+		}
+		return nil
+	},
+}
+
+var bdtPolicyDataResource = &appDataResource{
+	name:       "bdtPolicyData",
+	collName:   APPDATA_BDTPOLICY_DB_COLLECTION_NAME,
+	idName:     "bdtPolicyId",
+	dataInd:    DATA_IND_BDT,
+	patchAttrs: []string{"selTransPolicyId"},
+	check: func(raw []byte) *models.ProblemDetails {
+		var bdtPolicyData BdtPolicyData
+		if err := json.Unmarshal(raw, &bdtPolicyData); err != nil {
+			return util.ProblemDetailsMalformedReqSyntax(err.Error())
+		}
+		return validateBdtPolicyData(&bdtPolicyData)
+	},
+}
+
+var iptvConfigDataResource = &appDataResource{
+	name:       "iptvConfigData",
+	collName:   APPDATA_IPTVCONFIG_DB_COLLECTION_NAME,
+	idName:     "configurationId",
+	dataInd:    DATA_IND_IPTV,
+	appIdName:  "afAppId",
+	patchAttrs: []string{"multiAccCtrls"},
+	check: func(raw []byte) *models.ProblemDetails {
+		var iptvConfigData IptvConfigData
+		if err := json.Unmarshal(raw, &iptvConfigData); err != nil {
+			return util.ProblemDetailsMalformedReqSyntax(err.Error())
+		}
+		return validateIptvConfigData(&iptvConfigData)
+	},
+}
+
+// appDataQuery holds the query parameters of an application data collection.
+// An empty list does not restrict the query.
+type appDataQuery struct {
+	ids           []string
+	dnns          []string
+	snssais       []models.Snssai
+	interGroupIds []string
+	supis         []string
+	ueIpv4s       []string
+	ueIpv6s       []string
+	ueMacs        []string
+}
+
+// queryFilter builds the query of the collection. Data that applies to any UE,
+// with anyUeInd set or without any UE identification, matches any supis and
+// group identifiers.
+func (r *appDataResource) queryFilter(query *appDataQuery) bson.M {
+	conditions := []bson.M{}
+	if len(query.ids) != 0 {
+		conditions = append(conditions, bson.M{r.idName: bson.M{"$in": query.ids}})
+	}
+	if len(query.dnns) != 0 {
+		conditions = append(conditions, bson.M{"dnn": bson.M{"$in": query.dnns}})
+	}
+	if len(query.snssais) != 0 {
+		snssaiMatches := make([]bson.M, 0, len(query.snssais))
+		for _, snssai := range query.snssais {
+			snssaiMatches = append(snssaiMatches, snssaiMatch("snssai.", snssai))
+		}
+		conditions = append(conditions, bson.M{"$or": snssaiMatches})
+	}
+	if len(query.interGroupIds) != 0 || len(query.supis) != 0 {
+		anyUe := bson.M{}
+		for _, name := range []string{"supi", "interGroupId", "ueIpv4", "ueIpv6", "ueMac"} {
+			anyUe[name] = bson.M{"$exists": false}
+		}
+		ueMatches := []bson.M{{"anyUeInd": true}, anyUe}
+		if len(query.interGroupIds) != 0 {
+			ueMatches = append(ueMatches, bson.M{"interGroupId": bson.M{"$in": query.interGroupIds}})
+		}
+		if len(query.supis) != 0 {
+			ueMatches = append(ueMatches, bson.M{"supi": bson.M{"$in": query.supis}})
+		}
+		conditions = append(conditions, bson.M{"$or": ueMatches})
+	}
+	for name, values := range map[string][]string{
+		"ueIpv4": query.ueIpv4s,
+		"ueIpv6": query.ueIpv6s,
+		"ueMac":  query.ueMacs,
+	} {
+		if len(values) != 0 {
+			conditions = append(conditions, bson.M{name: bson.M{"$in": values}})
+		}
+	}
+	if len(conditions) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"$and": conditions}
+}
+
+func (r *appDataResource) resUri(id string) string {
+	return fmt.Sprintf("%s/application-data/%s/%s",
+		udr_context.UDR_Self().GetIPv4GroupUri(udr_context.NUDR_DR), r.name, id)
+}
+
+// appDataScope holds the attributes of application data that the data filters
+// of subscriptions are matched against.
+type appDataScope struct {
+	Dnn          string         `json:"dnn"`
+	Snssai       *models.Snssai `json:"snssai"`
+	InterGroupId string         `json:"interGroupId"`
+	Supi         string         `json:"supi"`
+	UeIpv4       string         `json:"ueIpv4"`
+	UeIpv6       string         `json:"ueIpv6"`
+	UeMac        string         `json:"ueMac"`
+	AnyUeInd     bool           `json:"anyUeInd"`
+}
+
+// subsFilter matches the application data subscriptions covering data. A list
+// of a data filter does not restrict it when data lacks the attribute.
+func (r *appDataResource) subsFilter(data map[string]interface{}) bson.M {
+	var scope appDataScope
+	if err := json.Unmarshal(util.MapToByte(data), &scope); err != nil {
+		logger.DataRepoLog.Warnln(err)
+	}
+
+	conditions := []bson.M{}
+	if appId, ok := data[r.appIdName].(string); ok && r.appIdName != "" && appId != "" {
+		conditions = append(conditions, unsetOr("appIds", bson.M{"appIds": appId}))
+	}
+	if scope.Dnn != "" {
+		conditions = append(conditions, unsetOr("dnns", bson.M{"dnns": scope.Dnn}))
+	}
+	if snssai := scope.Snssai; snssai != nil {
+		conditions = append(conditions, unsetOr("snssais",
+			bson.M{"snssais": bson.M{"$elemMatch": snssaiMatch("", *snssai)}}))
+	}
+	if !scope.AnyUeInd && (scope.InterGroupId != "" || scope.Supi != "") {
+		conditions = append(conditions, ueSubsMatch(scope.InterGroupId, scope.Supi))
+	}
+	for name, value := range map[string]string{
+		"ueIpv4s": scope.UeIpv4,
+		"ueIpv6s": scope.UeIpv6,
+		"ueMacs":  scope.UeMac,
+	} {
+		if value != "" {
+			conditions = append(conditions, unsetOr(name, bson.M{name: value}))
+		}
+	}
+
+	extra := bson.M{}
+	if len(conditions) != 0 {
+		extra["$and"] = conditions
+	}
+	return applicationDataSubsFilter(r.dataInd, extra)
+}
+
+// notifyChange notifies the subscriptions covering the individual resource id
+// of its new content data, or of its deletion when deleted is set, in which
+// case data is the deleted content.
+func (r *appDataResource) notifyChange(id string, data map[string]interface{}, deleted bool) {
+	notif := callback.ApplicationDataChangeNotif{
+		ResUri: r.resUri(id),
+	}
+	if !deleted {
+		switch r.dataInd {
+		case DATA_IND_IPTV:
+			notif.IptvConfigData = data
+		case DATA_IND_BDT:
+			notif.BdtPolicyData = data
+		case DATA_IND_SVC_PARAM:
+			notif.SerParamData = data
+		}
+	}
+
+	for _, notificationUri := range getApplicationDataSubsNotificationUris(r.subsFilter(data)) {
+		go callback.SendApplicationDataChangeNotification(notificationUri,
+			[]callback.ApplicationDataChangeNotif{notif})
+	}
+}
+
+func (r *appDataResource) getCollection(query *appDataQuery) *httpwrapper.Response {
+	matched, err := mongoapi.RestfulAPIGetMany(r.collName, r.queryFilter(query))
+	if err != nil {
+		logger.DataRepoLog.Errorf("get %s err: %+v", r.name, err)
+		pd := util.ProblemDetailsSystemFailure(err.Error())
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	response := make([]map[string]interface{}, 0, len(matched))
+	for _, data := range matched {
+		// Delete the identifier entry which is added by us
+		delete(data, r.idName)
+		response = append(response, data)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+func (r *appDataResource) getIndividual(id string) *httpwrapper.Response {
+	data, pd := getDataFromDB(r.collName, bson.M{r.idName: id})
+	if pd != nil {
+		logger.DataRepoLog.Errorf("get %s %q err: %s", r.name, id, pd.Detail)
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	delete(data, r.idName)
+	return httpwrapper.NewResponse(http.StatusOK, nil, data)
+}
+
+func (r *appDataResource) putIndividual(id string, data map[string]interface{}) *httpwrapper.Response {
+	// Add the identifier entry to DB
+	data[r.idName] = id
+	existed, err := replaceDataInDB(r.collName, bson.M{r.idName: id}, data)
+	if err != nil {
+		logger.DataRepoLog.Errorf("put %s %q err: %+v", r.name, id, err)
+		pd := util.ProblemDetailsSystemFailure(err.Error())
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	// Roll back to origin data before return
+	delete(data, r.idName)
+
+	r.notifyChange(id, data, false)
+
+	if existed {
+		return httpwrapper.NewResponse(http.StatusOK, nil, data)
+	}
+	return httpwrapper.NewResponse(http.StatusCreated, nil, data)
+}
+
+// patchIndividual applies patchData as a JSON merge patch restricted to the
+// patchAttrs of the resource.
+func (r *appDataResource) patchIndividual(id string, patchData map[string]interface{}) *httpwrapper.Response {
+	for name := range patchData {
+		allowed := false
+		for _, patchAttr := range r.patchAttrs {
+			if name == patchAttr {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			pd := util.ProblemDetailsMalformedReqSyntax("Attribute cannot be patched: " + name)
+			return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+		}
+	}
+
+	filter := bson.M{r.idName: id}
+	oldData, pd := getDataFromDB(r.collName, filter)
+	if pd != nil {
+		logger.DataRepoLog.Errorf("patch %s %q err: %s", r.name, id, pd.Detail)
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	delete(oldData, r.idName)
+
+	original, err := json.Marshal(oldData)
+	if err != nil {
+		pd = util.ProblemDetailsSystemFailure(err.Error())
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	patch, err := json.Marshal(patchData)
+	if err != nil {
+		pd = util.ProblemDetailsSystemFailure(err.Error())
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	modified, err := jsonpatch.MergePatch(original, patch)
+	if err != nil {
+		pd = util.ProblemDetailsMalformedReqSyntax(err.Error())
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	if pd = r.check(modified); pd != nil {
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	var newData map[string]interface{}
+	if err = json.Unmarshal(modified, &newData); err != nil {
+		pd = util.ProblemDetailsSystemFailure(err.Error())
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+
+	newData[r.idName] = id
+	if _, err = replaceDataInDB(r.collName, filter, newData); err != nil {
+		logger.DataRepoLog.Errorf("patch %s %q err: %+v", r.name, id, err)
+		pd = util.ProblemDetailsSystemFailure(err.Error())
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	delete(newData, r.idName)
+
+	r.notifyChange(id, newData, false)
+	return httpwrapper.NewResponse(http.StatusOK, nil, newData)
+}
+
+func (r *appDataResource) deleteIndividual(id string) *httpwrapper.Response {
+	filter := bson.M{r.idName: id}
+	oldData, pd := getDataFromDB(r.collName, filter)
+	deleteDataFromDB(r.collName, filter)
+	if pd == nil {
+		delete(oldData, r.idName)
+		r.notifyChange(id, oldData, true)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func HandleApplicationDataServiceParamDataGet(queryParams map[string][]string) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataServiceParamDataGet: queryParams=%#v", queryParams)
+
+	snssais, err := parseSnssaiQuery(queryParams["snssais"])
+	if err != nil {
+		pd := util.ProblemDetailsMalformedReqSyntax("Invalid snssais: " + err.Error())
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	return serviceParamDataResource.getCollection(&appDataQuery{
+		ids:           parseQueryList(queryParams["service-param-ids"]),
+		dnns:          parseQueryList(queryParams["dnns"]),
+		snssais:       snssais,
+		interGroupIds: parseQueryList(queryParams["internal-group-ids"]),
+		supis:         parseQueryList(queryParams["supis"]),
+		ueIpv4s:       parseQueryList(queryParams["ue-ipv4s"]),
+		ueIpv6s:       parseQueryList(queryParams["ue-ipv6s"]),
+		ueMacs:        parseQueryList(queryParams["ue-macs"]),
+	})
+}
+
+func HandleApplicationDataServiceParamDataServiceParamIdGet(serviceParamID string) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataServiceParamDataServiceParamIdGet: serviceParamID=%q",
+		serviceParamID)
+
+	return serviceParamDataResource.getIndividual(serviceParamID)
+}
+
+func HandleApplicationDataServiceParamDataServiceParamIdPut(serviceParamID string,
+	serviceParamData *ServiceParameterData,
+) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataServiceParamDataServiceParamIdPut: serviceParamID=%q",
+		serviceParamID)
+
+	return serviceParamDataResource.putIndividual(serviceParamID, util.ToBsonM(*serviceParamData))
+}
+
+func HandleApplicationDataServiceParamDataServiceParamIdPatch(serviceParamID string,
+	patchData map[string]interface{},
+) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataServiceParamDataServiceParamIdPatch: serviceParamID=%q",
+		serviceParamID)
+
+	return serviceParamDataResource.patchIndividual(serviceParamID, patchData)
+}
+
+func HandleApplicationDataServiceParamDataServiceParamIdDelete(serviceParamID string) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataServiceParamDataServiceParamIdDelete: serviceParamID=%q",
+		serviceParamID)
+
+	return serviceParamDataResource.deleteIndividual(serviceParamID)
+}
+
+func HandleApplicationDataBdtPolicyDataGet(queryParams map[string][]string) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataBdtPolicyDataGet: queryParams=%#v", queryParams)
+
+	return bdtPolicyDataResource.getCollection(&appDataQuery{
+		ids:           parseQueryList(queryParams["bdt-policy-ids"]),
+		interGroupIds: parseQueryList(queryParams["internal-group-ids"]),
+		supis:         parseQueryList(queryParams["supis"]),
+	})
+}
+
+func HandleApplicationDataBdtPolicyDataBdtPolicyIdGet(bdtPolicyID string) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataBdtPolicyDataBdtPolicyIdGet: bdtPolicyID=%q", bdtPolicyID)
+
+	return bdtPolicyDataResource.getIndividual(bdtPolicyID)
+}
+
+func HandleApplicationDataBdtPolicyDataBdtPolicyIdPut(bdtPolicyID string,
+	bdtPolicyData *BdtPolicyData,
+) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataBdtPolicyDataBdtPolicyIdPut: bdtPolicyID=%q", bdtPolicyID)
+
+	if pd := validateBdtPolicyData(bdtPolicyData); pd != nil {
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	return bdtPolicyDataResource.putIndividual(bdtPolicyID, util.ToBsonM(*bdtPolicyData))
+}
+
+func HandleApplicationDataBdtPolicyDataBdtPolicyIdPatch(bdtPolicyID string,
+	patchData map[string]interface{},
+) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataBdtPolicyDataBdtPolicyIdPatch: bdtPolicyID=%q", bdtPolicyID)
+
+	return bdtPolicyDataResource.patchIndividual(bdtPolicyID, patchData)
+}
+
+func HandleApplicationDataBdtPolicyDataBdtPolicyIdDelete(bdtPolicyID string) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataBdtPolicyDataBdtPolicyIdDelete: bdtPolicyID=%q", bdtPolicyID)
+
+	return bdtPolicyDataResource.deleteIndividual(bdtPolicyID)
+}
+
+func HandleApplicationDataIptvConfigDataGet(queryParams map[string][]string) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataIptvConfigDataGet: queryParams=%#v", queryParams)
+
+	snssais, err := parseSnssaiQuery(queryParams["snssais"])
+	if err != nil {
+		pd := util.ProblemDetailsMalformedReqSyntax("Invalid snssais: " + err.Error())
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	return iptvConfigDataResource.getCollection(&appDataQuery{
+		ids:           parseQueryList(queryParams["config-ids"]),
+		dnns:          parseQueryList(queryParams["dnns"]),
+		snssais:       snssais,
+		interGroupIds: parseQueryList(queryParams["inter-group-ids"]),
+		supis:         parseQueryList(queryParams["supis"]),
+	})
+}
+
+func HandleApplicationDataIptvConfigDataConfigurationIdGet(configurationID string) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataIptvConfigDataConfigurationIdGet: configurationID=%q",
+		configurationID)
+
+	return iptvConfigDataResource.getIndividual(configurationID)
+}
+
+func HandleApplicationDataIptvConfigDataConfigurationIdPut(configurationID string,
+	iptvConfigData *IptvConfigData,
+) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataIptvConfigDataConfigurationIdPut: configurationID=%q",
+		configurationID)
+
+	if pd := validateIptvConfigData(iptvConfigData); pd != nil {
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	return iptvConfigDataResource.putIndividual(configurationID, util.ToBsonM(*iptvConfigData))
+}
+
+func HandleApplicationDataIptvConfigDataConfigurationIdPatch(configurationID string,
+	patchData map[string]interface{},
+) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataIptvConfigDataConfigurationIdPatch: configurationID=%q",
+		configurationID)
+
+	return iptvConfigDataResource.patchIndividual(configurationID, patchData)
+}
+
+func HandleApplicationDataIptvConfigDataConfigurationIdDelete(configurationID string) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataIptvConfigDataConfigurationIdDelete: configurationID=%q",
+		configurationID)
+
+	return iptvConfigDataResource.deleteIndividual(configurationID)
+}
@@ -0,0 +1,136 @@
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"runtime/debug"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+)
+
+const (
+	POLICYDATA_BDTDATA_DB_COLLECTION_NAME         = "policyData.bdtData"
+	POLICYDATA_BDTDATA_ARCHIVE_DB_COLLECTION_NAME = "policyData.bdtData.archive"
+)
+
+// BDT data documents keep the end of the transfer window of their transfer
+// policy as a BSON date beside the recTimeInt strings, so that it can be
+// queried.
+const BDTDATA_WINDOW_END_FIELD = "recTimeIntStopDate"
+
+// How often the expiry scheduler looks for BDT data whose transfer window
+// ended.
+var BdtDataExpiryCheckInterval = 10 * time.Second
+
+// bdtDataToDB adds the attributes the UDR keeps in a BDT data document beside
+// the BdtData itself.
+func bdtDataToDB(bdtReferenceId string, bdtData *models.BdtData, data map[string]interface{}) {
+	data["bdtReferenceId"] = bdtReferenceId
+	delete(data, BDTDATA_WINDOW_END_FIELD)
+	if window := bdtData.TransPolicy.RecTimeInt; window != nil {
+		if stopTime, err := time.Parse(time.RFC3339, window.StopTime); err == nil {
+			data[BDTDATA_WINDOW_END_FIELD] = stopTime.UTC()
+		}
+	}
+}
+
+// bdtDataFromDB removes the window end added by bdtDataToDB.
+func bdtDataFromDB(data map[string]interface{}) {
+	delete(data, BDTDATA_WINDOW_END_FIELD)
+}
+
+// bdtDataUnexpiredAt matches the BDT data whose transfer window has not ended
+// at t. Data without a window end never expires.
+func bdtDataUnexpiredAt(t time.Time) bson.M {
+	return bson.M{"$or": []bson.M{
+		{BDTDATA_WINDOW_END_FIELD: bson.M{"$exists": false}},
+		{BDTDATA_WINDOW_END_FIELD: bson.M{"$gt": t}},
+	}}
+}
+
+// StartBdtDataExpiryScheduler removes, or archives when configured, the BDT
+// data whose transfer window ended, and notifies the policy data subscribers.
+// Data that expired while the UDR was not running is removed on the first
+// check.
+func StartBdtDataExpiryScheduler() {
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Print stack for panic to log. Fatalf() will let program exit.
+				logger.DataRepoLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+		}()
+
+		ticker := time.NewTicker(BdtDataExpiryCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			expireBdtData(time.Now().UTC())
+		}
+	}()
+}
+
+// expireBdtData removes the BDT data whose transfer window ended at now.
+func expireBdtData(now time.Time) {
+	expired, err := database.GetMany(context.TODO(), POLICYDATA_BDTDATA_DB_COLLECTION_NAME,
+		bson.M{BDTDATA_WINDOW_END_FIELD: bson.M{"$lte": now}})
+	if err != nil {
+		logger.DataRepoLog.Errorf("expireBdtData err: %+v", err)
+		return
+	}
+
+	archive := udr_context.UDR_Self().ArchiveExpiredBdtData
+	var changes policyDataChanges
+	for _, data := range expired {
+		bdtReferenceId, ok := data["bdtReferenceId"].(string)
+		if !ok {
+			continue
+		}
+		// The data may have been updated with a later window, or removed, since
+		// it was listed, so it is read and removed again only if still expired.
+		filter := bson.M{"bdtReferenceId": bdtReferenceId, BDTDATA_WINDOW_END_FIELD: bson.M{"$lte": now}}
+
+		var deleted int64
+		err := database.WithTransaction(func(ctx context.Context) error {
+			var err error
+			deleted = 0
+			if data, err = database.GetOne(ctx, POLICYDATA_BDTDATA_DB_COLLECTION_NAME, filter); err != nil ||
+				data == nil {
+				return err
+			}
+			if archive {
+				if _, err = database.ReplaceOne(ctx, POLICYDATA_BDTDATA_ARCHIVE_DB_COLLECTION_NAME,
+					bson.M{"bdtReferenceId": bdtReferenceId}, data); err != nil {
+					return err
+				}
+			}
+			deleted, err = database.DeleteMany(ctx, POLICYDATA_BDTDATA_DB_COLLECTION_NAME, filter)
+			return err
+		})
+		if err != nil {
+			logger.DataRepoLog.Errorf("expireBdtData err: %+v", err)
+			continue
+		}
+		// Another UDR instance or a client may have removed or renewed it first
+		if deleted == 0 {
+			continue
+		}
+		logger.DataRepoLog.Infof("BDT data %q expired", bdtReferenceId)
+
+		bdtDataFromDB(data)
+		var bdtData models.BdtData
+		if err = json.Unmarshal(util.MapToByte(data), &bdtData); err != nil {
+			logger.DataRepoLog.Warnln(err)
+		} else {
+			changes.update("", bdtReferenceId, bdtData)
+		}
+		changes.remove("", "bdt-data/"+bdtReferenceId)
+	}
+	changes.send()
+}
@@ -1,13 +1,31 @@
 package producer
 
 import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
 	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/logger"
 	"github.com/free5gc/udr/internal/sbi/producer/callback"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/mongoapi"
 )
 
 func PreHandleOnDataChangeNotify(ueId string, resourceId string, patchItems []models.PatchItem,
 	origValue interface{}, newValue interface{},
 ) {
+	notifyItems := dataChangeNotifyItems(resourceId, patchItems, origValue, newValue)
+	go callback.SendOnDataChangeNotify(ueId, notifyItems)
+}
+
+// dataChangeNotifyItems returns the notify items of the change of the resource
+// resourceId by patchItems.
+func dataChangeNotifyItems(resourceId string, patchItems []models.PatchItem,
+	origValue interface{}, newValue interface{},
+) []models.NotifyItem {
 	notifyItems := []models.NotifyItem{}
 	changes := []models.ChangeItem{}
 
@@ -28,12 +46,26 @@ func PreHandleOnDataChangeNotify(ueId string, resourceId string, patchItems []mo
 	}
 
 	notifyItems = append(notifyItems, notifyItem)
-
-	go callback.SendOnDataChangeNotify(ueId, notifyItems)
+	return notifyItems
 }
 
 func PreHandlePolicyDataChangeNotification(ueId string, dataId string, value interface{}) {
-	policyDataChangeNotification := models.PolicyDataChangeNotification{}
+	var changes policyDataChanges
+	changes.update(ueId, dataId, value)
+	changes.send()
+}
+
+// policyDataChanges collects the policy data changes made by one request, so
+// that each subscriber receives them in a single notification array.
+type policyDataChanges struct {
+	notifications []callback.PolicyDataChangeNotification
+}
+
+// update adds the new value of a policy data resource. dataId identifies the
+// usage monitoring data, sponsor, BDT reference or, for a UE policy set without
+// ueId, the PLMN the value belongs to.
+func (c *policyDataChanges) update(ueId string, dataId string, value interface{}) {
+	policyDataChangeNotification := callback.PolicyDataChangeNotification{}
 
 	if ueId != "" {
 		policyDataChangeNotification.UeId = ueId
@@ -43,7 +75,17 @@ func PreHandlePolicyDataChangeNotification(ueId string, dataId string, value int
 	case models.AmPolicyData:
 		policyDataChangeNotification.AmPolicyData = &v
 	case models.UePolicySet:
-		policyDataChangeNotification.UePolicySet = &v
+		if ueId == "" {
+			// The UE policy set of the PLMN dataId
+			plmnId, err := util.PlmnIdStringToModels(dataId)
+			if err != nil {
+				logger.DataRepoLog.Warnf("PreHandlePolicyDataChangeNotification err: %+v", err)
+			}
+			policyDataChangeNotification.PlmnId = plmnId
+			policyDataChangeNotification.PlmnUePolicySet = &v
+		} else {
+			policyDataChangeNotification.UePolicySet = &v
+		}
 	case models.SmPolicyData:
 		policyDataChangeNotification.SmPolicyData = &v
 	case models.UsageMonData:
@@ -59,5 +101,201 @@ func PreHandlePolicyDataChangeNotification(ueId string, dataId string, value int
 		return
 	}
 
-	go callback.SendPolicyDataChangeNotification(policyDataChangeNotification)
+	c.notifications = append(c.notifications, policyDataChangeNotification)
+}
+
+// remove adds the deletion of the policy data resources at paths, which are
+// relative to the policy-data root, of the UE ueId or of no UE.
+func (c *policyDataChanges) remove(ueId string, paths ...string) {
+	if len(paths) == 0 {
+		return
+	}
+	policyDataChangeNotification := callback.PolicyDataChangeNotification{}
+	policyDataChangeNotification.UeId = ueId
+	for _, path := range paths {
+		policyDataChangeNotification.DelResources = append(policyDataChangeNotification.DelResources,
+			policyDataResUri(path))
+	}
+	c.notifications = append(c.notifications, policyDataChangeNotification)
+}
+
+func (c *policyDataChanges) send() {
+	if len(c.notifications) == 0 {
+		return
+	}
+	go callback.SendPolicyDataChangeNotification(c.notifications)
+}
+
+func policyDataResUri(path string) string {
+	return fmt.Sprintf("%s/policy-data/%s",
+		udr_context.UDR_Self().GetIPv4GroupUri(udr_context.NUDR_DR), path)
+}
+
+// unset matches documents whose array attribute name is absent or empty.
+func unset(name string) bson.M {
+	return bson.M{"$or": []bson.M{
+		{name: bson.M{"$exists": false}},
+		{name: bson.M{"$size": 0}},
+	}}
+}
+
+// unsetOr matches documents whose array attribute name is absent or empty, or
+// that satisfy match.
+func unsetOr(name string, match bson.M) bson.M {
+	return bson.M{"$or": []bson.M{unset(name), match}}
+}
+
+// ueSubsMatch matches the subscriptions without internalGroupIds and supis
+// lists, or whose lists contain interGroupId or supi.
+func ueSubsMatch(interGroupId, supi string) bson.M {
+	ueMatch := []bson.M{{"$and": []bson.M{unset("internalGroupIds"), unset("supis")}}}
+	if interGroupId != "" {
+		ueMatch = append(ueMatch, bson.M{"internalGroupIds": interGroupId})
+	}
+	if supi != "" {
+		ueMatch = append(ueMatch, bson.M{"supis": supi})
+	}
+	return bson.M{"$or": ueMatch}
+}
+
+// influenceDataSubsFilter matches the traffic influence subscriptions that
+// cover trInfluData. A subscription without a dnns, snssais, internalGroupIds
+// or supis list is not restricted by it, and data without a group or SUPI
+// applies to any UE.
+func influenceDataSubsFilter(trInfluData *models.TrafficInfluData) bson.M {
+	conditions := []bson.M{}
+	if trInfluData.Dnn != "" {
+		conditions = append(conditions, unsetOr("dnns", bson.M{"dnns": trInfluData.Dnn}))
+	}
+	if snssai := trInfluData.Snssai; snssai != nil {
+		conditions = append(conditions, unsetOr("snssais",
+			bson.M{"snssais": bson.M{"$elemMatch": snssaiMatch("", *snssai)}}))
+	}
+	if trInfluData.InterGroupId != "" || trInfluData.Supi != "" {
+		conditions = append(conditions, ueSubsMatch(trInfluData.InterGroupId, trInfluData.Supi))
+	}
+	if len(conditions) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"$and": conditions}
+}
+
+func notifyInfluenceDataSubscribers(trInfluData *models.TrafficInfluData, notif callback.TrafficInfluDataNotif) {
+	matchedSubs, err := mongoapi.RestfulAPIGetMany(APPDATA_INFLUDATA_SUBSC_DB_COLLECTION_NAME,
+		influenceDataSubsFilter(trInfluData))
+	if err != nil {
+		logger.DataRepoLog.Errorf("notifyInfluenceDataSubscribers err: %+v", err)
+		return
+	}
+	for _, sub := range matchedSubs {
+		if subscriptionExpired(sub) {
+			deleteDataFromDB(APPDATA_INFLUDATA_SUBSC_DB_COLLECTION_NAME,
+				bson.M{"subscriptionId": sub["subscriptionId"]})
+			continue
+		}
+		notificationUri, ok := sub["notificationUri"].(string)
+		if !ok || notificationUri == "" {
+			continue
+		}
+		go callback.SendTrafficInfluDataNotification(notificationUri, []callback.TrafficInfluDataNotif{notif})
+	}
+}
+
+func influenceDataResUri(influId string) string {
+	return fmt.Sprintf("%s/application-data/influenceData/%s",
+		udr_context.UDR_Self().GetIPv4GroupUri(udr_context.NUDR_DR), influId)
+}
+
+// PreHandleInfluenceDataUpdateNotification notifies the subscriptions covering
+// the created or updated traffic influence data. Data that is not valid yet is
+// not notified until the validity scheduler sees it become valid, and expired
+// data is notified like deleted data.
+func PreHandleInfluenceDataUpdateNotification(influId string, trInfluData models.TrafficInfluData) {
+	now := time.Now()
+	if trInfluData.ValidStartTime != nil && trInfluData.ValidStartTime.After(now) {
+		return
+	}
+	if !isInfluenceDataValidAt(&trInfluData, now) {
+		PreHandleInfluenceDataDeleteNotification(influId, trInfluData)
+		return
+	}
+	notif := callback.TrafficInfluDataNotif{
+		ResUri:           influenceDataResUri(influId),
+		TrafficInfluData: &trInfluData,
+	}
+	notifyInfluenceDataSubscribers(&trInfluData, notif)
+}
+
+// PreHandleInfluenceDataDeleteNotification notifies the subscriptions that
+// covered the deleted traffic influence data.
+func PreHandleInfluenceDataDeleteNotification(influId string, trInfluData models.TrafficInfluData) {
+	notif := callback.TrafficInfluDataNotif{
+		ResUri: influenceDataResUri(influId),
+	}
+	notifyInfluenceDataSubscribers(&trInfluData, notif)
+}
+
+// applicationDataSubsFilter matches the application data subscriptions that
+// have no data filters or a filter of kind dataInd that matches extra.
+func applicationDataSubsFilter(dataInd string, extra bson.M) bson.M {
+	dataFilterMatch := bson.M{"dataInd": dataInd}
+	for k, v := range extra {
+		dataFilterMatch[k] = v
+	}
+	return unsetOr("dataFilters", bson.M{"dataFilters": bson.M{"$elemMatch": dataFilterMatch}})
+}
+
+func getApplicationDataSubsNotificationUris(filter bson.M) []string {
+	matchedSubs, err := mongoapi.RestfulAPIGetMany(APPDATA_SUBSC_DB_COLLECTION_NAME, filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("getApplicationDataSubsNotificationUris err: %+v", err)
+		return nil
+	}
+	var notificationUris []string
+	for _, sub := range matchedSubs {
+		if subscriptionExpired(sub) {
+			deleteDataFromDB(APPDATA_SUBSC_DB_COLLECTION_NAME, bson.M{"subscriptionId": sub["subscriptionId"]})
+			continue
+		}
+		if notificationUri, ok := sub["notificationUri"].(string); ok && notificationUri != "" {
+			notificationUris = append(notificationUris, notificationUri)
+		}
+	}
+	return notificationUris
+}
+
+// subscriptionExpired reports whether the expiry of the stored subscription sub
+// has passed.
+func subscriptionExpired(sub map[string]interface{}) bool {
+	expiry, ok := sub["expiry"].(string)
+	if !ok || expiry == "" {
+		return false
+	}
+	expiryTime, err := time.Parse(time.RFC3339, expiry)
+	if err != nil {
+		logger.DataRepoLog.Warnf("Invalid expiry %q of subscription: %+v", expiry, err)
+		return false
+	}
+	return !expiryTime.After(time.Now())
+}
+
+// PreHandlePfdChangeNotification notifies the subscriptions to the PFDs of
+// appID. A nil pfdDataForApp means the PFDs were removed.
+func PreHandlePfdChangeNotification(appID string, pfdDataForApp *models.PfdDataForApp) {
+	notif := models.PfdChangeNotification{
+		ApplicationId: appID,
+	}
+	if pfdDataForApp == nil {
+		notif.RemovalFlag = true
+	} else {
+		notif.Pfds = pfdDataForApp.Pfds
+	}
+
+	filter := applicationDataSubsFilter(DATA_IND_PFD, bson.M{"$or": []bson.M{
+		unset("appIds"),
+		{"appIds": appID},
+	}})
+	for _, notificationUri := range getApplicationDataSubsNotificationUris(filter) {
+		go callback.SendPfdChangeNotification(notificationUri, []models.PfdChangeNotification{notif})
+	}
 }
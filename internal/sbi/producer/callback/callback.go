@@ -2,8 +2,12 @@ package callback
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
 	"runtime/debug"
 
+	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/Nudr_DataRepository"
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
@@ -19,31 +23,22 @@ func SendOnDataChangeNotify(ueId string, notifyItems []models.NotifyItem) {
 	}()
 
 	udrSelf := udr_context.UDR_Self()
-	configuration := Nudr_DataRepository.NewConfiguration()
-	client := Nudr_DataRepository.NewAPIClient(configuration)
-
+	var subscriptions []*models.SubscriptionDataSubscriptions
+	udrSelf.SubscriptionDataSubscriptionsMtx.RLock()
 	for _, subscriptionDataSubscription := range udrSelf.SubscriptionDataSubscriptions {
 		if ueId == subscriptionDataSubscription.UeId {
-			onDataChangeNotifyUrl := subscriptionDataSubscription.CallbackReference
-
-			dataChangeNotify := models.DataChangeNotify{}
-			dataChangeNotify.UeId = ueId
-			dataChangeNotify.OriginalCallbackReference = []string{subscriptionDataSubscription.OriginalCallbackReference}
-			dataChangeNotify.NotifyItems = notifyItems
-			httpResponse, err := client.DataChangeNotifyCallbackDocumentApi.OnDataChangeNotify(context.TODO(),
-				onDataChangeNotifyUrl, dataChangeNotify)
-			if err != nil {
-				if httpResponse == nil {
-					logger.HttpLog.Errorln(err.Error())
-				} else if err.Error() != httpResponse.Status {
-					logger.HttpLog.Errorln(err.Error())
-				}
-			}
+			subscriptions = append(subscriptions, subscriptionDataSubscription)
 		}
 	}
+	udrSelf.SubscriptionDataSubscriptionsMtx.RUnlock()
+	sendOnDataChangeNotify(subscriptions, ueId, notifyItems)
 }
 
-func SendPolicyDataChangeNotification(policyDataChangeNotification models.PolicyDataChangeNotification) {
+// SendOnDataChangeNotifyTo notifies the given subscriptions, which may already
+// be removed from the context, of the changes of ueId's data.
+func SendOnDataChangeNotifyTo(subscriptions []*models.SubscriptionDataSubscriptions, ueId string,
+	notifyItems []models.NotifyItem,
+) {
 	defer func() {
 		if p := recover(); p != nil {
 			// Print stack for panic to log. Fatalf() will let program exit.
@@ -51,15 +46,24 @@ func SendPolicyDataChangeNotification(policyDataChangeNotification models.Policy
 		}
 	}()
 
-	udrSelf := udr_context.UDR_Self()
+	sendOnDataChangeNotify(subscriptions, ueId, notifyItems)
+}
 
-	for _, policyDataSubscription := range udrSelf.PolicyDataSubscriptions {
-		policyDataChangeNotificationUrl := policyDataSubscription.NotificationUri
+func sendOnDataChangeNotify(subscriptions []*models.SubscriptionDataSubscriptions, ueId string,
+	notifyItems []models.NotifyItem,
+) {
+	configuration := Nudr_DataRepository.NewConfiguration()
+	client := Nudr_DataRepository.NewAPIClient(configuration)
+
+	for _, subscriptionDataSubscription := range subscriptions {
+		onDataChangeNotifyUrl := subscriptionDataSubscription.CallbackReference
 
-		configuration := Nudr_DataRepository.NewConfiguration()
-		client := Nudr_DataRepository.NewAPIClient(configuration)
-		httpResponse, err := client.PolicyDataChangeNotificationCallbackDocumentApi.PolicyDataChangeNotification(
-			context.TODO(), policyDataChangeNotificationUrl, policyDataChangeNotification)
+		dataChangeNotify := models.DataChangeNotify{}
+		dataChangeNotify.UeId = ueId
+		dataChangeNotify.OriginalCallbackReference = []string{subscriptionDataSubscription.OriginalCallbackReference}
+		dataChangeNotify.NotifyItems = notifyItems
+		httpResponse, err := client.DataChangeNotifyCallbackDocumentApi.OnDataChangeNotify(context.TODO(),
+			onDataChangeNotifyUrl, dataChangeNotify)
 		if err != nil {
 			if httpResponse == nil {
 				logger.HttpLog.Errorln(err.Error())
@@ -69,3 +73,128 @@ func SendPolicyDataChangeNotification(policyDataChangeNotification models.Policy
 		}
 	}
 }
+
+// PolicyDataChangeNotification is the TS 29.519 notification of a change of
+// policy data, which adds the UE policy set of a PLMN and the deleted resources
+// to the generated model.
+type PolicyDataChangeNotification struct {
+	models.PolicyDataChangeNotification
+	PlmnUePolicySet *models.UePolicySet `json:"plmnUePolicySet,omitempty"`
+	PlmnId          *models.PlmnId      `json:"plmnId,omitempty"`
+	DelResources    []string            `json:"delResources,omitempty"`
+}
+
+// SendPolicyDataChangeNotification sends policyDataChangeNotifications to every
+// policy data subscriber as one notification array.
+func SendPolicyDataChangeNotification(policyDataChangeNotifications []PolicyDataChangeNotification) {
+	defer func() {
+		if p := recover(); p != nil {
+			// Print stack for panic to log. Fatalf() will let program exit.
+			logger.HttpLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+		}
+	}()
+
+	udrSelf := udr_context.UDR_Self()
+
+	for _, policyDataSubscription := range udrSelf.PolicyDataSubscriptions {
+		if err := sendNotification(policyDataSubscription.NotificationUri, policyDataChangeNotifications); err != nil {
+			logger.HttpLog.Errorln(err.Error())
+		}
+	}
+}
+
+// TrafficInfluDataNotif is the TS 29.519 notification of a change of traffic
+// influence data. TrafficInfluData is absent when the data was deleted.
+type TrafficInfluDataNotif struct {
+	ResUri           string                   `json:"resUri"`
+	TrafficInfluData *models.TrafficInfluData `json:"trafficInfluData,omitempty"`
+}
+
+// sendNotification POSTs body as JSON to the notification URI of a subscription
+// that has no generated client.
+func sendNotification(notificationUri string, body interface{}) error {
+	configuration := Nudr_DataRepository.NewConfiguration()
+	headerParams := map[string]string{"Content-Type": "application/json"}
+	request, err := openapi.PrepareRequest(context.TODO(), configuration, notificationUri, http.MethodPost,
+		body, headerParams, url.Values{}, url.Values{}, "", "", nil)
+	if err != nil {
+		return err
+	}
+	httpResponse, err := openapi.CallAPI(configuration, request)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rspCloseErr := httpResponse.Body.Close(); rspCloseErr != nil {
+			logger.HttpLog.Errorf("Notification response body cannot close: %+v", rspCloseErr)
+		}
+	}()
+	if httpResponse.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("notification to %s failed: %s", notificationUri, httpResponse.Status)
+	}
+	return nil
+}
+
+func SendTrafficInfluDataNotification(notificationUri string, notifs []TrafficInfluDataNotif) {
+	defer func() {
+		if p := recover(); p != nil {
+			// Print stack for panic to log. Fatalf() will let program exit.
+			logger.HttpLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+		}
+	}()
+
+	if err := sendNotification(notificationUri, notifs); err != nil {
+		logger.HttpLog.Errorln(err.Error())
+	}
+}
+
+func SendPfdChangeNotification(notificationUri string, notifs []models.PfdChangeNotification) {
+	defer func() {
+		if p := recover(); p != nil {
+			// Print stack for panic to log. Fatalf() will let program exit.
+			logger.HttpLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+		}
+	}()
+
+	if err := sendNotification(notificationUri, notifs); err != nil {
+		logger.HttpLog.Errorln(err.Error())
+	}
+}
+
+// ApplicationDataChangeNotif is the TS 29.519 notification of a change of
+// application data other than PFDs. The changed data is absent when it was
+// deleted.
+type ApplicationDataChangeNotif struct {
+	IptvConfigData map[string]interface{} `json:"iptvConfigData,omitempty"`
+	BdtPolicyData  map[string]interface{} `json:"bdtPolicyData,omitempty"`
+	SerParamData   map[string]interface{} `json:"serParamData,omitempty"`
+	ResUri         string                 `json:"resUri"`
+}
+
+func SendApplicationDataChangeNotification(notificationUri string, notifs []ApplicationDataChangeNotif) {
+	defer func() {
+		if p := recover(); p != nil {
+			// Print stack for panic to log. Fatalf() will let program exit.
+			logger.HttpLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+		}
+	}()
+
+	if err := sendNotification(notificationUri, notifs); err != nil {
+		logger.HttpLog.Errorln(err.Error())
+	}
+}
+
+// SendMonitoringReports sends the monitoring reports of an EE subscription to
+// its callback reference.
+func SendMonitoringReports(callbackReference string, reports []models.MonitoringReport) {
+	defer func() {
+		if p := recover(); p != nil {
+			// Print stack for panic to log. Fatalf() will let program exit.
+			logger.HttpLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+		}
+	}()
+
+	if err := sendNotification(callbackReference, reports); err != nil {
+		logger.HttpLog.Errorln(err.Error())
+	}
+}
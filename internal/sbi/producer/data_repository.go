@@ -1,21 +1,26 @@
 package producer
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
-	"github.com/mitchellh/mapstructure"
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/identity"
 	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/repository"
 	"github.com/free5gc/udr/internal/util"
 	"github.com/free5gc/util/httpwrapper"
 	"github.com/free5gc/util/mongoapi"
@@ -25,6 +30,10 @@ const (
 	APPDATA_INFLUDATA_DB_COLLECTION_NAME       = "applicationData.influenceData"
 	APPDATA_INFLUDATA_SUBSC_DB_COLLECTION_NAME = "applicationData.influenceData.subsToNotify"
 	APPDATA_PFD_DB_COLLECTION_NAME             = "applicationData.pfds"
+	APPDATA_SUBSC_DB_COLLECTION_NAME           = "applicationData.subsToNotify"
+	APPDATA_SVCPARAM_DB_COLLECTION_NAME        = "applicationData.serviceParamData"
+	APPDATA_BDTPOLICY_DB_COLLECTION_NAME       = "applicationData.bdtPolicyData"
+	APPDATA_IPTVCONFIG_DB_COLLECTION_NAME      = "applicationData.iptvConfigData"
 )
 
 var CurrentResourceUri string
@@ -40,6 +49,12 @@ func getDataFromDB(collName string, filter bson.M) (map[string]interface{}, *mod
 	return data, nil
 }
 
+// replaceDataInDB replaces the whole document matching filter, so that
+// attributes absent from data are removed, or inserts data when there is none.
+func replaceDataInDB(collName string, filter bson.M, data map[string]interface{}) (bool, error) {
+	return database.ReplaceOne(context.TODO(), collName, filter, data)
+}
+
 func deleteDataFromDB(collName string, filter bson.M) {
 	if err := mongoapi.RestfulAPIDeleteOne(collName, filter); err != nil {
 		logger.DataRepoLog.Errorf("deleteDataFromDB: %+v", err)
@@ -61,10 +76,10 @@ func HandleQueryAccessAndMobilityData(request *httpwrapper.Request) *httpwrapper
 func HandleQueryAmData(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle QueryAmData")
 
-	collName := "subscriptionData.provisionedData.amData"
 	ueId := request.Params["ueId"]
 	servingPlmnId := request.Params["servingPlmnId"]
-	response, problemDetails := QueryAmDataProcedure(collName, ueId, servingPlmnId)
+	mergeSharedData := request.Query.Get("merge-shared-data") == "true"
+	response, problemDetails := QueryAmDataProcedure(ueId, servingPlmnId, mergeSharedData)
 
 	if problemDetails == nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -73,16 +88,32 @@ func HandleQueryAmData(request *httpwrapper.Request) *httpwrapper.Response {
 	}
 }
 
-func QueryAmDataProcedure(collName string, ueId string, servingPlmnId string) (*map[string]interface{},
-	*models.ProblemDetails,
-) {
-	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QueryAmDataProcedure err: %s", pd.Detail)
-		return nil, pd
+// QueryAmDataProcedure returns the access and mobility subscription data of the
+// UE, with the shared data it refers to merged in when mergeSharedData is set.
+func QueryAmDataProcedure(ueId string, servingPlmnId string,
+	mergeSharedData bool,
+) (*models.AccessAndMobilitySubscriptionData, *models.ProblemDetails) {
+	var data *models.AccessAndMobilitySubscriptionData
+	var err error
+	if mergeSharedData {
+		err = database.WithTransaction(func(ctx context.Context) error {
+			if data, err = repository.GetAmData(ctx, ueId, servingPlmnId); err != nil {
+				return err
+			}
+			data, err = repository.DereferenceAmData(ctx, data)
+			return err
+		})
+	} else {
+		data, err = repository.GetAmData(context.TODO(), ueId, servingPlmnId)
 	}
-	return &data, nil
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryAmDataProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandleAmfContext3gpp(request *httpwrapper.Request) *httpwrapper.Response {
@@ -91,7 +122,9 @@ func HandleAmfContext3gpp(request *httpwrapper.Request) *httpwrapper.Response {
 	patchItem := request.Body.([]models.PatchItem)
 	ueId := request.Params["ueId"]
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	problemDetails := AmfContext3gppProcedure(collName, ueId, patchItem)
+	history.record()
 	if problemDetails == nil {
 		return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 	} else {
@@ -119,13 +152,17 @@ func patchDataToDBAndNotify(collName string, ueId string, patchItem []models.Pat
 	if err != nil {
 		return err
 	}
-	PreHandleOnDataChangeNotify(ueId, CurrentResourceUri, patchItem, origValue, newValue)
+	PreHandleOnDataChangeNotify(ueId, CurrentResourceUri, patchItem, repository.WithoutSecrets(collName, origValue),
+		repository.WithoutSecrets(collName, newValue))
 	return nil
 }
 
 func AmfContext3gppProcedure(collName string, ueId string, patchItem []models.PatchItem) *models.ProblemDetails {
 	filter := bson.M{"ueId": ueId}
-	if err := patchDataToDBAndNotify(collName, ueId, patchItem, filter); err != nil {
+	err := observeAmf3GppAccess(ueId, func() error {
+		return patchDataToDBAndNotify(collName, ueId, patchItem, filter)
+	})
+	if err != nil {
 		logger.DataRepoLog.Errorf("AmfContext3gppProcedure err: %+v", err)
 		return util.ProblemDetailsModifyNotAllowed("")
 	}
@@ -139,7 +176,9 @@ func HandleCreateAmfContext3gpp(request *httpwrapper.Request) *httpwrapper.Respo
 	ueId := request.Params["ueId"]
 	collName := "subscriptionData.contextData.amf3gppAccess"
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	CreateAmfContext3gppProcedure(collName, ueId, Amf3GppAccessRegistration)
+	history.record()
 
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 }
@@ -151,7 +190,11 @@ func CreateAmfContext3gppProcedure(collName string, ueId string,
 	putData := util.ToBsonM(Amf3GppAccessRegistration)
 	putData["ueId"] = ueId
 
-	if _, err := mongoapi.RestfulAPIPutOne(collName, filter, putData); err != nil {
+	err := observeAmf3GppAccess(ueId, func() error {
+		_, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+		return err
+	})
+	if err != nil {
 		logger.DataRepoLog.Errorf("CreateAmfContext3gppProcedure err: %+v", err)
 	}
 }
@@ -160,9 +203,8 @@ func HandleQueryAmfContext3gpp(request *httpwrapper.Request) *httpwrapper.Respon
 	logger.DataRepoLog.Infof("Handle QueryAmfContext3gpp")
 
 	ueId := request.Params["ueId"]
-	collName := "subscriptionData.contextData.amf3gppAccess"
 
-	response, problemDetails := QueryAmfContext3gppProcedure(collName, ueId)
+	response, problemDetails := QueryAmfContext3gppProcedure(ueId)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -174,14 +216,16 @@ func HandleQueryAmfContext3gpp(request *httpwrapper.Request) *httpwrapper.Respon
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func QueryAmfContext3gppProcedure(collName string, ueId string) (*map[string]interface{}, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QueryAmfContext3gppProcedure err: %s", pd.Detail)
-		return nil, pd
+func QueryAmfContext3gppProcedure(ueId string) (*models.Amf3GppAccessRegistration, *models.ProblemDetails) {
+	data, err := repository.GetAmf3GppAccessRegistration(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryAmfContext3gppProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &data, nil
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandleAmfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.Response {
@@ -192,7 +236,9 @@ func HandleAmfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.Response
 	patchItem := request.Body.([]models.PatchItem)
 	filter := bson.M{"ueId": ueId}
 
+	history := newHistoryRecorder(request, collName, ueId, filter)
 	problemDetails := AmfContextNon3gppProcedure(ueId, collName, patchItem, filter)
+	history.record()
 
 	if problemDetails == nil {
 		return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
@@ -218,7 +264,9 @@ func HandleCreateAmfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.Re
 	collName := "subscriptionData.contextData.amfNon3gppAccess"
 	ueId := request.Params["ueId"]
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	CreateAmfContextNon3gppProcedure(AmfNon3GppAccessRegistration, collName, ueId)
+	history.record()
 
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 }
@@ -238,10 +286,9 @@ func CreateAmfContextNon3gppProcedure(AmfNon3GppAccessRegistration models.AmfNon
 func HandleQueryAmfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle QueryAmfContextNon3gpp")
 
-	collName := "subscriptionData.contextData.amfNon3gppAccess"
 	ueId := request.Params["ueId"]
 
-	response, problemDetails := QueryAmfContextNon3gppProcedure(collName, ueId)
+	response, problemDetails := QueryAmfContextNon3gppProcedure(ueId)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -253,14 +300,16 @@ func HandleQueryAmfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.Res
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func QueryAmfContextNon3gppProcedure(collName string, ueId string) (*map[string]interface{}, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QueryAmfContextNon3gppProcedure err: %s", pd.Detail)
-		return nil, pd
+func QueryAmfContextNon3gppProcedure(ueId string) (*models.AmfNon3GppAccessRegistration, *models.ProblemDetails) {
+	data, err := repository.GetAmfNon3GppAccessRegistration(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryAmfContextNon3gppProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &data, nil
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandleModifyAuthentication(request *httpwrapper.Request) *httpwrapper.Response {
@@ -270,7 +319,9 @@ func HandleModifyAuthentication(request *httpwrapper.Request) *httpwrapper.Respo
 	ueId := request.Params["ueId"]
 	patchItem := request.Body.([]models.PatchItem)
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	problemDetails := ModifyAuthenticationProcedure(collName, ueId, patchItem)
+	history.record()
 
 	if problemDetails == nil {
 		return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
@@ -291,10 +342,9 @@ func ModifyAuthenticationProcedure(collName string, ueId string, patchItem []mod
 func HandleQueryAuthSubsData(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle QueryAuthSubsData")
 
-	collName := "subscriptionData.authenticationData.authenticationSubscription"
 	ueId := request.Params["ueId"]
 
-	response, problemDetails := QueryAuthSubsDataProcedure(collName, ueId)
+	response, problemDetails := QueryAuthSubsDataProcedure(ueId)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -306,16 +356,15 @@ func HandleQueryAuthSubsData(request *httpwrapper.Request) *httpwrapper.Response
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func QueryAuthSubsDataProcedure(collName string, ueId string) (map[string]interface{}, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		if pd.Status == http.StatusNotFound {
-			logger.DataRepoLog.Warnf("QueryAuthSubsDataProcedure err: %s", pd.Title)
-		} else {
-			logger.DataRepoLog.Errorf("QueryAuthSubsDataProcedure err: %s", pd.Detail)
-		}
-		return nil, pd
+func QueryAuthSubsDataProcedure(ueId string) (*models.AuthenticationSubscription, *models.ProblemDetails) {
+	data, err := repository.GetAuthenticationSubscription(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryAuthSubsDataProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if data == nil {
+		logger.DataRepoLog.Warnf("QueryAuthSubsDataProcedure err: Data not found")
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
 	}
 	return data, nil
 }
@@ -326,7 +375,9 @@ func HandleCreateAuthenticationSoR(request *httpwrapper.Request) *httpwrapper.Re
 	ueId := request.Params["ueId"]
 	collName := "subscriptionData.ueUpdateConfirmationData.sorData"
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	CreateAuthenticationSoRProcedure(collName, ueId, putData)
+	history.record()
 
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 }
@@ -365,7 +416,7 @@ func QueryAuthSoRProcedure(collName string, ueId string) (map[string]interface{}
 		logger.DataRepoLog.Errorf("QueryAuthSoRProcedure err: %s", pd.Detail)
 		return nil, pd
 	}
-	return data, nil
+	return repository.StripInternalFields(data), nil
 }
 
 func HandleCreateAuthenticationStatus(request *httpwrapper.Request) *httpwrapper.Response {
@@ -375,7 +426,9 @@ func HandleCreateAuthenticationStatus(request *httpwrapper.Request) *httpwrapper
 	ueId := request.Params["ueId"]
 	collName := "subscriptionData.authenticationData.authenticationStatus"
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	CreateAuthenticationStatusProcedure(collName, ueId, putData)
+	history.record()
 
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 }
@@ -393,9 +446,8 @@ func HandleQueryAuthenticationStatus(request *httpwrapper.Request) *httpwrapper.
 	logger.DataRepoLog.Infof("Handle QueryAuthenticationStatus")
 
 	ueId := request.Params["ueId"]
-	collName := "subscriptionData.authenticationData.authenticationStatus"
 
-	response, problemDetails := QueryAuthenticationStatusProcedure(collName, ueId)
+	response, problemDetails := QueryAuthenticationStatusProcedure(ueId)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -407,105 +459,167 @@ func HandleQueryAuthenticationStatus(request *httpwrapper.Request) *httpwrapper.
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func QueryAuthenticationStatusProcedure(collName string, ueId string) (*map[string]interface{},
-	*models.ProblemDetails,
-) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QueryAuthenticationStatusProcedure err: %s", pd.Detail)
-		return nil, pd
+func QueryAuthenticationStatusProcedure(ueId string) (*models.AuthEvent, *models.ProblemDetails) {
+	data, err := repository.GetAuthenticationStatus(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryAuthenticationStatusProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &data, nil
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandleApplicationDataInfluenceDataGet(queryParams map[string][]string) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle ApplicationDataInfluenceDataGet: queryParams=%#v", queryParams)
 
-	influIDs := queryParams["influence-Ids"]
-	dnns := queryParams["dnns"]
-	snssais := queryParams["snssais"]
-	intGroupIDs := queryParams["internal-Group-Ids"]
-	supis := queryParams["supis"]
+	influIDs := parseQueryList(queryParams["influence-Ids"])
+	dnns := parseQueryList(queryParams["dnns"])
+	intGroupIDs := parseQueryList(queryParams["internal-Group-Ids"])
+	supis := parseQueryList(queryParams["supis"])
+	snssais, err := parseSnssaiQuery(queryParams["snssais"])
+	if err != nil {
+		pd := util.ProblemDetailsMalformedReqSyntax("Invalid snssais: " + err.Error())
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
 	if len(influIDs) == 0 && len(dnns) == 0 && len(snssais) == 0 && len(intGroupIDs) == 0 && len(supis) == 0 {
 		pd := util.ProblemDetailsMalformedReqSyntax("No query parameters")
 		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 	}
 
-	response := getApplicationDataInfluenceDatafromDB(influIDs, dnns, snssais, intGroupIDs, supis)
+	// Entries outside their validity window are only returned on request
+	includeInvalid := false
+	if values := queryParams["include-invalid"]; len(values) != 0 {
+		if includeInvalid, err = strconv.ParseBool(values[0]); err != nil {
+			pd := util.ProblemDetailsMalformedReqSyntax("Invalid include-invalid: " + values[0])
+			return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+		}
+	}
 
+	response, problemDetails := getApplicationDataInfluenceDatafromDB(influIDs, dnns, snssais, intGroupIDs, supis,
+		includeInvalid)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
 	return httpwrapper.NewResponse(http.StatusOK, nil, response)
 }
 
-func getApplicationDataInfluenceDatafromDB(influIDs, dnns, snssais,
-	intGroupIDs, supis []string,
-) []map[string]interface{} {
-	filter := bson.M{}
-	allInfluDatas, err := mongoapi.RestfulAPIGetMany(APPDATA_INFLUDATA_DB_COLLECTION_NAME, filter)
-	if err != nil {
-		logger.DataRepoLog.Errorf("getApplicationDataInfluenceDatafromDB err: %+v", err)
-		return nil
-	}
-	var matchedInfluDatas []map[string]interface{}
-	matchedInfluDatas = filterDataByString("influenceId", influIDs, allInfluDatas)
-	matchedInfluDatas = filterDataByString("dnn", dnns, matchedInfluDatas)
-	matchedInfluDatas = filterDataByString("interGroupId", intGroupIDs, matchedInfluDatas)
-	matchedInfluDatas = filterDataByString("supi", supis, matchedInfluDatas)
-	matchedInfluDatas = filterDataBySnssai(snssais, matchedInfluDatas)
-	for i := 0; i < len(matchedInfluDatas); i++ {
-		// Delete "influenceId" entry which is added by us
-		delete(matchedInfluDatas[i], "influenceId")
+// parseQueryList splits query values given as comma separated lists, as well
+// as repeated query parameters, into their elements.
+func parseQueryList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			if element = strings.TrimSpace(element); element != "" {
+				list = append(list, element)
+			}
+		}
 	}
-	return matchedInfluDatas
+	return list
 }
 
-func filterDataByString(filterName string, filterValues []string,
-	datas []map[string]interface{},
-) []map[string]interface{} {
-	if len(filterValues) == 0 {
-		return datas
-	}
-	var matchedDatas []map[string]interface{}
-	for _, data := range datas {
-		for _, v := range filterValues {
-			if data[filterName].(string) == v {
-				matchedDatas = append(matchedDatas, data)
-				break
+// parseSnssaiQuery decodes query values holding a JSON Snssai or a JSON array
+// of Snssai.
+func parseSnssaiQuery(values []string) ([]models.Snssai, error) {
+	var snssais []models.Snssai
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		var decoded []models.Snssai
+		if strings.HasPrefix(value, "[") {
+			if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+				return nil, err
+			}
+		} else {
+			var snssai models.Snssai
+			if err := json.Unmarshal([]byte(value), &snssai); err != nil {
+				return nil, err
 			}
+			decoded = []models.Snssai{snssai}
 		}
+		for _, snssai := range decoded {
+			if snssai.Sst < 0 || snssai.Sst > 255 {
+				return nil, fmt.Errorf("sst %d out of range", snssai.Sst)
+			}
+		}
+		snssais = append(snssais, decoded...)
 	}
-	return matchedDatas
+	return snssais, nil
 }
 
-func filterDataBySnssai(snssaiValues []string,
-	datas []map[string]interface{},
-) []map[string]interface{} {
-	if len(snssaiValues) == 0 {
-		return datas
+// snssaiMatch matches an embedded Snssai attribute equal to snssai.
+func snssaiMatch(prefix string, snssai models.Snssai) bson.M {
+	match := bson.M{prefix + "sst": snssai.Sst}
+	if snssai.Sd != "" {
+		match[prefix+"sd"] = snssai.Sd
+	} else {
+		match[prefix+"sd"] = bson.M{"$exists": false}
+	}
+	return match
+}
+
+// influenceDataFilter builds the query of traffic influence data. Data that
+// applies to any UE, with anyUeInd set or without SUPI and group, matches any
+// supis and internal-Group-Ids.
+func influenceDataFilter(influIDs, dnns []string, snssais []models.Snssai,
+	intGroupIDs, supis []string,
+) bson.M {
+	conditions := []bson.M{}
+	if len(influIDs) != 0 {
+		conditions = append(conditions, bson.M{"influenceId": bson.M{"$in": influIDs}})
 	}
-	var matchedDatas []map[string]interface{}
-	for _, data := range datas {
-		var dataSnssai models.Snssai
-		if err := json.Unmarshal(
-			util.MapToByte(data["snssai"].(map[string]interface{})), &dataSnssai); err != nil {
-			logger.DataRepoLog.Warnln(err)
-			break
+	if len(dnns) != 0 {
+		conditions = append(conditions, bson.M{"dnn": bson.M{"$in": dnns}})
+	}
+	if len(snssais) != 0 {
+		snssaiMatches := make([]bson.M, 0, len(snssais))
+		for _, snssai := range snssais {
+			snssaiMatches = append(snssaiMatches, snssaiMatch("snssai.", snssai))
 		}
-		logger.DataRepoLog.Debugf("dataSnssai=%#v", dataSnssai)
-		for _, v := range snssaiValues {
-			var filterSnssai models.Snssai
-			if err := json.Unmarshal([]byte(v), &filterSnssai); err != nil {
-				logger.DataRepoLog.Warnln(err)
-				break
-			}
-			logger.DataRepoLog.Debugf("filterSnssai=%#v", filterSnssai)
-			if dataSnssai.Sd == filterSnssai.Sd && dataSnssai.Sst == filterSnssai.Sst {
-				matchedDatas = append(matchedDatas, data)
-				break
-			}
+		conditions = append(conditions, bson.M{"$or": snssaiMatches})
+	}
+	if len(intGroupIDs) != 0 || len(supis) != 0 {
+		ueMatches := []bson.M{
+			{"anyUeInd": true},
+			{"supi": bson.M{"$exists": false}, "interGroupId": bson.M{"$exists": false}},
+		}
+		if len(intGroupIDs) != 0 {
+			ueMatches = append(ueMatches, bson.M{"interGroupId": bson.M{"$in": intGroupIDs}})
 		}
+		if len(supis) != 0 {
+			ueMatches = append(ueMatches, bson.M{"supi": bson.M{"$in": supis}})
+		}
+		conditions = append(conditions, bson.M{"$or": ueMatches})
+	}
+	if len(conditions) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"$and": conditions}
+}
+
+func getApplicationDataInfluenceDatafromDB(influIDs, dnns []string, snssais []models.Snssai,
+	intGroupIDs, supis []string, includeInvalid bool,
+) ([]map[string]interface{}, *models.ProblemDetails) {
+	filter := influenceDataFilter(influIDs, dnns, snssais, intGroupIDs, supis)
+	if !includeInvalid {
+		filter = bson.M{"$and": []bson.M{filter, influenceDataValidAt(time.Now().UTC())}}
+	}
+	matchedInfluDatas, err := mongoapi.RestfulAPIGetMany(APPDATA_INFLUDATA_DB_COLLECTION_NAME, filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("getApplicationDataInfluenceDatafromDB err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	for i := 0; i < len(matchedInfluDatas); i++ {
+		// Delete entries which are added by us
+		influenceDataFromDB(matchedInfluDatas[i])
 	}
-	return matchedDatas
+	if matchedInfluDatas == nil {
+		matchedInfluDatas = []map[string]interface{}{}
+	}
+	return matchedInfluDatas, nil
 }
 
 func HandleApplicationDataInfluenceDataInfluenceIdDelete(influId string) *httpwrapper.Response {
@@ -518,58 +632,87 @@ func HandleApplicationDataInfluenceDataInfluenceIdDelete(influId string) *httpwr
 
 func deleteApplicationDataIndividualInfluenceDataFromDB(influId string) {
 	filter := bson.M{"influenceId": influId}
+	oldData, pd := getDataFromDB(APPDATA_INFLUDATA_DB_COLLECTION_NAME, filter)
 	deleteDataFromDB(APPDATA_INFLUDATA_DB_COLLECTION_NAME, filter)
+	if pd != nil {
+		return
+	}
+
+	var trInfluData models.TrafficInfluData
+	if err := json.Unmarshal(util.MapToByte(oldData), &trInfluData); err != nil {
+		logger.DataRepoLog.Warnln(err)
+		return
+	}
+	PreHandleInfluenceDataDeleteNotification(influId, trInfluData)
 }
 
 func HandleApplicationDataInfluenceDataInfluenceIdPatch(influID string,
-	trInfluDataPatch *models.TrafficInfluDataPatch,
+	patchData map[string]interface{},
 ) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle ApplicationDataInfluenceDataInfluenceIdPatch: influID=%q", influID)
 
-	response, status := patchApplicationDataIndividualInfluenceDataToDB(influID, trInfluDataPatch)
-
-	return httpwrapper.NewResponse(status, nil, response)
+	response, problemDetails := patchApplicationDataIndividualInfluenceDataToDB(influID, patchData)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
 }
 
+// patchApplicationDataIndividualInfluenceDataToDB applies a TrafficInfluDataPatch
+// as a JSON merge patch: attributes absent from the patch are kept and null
+// attributes are removed.
 func patchApplicationDataIndividualInfluenceDataToDB(influID string,
-	trInfluDataPatch *models.TrafficInfluDataPatch,
-) (bson.M, int) {
+	patchData map[string]interface{},
+) (map[string]interface{}, *models.ProblemDetails) {
 	filter := bson.M{"influenceId": influID}
 
 	oldData, pd := getDataFromDB(APPDATA_INFLUDATA_DB_COLLECTION_NAME, filter)
 	if pd != nil {
 		logger.DataRepoLog.Errorf("patchApplicationDataIndividualInfluenceDataToDB err: %s", pd.Detail)
-		return nil, http.StatusNotFound
+		return nil, pd
+	}
+	influenceDataFromDB(oldData)
+
+	// TrafficInfluDataPatch names the group attribute differently
+	if internalGroupId, ok := patchData["internalGroupId"]; ok {
+		patchData["interGroupId"] = internalGroupId
+		delete(patchData, "internalGroupId")
+	}
+	delete(patchData, "influenceId")
+
+	original, err := json.Marshal(oldData)
+	if err != nil {
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	patch, err := json.Marshal(patchData)
+	if err != nil {
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	modified, err := jsonpatch.MergePatch(original, patch)
+	if err != nil {
+		return nil, util.ProblemDetailsMalformedReqSyntax(err.Error())
+	}
+
+	var trInfluData models.TrafficInfluData
+	if err = json.Unmarshal(modified, &trInfluData); err != nil {
+		return nil, util.ProblemDetailsMalformedReqSyntax(err.Error())
+	}
+	var newData map[string]interface{}
+	if err = json.Unmarshal(modified, &newData); err != nil {
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
 
-	trInfluData := models.TrafficInfluData{
-		UpPathChgNotifCorreId: trInfluDataPatch.UpPathChgNotifCorreId,
-		AppReloInd:            trInfluDataPatch.AppReloInd,
-		AfAppId:               oldData["afAppId"].(string),
-		Dnn:                   trInfluDataPatch.Dnn,
-		EthTrafficFilters:     trInfluDataPatch.EthTrafficFilters,
-		Snssai:                trInfluDataPatch.Snssai,
-		InterGroupId:          trInfluDataPatch.InternalGroupId,
-		Supi:                  trInfluDataPatch.Supi,
-		TrafficFilters:        trInfluDataPatch.TrafficFilters,
-		TrafficRoutes:         trInfluDataPatch.TrafficRoutes,
-		ValidStartTime:        trInfluDataPatch.ValidStartTime,
-		ValidEndTime:          trInfluDataPatch.ValidEndTime,
-		NwAreaInfo:            trInfluDataPatch.NwAreaInfo,
-		UpPathChgNotifUri:     trInfluDataPatch.UpPathChgNotifUri,
-	}
-	newData := util.ToBsonM(trInfluData)
-
-	// Add "influenceId" entry to DB
-	newData["influenceId"] = influID
-	if _, err := mongoapi.RestfulAPIPutOne(APPDATA_INFLUDATA_DB_COLLECTION_NAME, filter, newData); err != nil {
+	// Add entries of the UDR to DB
+	influenceDataToDB(influID, &trInfluData, newData)
+	if _, err = replaceDataInDB(APPDATA_INFLUDATA_DB_COLLECTION_NAME, filter, newData); err != nil {
 		logger.DataRepoLog.Errorf("patchApplicationDataIndividualInfluenceDataToDB err: %+v", err)
-		return nil, http.StatusInternalServerError
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
 	// Roll back to origin data before return
-	delete(newData, "influenceId")
+	influenceDataFromDB(newData)
 
-	return newData, http.StatusOK
+	PreHandleInfluenceDataUpdateNotification(influID, trInfluData)
+	return newData, nil
 }
 
 func HandleApplicationDataInfluenceDataInfluenceIdPut(influID string,
@@ -588,17 +731,18 @@ func putApplicationDataIndividualInfluenceDataToDB(influID string,
 	filter := bson.M{"influenceId": influID}
 	data := util.ToBsonM(*trInfluData)
 
-	// Add "influenceId" entry to DB
-	data["influenceId"] = influID
-	existed, err := mongoapi.RestfulAPIPutOne(APPDATA_INFLUDATA_DB_COLLECTION_NAME, filter, data)
+	// Add entries of the UDR to DB
+	influenceDataToDB(influID, trInfluData, data)
+	existed, err := replaceDataInDB(APPDATA_INFLUDATA_DB_COLLECTION_NAME, filter, data)
 	if err != nil {
 		logger.DataRepoLog.Errorf("putApplicationDataIndividualInfluenceDataToDB err: %+v", err)
 		return nil, http.StatusInternalServerError
 	}
 
 	// Roll back to origin data before return
-	delete(data, "influenceId")
+	influenceDataFromDB(data)
 
+	PreHandleInfluenceDataUpdateNotification(influID, *trInfluData)
 	if existed {
 		return data, http.StatusOK
 	}
@@ -633,18 +777,32 @@ func HandleApplicationDataInfluenceDataSubsToNotifyGet(queryParams map[string][]
 		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 	}
 
-	response := getApplicationDataInfluenceDataSubsToNotifyfromDB(dnn, snssai, intGroupID, supi)
+	var snssaiFilter *models.Snssai
+	if len(snssai) != 0 {
+		snssaiFilter = &models.Snssai{}
+		if err := json.Unmarshal([]byte(snssai[0]), snssaiFilter); err != nil {
+			pd := util.ProblemDetailsMalformedReqSyntax("Invalid snssai: " + err.Error())
+			return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+		}
+	}
 
+	response, problemDetails := getApplicationDataInfluenceDataSubsToNotifyfromDB(dnn, snssaiFilter, intGroupID, supi)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
 	return httpwrapper.NewResponse(http.StatusOK, nil, response)
 }
 
-func getApplicationDataInfluenceDataSubsToNotifyfromDB(dnn, snssai, intGroupID,
+func getApplicationDataInfluenceDataSubsToNotifyfromDB(dnn []string, snssai *models.Snssai, intGroupID,
 	supi []string,
-) []map[string]interface{} {
+) ([]map[string]interface{}, *models.ProblemDetails) {
 	filter := bson.M{}
 	if len(dnn) != 0 {
 		filter["dnns"] = dnn[0]
 	}
+	if snssai != nil {
+		filter["snssais"] = bson.M{"$elemMatch": snssaiMatch("", *snssai)}
+	}
 	if len(intGroupID) != 0 {
 		filter["internalGroupIds"] = intGroupID[0]
 	}
@@ -654,48 +812,21 @@ func getApplicationDataInfluenceDataSubsToNotifyfromDB(dnn, snssai, intGroupID,
 	matchedSubs, err := mongoapi.RestfulAPIGetMany(APPDATA_INFLUDATA_SUBSC_DB_COLLECTION_NAME, filter)
 	if err != nil {
 		logger.DataRepoLog.Errorf("getApplicationDataInfluenceDataSubsToNotifyfromDB err: %+v", err)
-		return nil
-	}
-	if len(snssai) != 0 {
-		matchedSubs = filterDataBySnssais(snssai[0], matchedSubs)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
 	for i := 0; i < len(matchedSubs); i++ {
-		// Delete "_id" entry which is auto-inserted by MongoDB
-		delete(matchedSubs[i], "_id")
 		// Delete "subscriptionId" entry which is added by us
 		delete(matchedSubs[i], "subscriptionId")
 	}
-	return matchedSubs
-}
-
-func filterDataBySnssais(snssaiValue string,
-	datas []map[string]interface{},
-) []map[string]interface{} {
-	var matchedDatas []map[string]interface{}
-	var filterSnssai models.Snssai
-	if err := json.Unmarshal([]byte(snssaiValue), &filterSnssai); err != nil {
-		logger.DataRepoLog.Warnln(err)
+	if matchedSubs == nil {
+		matchedSubs = []map[string]interface{}{}
 	}
-	logger.DataRepoLog.Debugf("filterSnssai=%#v", filterSnssai)
-	for _, data := range datas {
-		var dataSnssais []models.Snssai
-		if err := json.Unmarshal(
-			util.PrimitiveAToByte(data["snssais"].(primitive.A)), &dataSnssais); err != nil {
-			logger.DataRepoLog.Warnln(err)
-			break
-		}
-		logger.DataRepoLog.Debugf("dataSnssais=%#v", dataSnssais)
-		for _, v := range dataSnssais {
-			if v.Sd == filterSnssai.Sd && v.Sst == filterSnssai.Sst {
-				matchedDatas = append(matchedDatas, data)
-				break
-			}
-		}
-	}
-	return matchedDatas
+	return matchedSubs, nil
 }
 
-func HandleApplicationDataInfluenceDataSubsToNotifyPost(trInfluSub *models.TrafficInfluSub) *httpwrapper.Response {
+func HandleApplicationDataInfluenceDataSubsToNotifyPost(
+	trInfluSub *models.UdrTrafficInfluSub,
+) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle ApplicationDataInfluenceDataSubsToNotifyPost")
 	udrSelf := udr_context.UDR_Self()
 
@@ -713,7 +844,7 @@ func HandleApplicationDataInfluenceDataSubsToNotifyPost(trInfluSub *models.Traff
 }
 
 func postApplicationDataInfluenceDataSubsToNotifyToDB(subscID string,
-	trInfluSub *models.TrafficInfluSub,
+	trInfluSub *models.UdrTrafficInfluSub,
 ) (bson.M, int) {
 	filter := bson.M{"subscriptionId": subscID}
 	data := util.ToBsonM(*trInfluSub)
@@ -770,7 +901,7 @@ func getApplicationDataIndividualInfluenceDataSubsToNotifyFromDB(
 }
 
 func HandleApplicationDataInfluenceDataSubsToNotifySubscriptionIdPut(
-	subscID string, trInfluSub *models.TrafficInfluSub,
+	subscID string, trInfluSub *models.UdrTrafficInfluSub,
 ) *httpwrapper.Response {
 	logger.DataRepoLog.Infof(
 		"Handle HandleApplicationDataInfluenceDataSubsToNotifySubscriptionIdPut: subscID=%q", subscID)
@@ -781,7 +912,7 @@ func HandleApplicationDataInfluenceDataSubsToNotifySubscriptionIdPut(
 }
 
 func putApplicationDataIndividualInfluenceDataSubsToNotifyToDB(subscID string,
-	trInfluSub *models.TrafficInfluSub,
+	trInfluSub *models.UdrTrafficInfluSub,
 ) (bson.M, int) {
 	filter := bson.M{"subscriptionId": subscID}
 	newData := util.ToBsonM(*trInfluSub)
@@ -813,7 +944,11 @@ func HandleApplicationDataPfdsAppIdDelete(appID string) *httpwrapper.Response {
 
 func deleteApplicationDataIndividualPfdFromDB(appID string) {
 	filter := bson.M{"applicationId": appID}
+	_, pd := getDataFromDB(APPDATA_PFD_DB_COLLECTION_NAME, filter)
 	deleteDataFromDB(APPDATA_PFD_DB_COLLECTION_NAME, filter)
+	if pd == nil {
+		PreHandlePfdChangeNotification(appID, nil)
+	}
 }
 
 func HandleApplicationDataPfdsAppIdGet(appID string) *httpwrapper.Response {
@@ -855,6 +990,8 @@ func putApplicationDataIndividualPfdToDB(appID string, pfdDataForApp *models.Pfd
 		return nil, http.StatusInternalServerError
 	}
 
+	PreHandlePfdChangeNotification(appID, pfdDataForApp)
+
 	if existed {
 		return data, http.StatusOK
 	}
@@ -894,6 +1031,138 @@ func getApplicationDataPfdsFromDB(pfdsAppIDs []string) (response []map[string]in
 	return matchedPfds
 }
 
+// Values of DataFilter.DataInd, the kind of application data a subscription
+// is about.
+const (
+	DATA_IND_PFD       = "PFD"
+	DATA_IND_IPTV      = "IPTV"
+	DATA_IND_BDT       = "BDT"
+	DATA_IND_SVC_PARAM = "SVC_PARAM"
+)
+
+// DataFilter selects the application data a subscription is notified of. An
+// absent list does not restrict the selection.
+type DataFilter struct {
+	DataInd          string          `json:"dataInd" bson:"dataInd"`
+	Dnns             []string        `json:"dnns,omitempty" bson:"dnns"`
+	Snssais          []models.Snssai `json:"snssais,omitempty" bson:"snssais"`
+	InternalGroupIds []string        `json:"internalGroupIds,omitempty" bson:"internalGroupIds"`
+	Supis            []string        `json:"supis,omitempty" bson:"supis"`
+	AppIds           []string        `json:"appIds,omitempty" bson:"appIds"`
+	UeIpv4s          []string        `json:"ueIpv4s,omitempty" bson:"ueIpv4s"`
+	UeIpv6s          []string        `json:"ueIpv6s,omitempty" bson:"ueIpv6s"`
+	UeMacs           []string        `json:"ueMacs,omitempty" bson:"ueMacs"`
+}
+
+// ApplicationDataSubs is a subscription to changes of application data. A
+// subscription without data filters is notified of every change.
+type ApplicationDataSubs struct {
+	NotificationUri   string       `json:"notificationUri" bson:"notificationUri"`
+	DataFilters       []DataFilter `json:"dataFilters,omitempty" bson:"dataFilters"`
+	Expiry            *time.Time   `json:"expiry,omitempty" bson:"expiry"`
+	SupportedFeatures string       `json:"supportedFeatures,omitempty" bson:"supportedFeatures"`
+}
+
+func validateApplicationDataSubs(appDataSubs *ApplicationDataSubs) *models.ProblemDetails {
+	if appDataSubs.NotificationUri == "" {
+		return util.ProblemDetailsMalformedReqSyntax("Missing notificationUri")
+	}
+	for _, dataFilter := range appDataSubs.DataFilters {
+		switch dataFilter.DataInd {
+		case DATA_IND_PFD, DATA_IND_IPTV, DATA_IND_BDT, DATA_IND_SVC_PARAM:
+		default:
+			return util.ProblemDetailsMalformedReqSyntax("Invalid dataInd: " + dataFilter.DataInd)
+		}
+	}
+	if appDataSubs.Expiry != nil && !appDataSubs.Expiry.After(time.Now()) {
+		return util.ProblemDetailsMalformedReqSyntax("Expiry is in the past")
+	}
+	return nil
+}
+
+func HandleApplicationDataSubsToNotifyPost(appDataSubs *ApplicationDataSubs) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataSubsToNotifyPost")
+	udrSelf := udr_context.UDR_Self()
+
+	if pd := validateApplicationDataSubs(appDataSubs); pd != nil {
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+
+	// Subscriptions outlive the process, so the identifier must not be reused
+	// after a restart.
+	newSubscID := uuid.New().String()
+	response, status := putApplicationDataSubsToNotifyToDB(newSubscID, appDataSubs)
+
+	/* Contains the URI of the newly created resource, according
+	   to the structure: {apiRoot}/application-data/subs-to-notify/{subsId} */
+	locationHeader := fmt.Sprintf("%s/application-data/subs-to-notify/%s",
+		udrSelf.GetIPv4GroupUri(udr_context.NUDR_DR), newSubscID)
+	logger.DataRepoLog.Infof("locationHeader:%q", locationHeader)
+	headers := http.Header{}
+	headers.Set("Location", locationHeader)
+	if status == http.StatusOK {
+		status = http.StatusCreated
+	}
+	return httpwrapper.NewResponse(status, headers, response)
+}
+
+func putApplicationDataSubsToNotifyToDB(subscID string, appDataSubs *ApplicationDataSubs) (bson.M, int) {
+	filter := bson.M{"subscriptionId": subscID}
+	data := util.ToBsonM(*appDataSubs)
+
+	// Add "subscriptionId" entry to DB
+	data["subscriptionId"] = subscID
+	if _, err := mongoapi.RestfulAPIPutOne(APPDATA_SUBSC_DB_COLLECTION_NAME, filter, data); err != nil {
+		logger.DataRepoLog.Errorf("putApplicationDataSubsToNotifyToDB err: %+v", err)
+		return nil, http.StatusInternalServerError
+	}
+	// Revert back to origin data before return
+	delete(data, "subscriptionId")
+	return data, http.StatusOK
+}
+
+func HandleApplicationDataSubsToNotifySubsIdDelete(subscID string) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataSubsToNotifySubsIdDelete: subscID=%q", subscID)
+
+	filter := bson.M{"subscriptionId": subscID}
+	if _, pd := getDataFromDB(APPDATA_SUBSC_DB_COLLECTION_NAME, filter); pd != nil {
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	deleteDataFromDB(APPDATA_SUBSC_DB_COLLECTION_NAME, filter)
+
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func HandleApplicationDataSubsToNotifySubsIdGet(subscID string) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataSubsToNotifySubsIdGet: subscID=%q", subscID)
+
+	filter := bson.M{"subscriptionId": subscID}
+	data, pd := getDataFromDB(APPDATA_SUBSC_DB_COLLECTION_NAME, filter)
+	if pd != nil {
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	// Delete "subscriptionId" entry which is added by us
+	delete(data, "subscriptionId")
+	return httpwrapper.NewResponse(http.StatusOK, nil, data)
+}
+
+func HandleApplicationDataSubsToNotifySubsIdPut(subscID string,
+	appDataSubs *ApplicationDataSubs,
+) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ApplicationDataSubsToNotifySubsIdPut: subscID=%q", subscID)
+
+	if pd := validateApplicationDataSubs(appDataSubs); pd != nil {
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	filter := bson.M{"subscriptionId": subscID}
+	if _, pd := getDataFromDB(APPDATA_SUBSC_DB_COLLECTION_NAME, filter); pd != nil {
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+
+	response, status := putApplicationDataSubsToNotifyToDB(subscID, appDataSubs)
+	return httpwrapper.NewResponse(status, nil, response)
+}
+
 func HandleExposureDataSubsToNotifyPost(request *httpwrapper.Request) *httpwrapper.Response {
 	return httpwrapper.NewResponse(http.StatusOK, nil, map[string]interface{}{})
 }
@@ -909,7 +1178,7 @@ func HandleExposureDataSubsToNotifySubIdPut(request *httpwrapper.Request) *httpw
 func HandlePolicyDataBdtDataBdtReferenceIdDelete(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle PolicyDataBdtDataBdtReferenceIdDelete")
 
-	collName := "policyData.bdtData"
+	collName := POLICYDATA_BDTDATA_DB_COLLECTION_NAME
 	bdtReferenceId := request.Params["bdtReferenceId"]
 
 	PolicyDataBdtDataBdtReferenceIdDeleteProcedure(collName, bdtReferenceId)
@@ -918,13 +1187,22 @@ func HandlePolicyDataBdtDataBdtReferenceIdDelete(request *httpwrapper.Request) *
 
 func PolicyDataBdtDataBdtReferenceIdDeleteProcedure(collName string, bdtReferenceId string) {
 	filter := bson.M{"bdtReferenceId": bdtReferenceId}
-	deleteDataFromDB(collName, filter)
+	deleted, err := database.DeleteMany(context.TODO(), collName, filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataBdtDataBdtReferenceIdDeleteProcedure err: %+v", err)
+		return
+	}
+	if deleted != 0 {
+		var changes policyDataChanges
+		changes.remove("", "bdt-data/"+bdtReferenceId)
+		changes.send()
+	}
 }
 
 func HandlePolicyDataBdtDataBdtReferenceIdGet(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle PolicyDataBdtDataBdtReferenceIdGet")
 
-	collName := "policyData.bdtData"
+	collName := POLICYDATA_BDTDATA_DB_COLLECTION_NAME
 	bdtReferenceId := request.Params["bdtReferenceId"]
 
 	response, problemDetails := PolicyDataBdtDataBdtReferenceIdGetProcedure(collName, bdtReferenceId)
@@ -941,19 +1219,23 @@ func HandlePolicyDataBdtDataBdtReferenceIdGet(request *httpwrapper.Request) *htt
 func PolicyDataBdtDataBdtReferenceIdGetProcedure(collName string, bdtReferenceId string) (*map[string]interface{},
 	*models.ProblemDetails,
 ) {
-	filter := bson.M{"bdtReferenceId": bdtReferenceId}
+	filter := bson.M{"$and": []bson.M{
+		{"bdtReferenceId": bdtReferenceId},
+		bdtDataUnexpiredAt(time.Now().UTC()),
+	}}
 	data, pd := getDataFromDB(collName, filter)
 	if pd != nil {
 		logger.DataRepoLog.Errorf("PolicyDataBdtDataBdtReferenceIdGetProcedure err: %s", pd.Detail)
 		return nil, pd
 	}
+	bdtDataFromDB(data)
 	return &data, nil
 }
 
 func HandlePolicyDataBdtDataBdtReferenceIdPut(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle PolicyDataBdtDataBdtReferenceIdPut")
 
-	collName := "policyData.bdtData"
+	collName := POLICYDATA_BDTDATA_DB_COLLECTION_NAME
 	bdtReferenceId := request.Params["bdtReferenceId"]
 	bdtData := request.Body.(models.BdtData)
 
@@ -970,14 +1252,15 @@ func PolicyDataBdtDataBdtReferenceIdPutProcedure(collName string, bdtReferenceId
 	bdtData models.BdtData,
 ) bson.M {
 	putData := util.ToBsonM(bdtData)
-	putData["bdtReferenceId"] = bdtReferenceId
+	bdtDataToDB(bdtReferenceId, &bdtData, putData)
 	filter := bson.M{"bdtReferenceId": bdtReferenceId}
 
-	existed, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+	existed, err := replaceDataInDB(collName, filter, putData)
 	if err != nil {
-		logger.DataRepoLog.Errorf("putApplicationDataIndividualPfdToDB err: %+v", err)
+		logger.DataRepoLog.Errorf("PolicyDataBdtDataBdtReferenceIdPutProcedure err: %+v", err)
 		return nil
 	}
+	bdtDataFromDB(putData)
 
 	if existed {
 		PreHandlePolicyDataChangeNotification("", bdtReferenceId, bdtData)
@@ -985,32 +1268,106 @@ func PolicyDataBdtDataBdtReferenceIdPutProcedure(collName string, bdtReferenceId
 	return putData
 }
 
+func HandlePolicyDataBdtDataBdtReferenceIdPatch(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataBdtDataBdtReferenceIdPatch")
+
+	bdtReferenceId := request.Params["bdtReferenceId"]
+	patchData := request.Body.(map[string]interface{})
+
+	problemDetails := PolicyDataBdtDataBdtReferenceIdPatchProcedure(bdtReferenceId, patchData)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+// PolicyDataBdtDataBdtReferenceIdPatchProcedure applies patchData to the BDT
+// data as a JSON merge patch. The transfer window end is updated with it, so a
+// patch can extend or shorten the life of the data.
+func PolicyDataBdtDataBdtReferenceIdPatchProcedure(bdtReferenceId string,
+	patchData map[string]interface{},
+) *models.ProblemDetails {
+	filter := bson.M{"bdtReferenceId": bdtReferenceId}
+	delete(patchData, "bdtReferenceId")
+	delete(patchData, BDTDATA_WINDOW_END_FIELD)
+
+	var bdtData models.BdtData
+	found := false
+	err := database.WithTransaction(func(ctx context.Context) error {
+		original, err := database.GetOne(ctx, POLICYDATA_BDTDATA_DB_COLLECTION_NAME, bson.M{"$and": []bson.M{
+			filter,
+			bdtDataUnexpiredAt(time.Now().UTC()),
+		}})
+		found = original != nil
+		if err != nil || !found {
+			return err
+		}
+		bdtDataFromDB(original)
+
+		originalJson, err := json.Marshal(original)
+		if err != nil {
+			return err
+		}
+		patchJson, err := json.Marshal(patchData)
+		if err != nil {
+			return err
+		}
+		modifiedJson, err := jsonpatch.MergePatch(originalJson, patchJson)
+		if err != nil {
+			return err
+		}
+		bdtData = models.BdtData{}
+		if err = json.Unmarshal(modifiedJson, &bdtData); err != nil {
+			return err
+		}
+		var modified map[string]interface{}
+		if err = json.Unmarshal(modifiedJson, &modified); err != nil {
+			return err
+		}
+		bdtDataToDB(bdtReferenceId, &bdtData, modified)
+		_, err = database.ReplaceOne(ctx, POLICYDATA_BDTDATA_DB_COLLECTION_NAME, filter, modified)
+		return err
+	})
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataBdtDataBdtReferenceIdPatchProcedure err: %+v", err)
+		return util.ProblemDetailsModifyNotAllowed(err.Error())
+	}
+	if !found {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+
+	PreHandlePolicyDataChangeNotification("", bdtReferenceId, bdtData)
+	return nil
+}
+
 func HandlePolicyDataBdtDataGet(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle PolicyDataBdtDataGet")
 
-	collName := "policyData.bdtData"
+	collName := POLICYDATA_BDTDATA_DB_COLLECTION_NAME
 
 	response := PolicyDataBdtDataGetProcedure(collName)
 	return httpwrapper.NewResponse(http.StatusOK, nil, response)
 }
 
 func PolicyDataBdtDataGetProcedure(collName string) *[]map[string]interface{} {
-	filter := bson.M{}
+	filter := bdtDataUnexpiredAt(time.Now().UTC())
 	bdtDataArray, err := mongoapi.RestfulAPIGetMany(collName, filter)
 	if err != nil {
 		logger.DataRepoLog.Errorf("PolicyDataBdtDataGetProcedure err: %+v", err)
 		return nil
 	}
+	for _, bdtData := range bdtDataArray {
+		bdtDataFromDB(bdtData)
+	}
 	return &bdtDataArray
 }
 
 func HandlePolicyDataPlmnsPlmnIdUePolicySetGet(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle PolicyDataPlmnsPlmnIdUePolicySetGet")
 
-	collName := "policyData.plmns.uePolicySet"
 	plmnId := request.Params["plmnId"]
 
-	response, problemDetails := PolicyDataPlmnsPlmnIdUePolicySetGetProcedure(collName, plmnId)
+	response, problemDetails := PolicyDataPlmnsPlmnIdUePolicySetGetProcedure(plmnId)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -1022,46 +1379,272 @@ func HandlePolicyDataPlmnsPlmnIdUePolicySetGet(request *httpwrapper.Request) *ht
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func PolicyDataPlmnsPlmnIdUePolicySetGetProcedure(collName string,
-	plmnId string,
-) (*map[string]interface{}, *models.ProblemDetails) {
+func PolicyDataPlmnsPlmnIdUePolicySetGetProcedure(plmnId string) (*models.UePolicySet, *models.ProblemDetails) {
+	data, err := repository.GetPlmnUePolicySet(context.TODO(), plmnId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataPlmnsPlmnIdUePolicySetGetProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
+}
+
+func HandlePolicyDataPlmnsPlmnIdUePolicySetPut(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataPlmnsPlmnIdUePolicySetPut")
+
+	plmnId := request.Params["plmnId"]
+	uePolicySet := request.Body.(models.UePolicySet)
+
+	created, problemDetails := PolicyDataPlmnsPlmnIdUePolicySetPutProcedure(plmnId, uePolicySet)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	if created {
+		return httpwrapper.NewResponse(http.StatusCreated, nil, uePolicySet)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+// PolicyDataPlmnsPlmnIdUePolicySetPutProcedure replaces the UE policy set of
+// the PLMN. It reports whether the UE policy set was created.
+func PolicyDataPlmnsPlmnIdUePolicySetPutProcedure(plmnId string,
+	uePolicySet models.UePolicySet,
+) (bool, *models.ProblemDetails) {
+	if _, err := util.PlmnIdStringToModels(plmnId); err != nil {
+		return false, util.ProblemDetailsMalformedReqSyntax(err.Error())
+	}
+
+	putData := util.ToBsonM(uePolicySet)
+	putData["plmnId"] = plmnId
 	filter := bson.M{"plmnId": plmnId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataPlmnsPlmnIdUePolicySetGetProcedure err: %s", pd.Detail)
-		return nil, pd
+
+	existed, err := database.ReplaceOne(context.TODO(), repository.PlmnUePolicySetCollName, filter, putData)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataPlmnsPlmnIdUePolicySetPutProcedure err: %+v", err)
+		return false, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &data, nil
+
+	PreHandlePolicyDataChangeNotification("", plmnId, uePolicySet)
+	return !existed, nil
+}
+
+func HandlePolicyDataPlmnsPlmnIdUePolicySetPatch(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataPlmnsPlmnIdUePolicySetPatch")
+
+	plmnId := request.Params["plmnId"]
+	uePolicySet := request.Body.(models.UePolicySet)
+
+	problemDetails := PolicyDataPlmnsPlmnIdUePolicySetPatchProcedure(plmnId, uePolicySet)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func PolicyDataPlmnsPlmnIdUePolicySetPatchProcedure(plmnId string,
+	uePolicySet models.UePolicySet,
+) *models.ProblemDetails {
+	patchData := util.ToBsonM(uePolicySet)
+	filter := bson.M{"plmnId": plmnId}
+
+	var patched *models.UePolicySet
+	err := database.WithTransaction(func(ctx context.Context) error {
+		matched, err := database.MergePatch(ctx, repository.PlmnUePolicySetCollName, filter, patchData)
+		if err != nil || !matched {
+			patched = nil
+			return err
+		}
+		patched, err = repository.GetPlmnUePolicySet(ctx, plmnId)
+		return err
+	})
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataPlmnsPlmnIdUePolicySetPatchProcedure err: %+v", err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if patched == nil {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+
+	PreHandlePolicyDataChangeNotification("", plmnId, *patched)
+	return nil
+}
+
+func HandlePolicyDataPlmnsPlmnIdUePolicySetDelete(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataPlmnsPlmnIdUePolicySetDelete")
+
+	plmnId := request.Params["plmnId"]
+
+	problemDetails := PolicyDataPlmnsPlmnIdUePolicySetDeleteProcedure(plmnId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func PolicyDataPlmnsPlmnIdUePolicySetDeleteProcedure(plmnId string) *models.ProblemDetails {
+	filter := bson.M{"plmnId": plmnId}
+	deleted, err := database.DeleteMany(context.TODO(), repository.PlmnUePolicySetCollName, filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataPlmnsPlmnIdUePolicySetDeleteProcedure err: %+v", err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if deleted == 0 {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+
+	var changes policyDataChanges
+	changes.remove("", fmt.Sprintf("plmns/%s/ue-policy-set", plmnId))
+	changes.send()
+	return nil
 }
 
 func HandlePolicyDataSponsorConnectivityDataSponsorIdGet(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle PolicyDataSponsorConnectivityDataSponsorIdGet")
 
-	collName := "policyData.sponsorConnectivityData"
 	sponsorId := request.Params["sponsorId"]
 
-	response, status := PolicyDataSponsorConnectivityDataSponsorIdGetProcedure(collName, sponsorId)
+	response, problemDetails := PolicyDataSponsorConnectivityDataSponsorIdGetProcedure(sponsorId)
 
-	if status == http.StatusOK {
+	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
-	} else if status == http.StatusNoContent {
-		return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+	} else if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
 	}
 
 	pd := util.ProblemDetailsUpspecified("")
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func PolicyDataSponsorConnectivityDataSponsorIdGetProcedure(collName string,
-	sponsorId string,
-) (*map[string]interface{}, int) {
+func PolicyDataSponsorConnectivityDataSponsorIdGetProcedure(sponsorId string) (*models.SponsorConnectivityData,
+	*models.ProblemDetails,
+) {
+	data, err := repository.GetSponsorConnectivityData(context.TODO(), sponsorId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataSponsorConnectivityDataSponsorIdGetProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
+}
+
+func HandlePolicyDataSponsorConnectivityDataSponsorIdPut(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataSponsorConnectivityDataSponsorIdPut")
+
+	sponsorId := request.Params["sponsorId"]
+	sponsorConnectivityData := request.Body.(models.SponsorConnectivityData)
+
+	created, problemDetails := PolicyDataSponsorConnectivityDataSponsorIdPutProcedure(sponsorId,
+		sponsorConnectivityData)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	if created {
+		return httpwrapper.NewResponse(http.StatusCreated, nil, sponsorConnectivityData)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+// PolicyDataSponsorConnectivityDataSponsorIdPutProcedure replaces the
+// connectivity data of the sponsor. It reports whether the data was created.
+func PolicyDataSponsorConnectivityDataSponsorIdPutProcedure(sponsorId string,
+	sponsorConnectivityData models.SponsorConnectivityData,
+) (bool, *models.ProblemDetails) {
+	if len(sponsorConnectivityData.AspIds) == 0 {
+		return false, util.ProblemDetailsMalformedReqSyntax("aspIds must not be empty")
+	}
+
+	putData := util.ToBsonM(sponsorConnectivityData)
+	putData["sponsorId"] = sponsorId
 	filter := bson.M{"sponsorId": sponsorId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataSponsorConnectivityDataSponsorIdGetProcedure err: %s", pd.Detail)
-		return nil, http.StatusNoContent
+
+	existed, err := database.ReplaceOne(context.TODO(), repository.SponsorConnDataCollName, filter, putData)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataSponsorConnectivityDataSponsorIdPutProcedure err: %+v", err)
+		return false, util.ProblemDetailsSystemFailure(err.Error())
+	}
+
+	PreHandlePolicyDataChangeNotification("", sponsorId, sponsorConnectivityData)
+	return !existed, nil
+}
+
+func HandlePolicyDataSponsorConnectivityDataSponsorIdPatch(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataSponsorConnectivityDataSponsorIdPatch")
+
+	sponsorId := request.Params["sponsorId"]
+	sponsorConnectivityData := request.Body.(models.SponsorConnectivityData)
+
+	problemDetails := PolicyDataSponsorConnectivityDataSponsorIdPatchProcedure(sponsorId, sponsorConnectivityData)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func PolicyDataSponsorConnectivityDataSponsorIdPatchProcedure(sponsorId string,
+	sponsorConnectivityData models.SponsorConnectivityData,
+) *models.ProblemDetails {
+	// aspIds is the only attribute and it is mandatory, so a patch without it
+	// would remove it.
+	if len(sponsorConnectivityData.AspIds) == 0 {
+		return util.ProblemDetailsMalformedReqSyntax("aspIds must not be empty")
+	}
+
+	patchData := util.ToBsonM(sponsorConnectivityData)
+	filter := bson.M{"sponsorId": sponsorId}
+
+	var patched *models.SponsorConnectivityData
+	err := database.WithTransaction(func(ctx context.Context) error {
+		matched, err := database.MergePatch(ctx, repository.SponsorConnDataCollName, filter, patchData)
+		if err != nil || !matched {
+			patched = nil
+			return err
+		}
+		patched, err = repository.GetSponsorConnectivityData(ctx, sponsorId)
+		return err
+	})
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataSponsorConnectivityDataSponsorIdPatchProcedure err: %+v", err)
+		return util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &data, http.StatusOK
+	if patched == nil {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+
+	PreHandlePolicyDataChangeNotification("", sponsorId, *patched)
+	return nil
+}
+
+func HandlePolicyDataSponsorConnectivityDataSponsorIdDelete(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataSponsorConnectivityDataSponsorIdDelete")
+
+	sponsorId := request.Params["sponsorId"]
+
+	problemDetails := PolicyDataSponsorConnectivityDataSponsorIdDeleteProcedure(sponsorId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func PolicyDataSponsorConnectivityDataSponsorIdDeleteProcedure(sponsorId string) *models.ProblemDetails {
+	filter := bson.M{"sponsorId": sponsorId}
+	deleted, err := database.DeleteMany(context.TODO(), repository.SponsorConnDataCollName, filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataSponsorConnectivityDataSponsorIdDeleteProcedure err: %+v", err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if deleted == 0 {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+
+	var changes policyDataChanges
+	changes.remove("", "sponsor-connectivity-data/"+sponsorId)
+	changes.send()
+	return nil
 }
 
 func HandlePolicyDataSubsToNotifyPost(request *httpwrapper.Request) *httpwrapper.Response {
@@ -1148,10 +1731,10 @@ func PolicyDataSubsToNotifySubsIdPutProcedure(subsId string,
 func HandlePolicyDataUesUeIdAmDataGet(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle PolicyDataUesUeIdAmDataGet")
 
-	collName := "policyData.ues.amData"
 	ueId := request.Params["ueId"]
+	mergeGroupData := request.Query.Get("merge-group-data") == "true"
 
-	response, problemDetails := PolicyDataUesUeIdAmDataGetProcedure(collName, ueId)
+	response, problemDetails := PolicyDataUesUeIdAmDataGetProcedure(ueId, mergeGroupData)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -1163,16 +1746,29 @@ func HandlePolicyDataUesUeIdAmDataGet(request *httpwrapper.Request) *httpwrapper
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func PolicyDataUesUeIdAmDataGetProcedure(collName string,
-	ueId string,
-) (*map[string]interface{}, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdAmDataGetProcedure err: %s", pd.Detail)
-		return nil, pd
+// PolicyDataUesUeIdAmDataGetProcedure returns the AM policy data of the UE,
+// merged over the AM policy data of its groups when mergeGroupData is set.
+func PolicyDataUesUeIdAmDataGetProcedure(ueId string, mergeGroupData bool) (*models.AmPolicyData,
+	*models.ProblemDetails,
+) {
+	var data *models.AmPolicyData
+	var err error
+	if mergeGroupData {
+		err = database.WithTransaction(func(ctx context.Context) error {
+			data, err = repository.GetEffectiveAmPolicyData(ctx, ueId)
+			return err
+		})
+	} else {
+		data, err = repository.GetAmPolicyData(context.TODO(), ueId)
 	}
-	return &data, nil
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataUesUeIdAmDataGetProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandlePolicyDataUesUeIdOperatorSpecificDataGet(request *httpwrapper.Request) *httpwrapper.Response {
@@ -1213,7 +1809,9 @@ func HandlePolicyDataUesUeIdOperatorSpecificDataPatch(request *httpwrapper.Reque
 	ueId := request.Params["ueId"]
 	patchItem := request.Body.([]models.PatchItem)
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	problemDetails := PolicyDataUesUeIdOperatorSpecificDataPatchProcedure(collName, ueId, patchItem)
+	history.record()
 
 	if problemDetails == nil {
 		return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
@@ -1250,7 +1848,9 @@ func HandlePolicyDataUesUeIdOperatorSpecificDataPut(request *httpwrapper.Request
 	ueId := request.Params["ueId"]
 	OperatorSpecificDataContainer := request.Body.(map[string]models.OperatorSpecificDataContainer)
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	PolicyDataUesUeIdOperatorSpecificDataPutProcedure(collName, ueId, OperatorSpecificDataContainer)
+	history.record()
 
 	return httpwrapper.NewResponse(http.StatusOK, nil, map[string]interface{}{})
 }
@@ -1272,7 +1872,6 @@ func PolicyDataUesUeIdOperatorSpecificDataPutProcedure(collName string, ueId str
 func HandlePolicyDataUesUeIdSmDataGet(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle PolicyDataUesUeIdSmDataGet")
 
-	collName := "policyData.ues.smData"
 	ueId := request.Params["ueId"]
 	sNssai := models.Snssai{}
 	sNssaiQuery := request.Query.Get("snssai")
@@ -1281,8 +1880,9 @@ func HandlePolicyDataUesUeIdSmDataGet(request *httpwrapper.Request) *httpwrapper
 		logger.DataRepoLog.Warnln(err)
 	}
 	dnn := request.Query.Get("dnn")
+	mergeGroupData := request.Query.Get("merge-group-data") == "true"
 
-	response, problemDetails := PolicyDataUesUeIdSmDataGetProcedure(collName, ueId, sNssai, dnn)
+	response, problemDetails := PolicyDataUesUeIdSmDataGetProcedure(ueId, sNssai, dnn, mergeGroupData)
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
 	} else if problemDetails != nil {
@@ -1293,11 +1893,13 @@ func HandlePolicyDataUesUeIdSmDataGet(request *httpwrapper.Request) *httpwrapper
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func PolicyDataUesUeIdSmDataGetProcedure(collName string, ueId string, snssai models.Snssai,
-	dnn string,
+// PolicyDataUesUeIdSmDataGetProcedure returns the SM policy data of the UE,
+// merged over the SM policy data of its groups when mergeGroupData is set. The
+// data must hold snssai and dnn when they are given.
+func PolicyDataUesUeIdSmDataGetProcedure(ueId string, snssai models.Snssai,
+	dnn string, mergeGroupData bool,
 ) (*models.SmPolicyData, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId}
-
+	filter := bson.M{}
 	if !reflect.DeepEqual(snssai, models.Snssai{}) {
 		filter["smPolicySnssaiData."+util.SnssaiModelsToHex(snssai)] = bson.M{"$exists": true}
 	}
@@ -1306,44 +1908,31 @@ func PolicyDataUesUeIdSmDataGetProcedure(collName string, ueId string, snssai mo
 		filter["smPolicySnssaiData."+util.SnssaiModelsToHex(snssai)+".smPolicyDnnData."+dnnKey] = bson.M{"$exists": true}
 	}
 
-	smPolicyData, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		return nil, pd
-	}
-
-	var smPolicyDataResp models.SmPolicyData
-	err := json.Unmarshal(util.MapToByte(smPolicyData), &smPolicyDataResp)
-	if err != nil {
-		logger.DataRepoLog.Warnln(err)
-	}
-	tmpSmPolicySnssaiData := make(map[string]models.SmPolicySnssaiData)
-	for snssai, snssaiData := range smPolicyDataResp.SmPolicySnssaiData {
-		tmpSmPolicyDnnData := make(map[string]models.SmPolicyDnnData)
-		for escapedDnn, dnnData := range snssaiData.SmPolicyDnnData {
-			dnn := util.UnescapeDnn(escapedDnn)
-			tmpSmPolicyDnnData[dnn] = dnnData
+	var smPolicyData *models.SmPolicyData
+	err := database.WithTransaction(func(ctx context.Context) error {
+		var err error
+		if mergeGroupData {
+			smPolicyData, err = repository.GetEffectiveSmPolicyData(ctx, ueId)
+			if smPolicyData != nil && !smPolicyDataHolds(smPolicyData, snssai, dnn) {
+				smPolicyData = nil
+			}
+		} else {
+			smPolicyData, err = repository.GetSmPolicyData(ctx, ueId, filter)
 		}
-		snssaiData.SmPolicyDnnData = tmpSmPolicyDnnData
-		tmpSmPolicySnssaiData[snssai] = snssaiData
-	}
-	smPolicyDataResp.SmPolicySnssaiData = tmpSmPolicySnssaiData
-	filter = bson.M{"ueId": ueId}
-	usageMonDataMapArray, err := mongoapi.RestfulAPIGetMany("policyData.ues.smData.usageMonData", filter)
+		if err != nil || smPolicyData == nil {
+			return err
+		}
+		smPolicyData.UmData, err = repository.GetUsageMonDataMap(ctx, ueId)
+		return err
+	})
 	if err != nil {
 		logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataGetProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-
-	if !reflect.DeepEqual(usageMonDataMapArray, []map[string]interface{}{}) {
-		var usageMonDataArray []models.UsageMonData
-		if err := json.Unmarshal(util.MapArrayToByte(usageMonDataMapArray), &usageMonDataArray); err != nil {
-			logger.DataRepoLog.Warnln(err)
-		}
-		smPolicyDataResp.UmData = make(map[string]models.UsageMonData)
-		for _, element := range usageMonDataArray {
-			smPolicyDataResp.UmData[element.LimitId] = element
-		}
+	if smPolicyData == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
 	}
-	return &smPolicyDataResp, nil
+	return smPolicyData, nil
 }
 
 func HandlePolicyDataUesUeIdSmDataPatch(request *httpwrapper.Request) *httpwrapper.Response {
@@ -1353,7 +1942,11 @@ func HandlePolicyDataUesUeIdSmDataPatch(request *httpwrapper.Request) *httpwrapp
 	ueId := request.Params["ueId"]
 	usageMonData := request.Body.(map[string]models.UsageMonData)
 
+	// The patch changes the usage monitoring data of the UE, which are recorded
+	// together as one image
+	history := newListHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	problemDetails := PolicyDataUesUeIdSmDataPatchProcedure(collName, ueId, usageMonData)
+	history.record()
 	if problemDetails == nil {
 		return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 	} else {
@@ -1361,63 +1954,82 @@ func HandlePolicyDataUesUeIdSmDataPatch(request *httpwrapper.Request) *httpwrapp
 	}
 }
 
+// errUsageMonDataNotFound aborts a sm-data patch naming a limitId the UE has
+// no usage monitoring data for.
+var errUsageMonDataNotFound = errors.New("usage monitoring data not found")
+
 func PolicyDataUesUeIdSmDataPatchProcedure(collName string, ueId string,
 	UsageMonData map[string]models.UsageMonData,
 ) *models.ProblemDetails {
-	filter := bson.M{"ueId": ueId}
-
-	successAll := true
-	for k, usageMonData := range UsageMonData {
-		limitId := k
-		filterTmp := bson.M{"ueId": ueId, "limitId": limitId}
-		if err := mongoapi.RestfulAPIMergePatch(collName, filterTmp, util.ToBsonM(usageMonData)); err != nil {
-			successAll = false
-		} else {
-			var usageMonData models.UsageMonData
-			usageMonDataBsonM, pd := getDataFromDB(collName, filter)
-			if pd != nil && pd.Status == http.StatusInternalServerError {
-				logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataPatchProcedure err: %s", pd.Detail)
-				return pd
+	// Every usage monitoring data is patched in one transaction when the
+	// database supports it, so that a failed patch leaves none applied.
+	var patched map[string]models.UsageMonData
+	var smPolicyData *models.SmPolicyData
+	var umData map[string]models.UsageMonData
+	err := database.WithTransaction(func(ctx context.Context) error {
+		// Every limitId is checked before any is patched, as a database
+		// without transactions cannot roll a partial patch back.
+		for limitId := range UsageMonData {
+			count, err := database.Count(ctx, collName, bson.M{"ueId": ueId, "limitId": limitId})
+			if err != nil {
+				return err
 			}
-			if err := json.Unmarshal(util.MapToByte(usageMonDataBsonM), &usageMonData); err != nil {
-				logger.DataRepoLog.Warnln(err)
+			if count == 0 {
+				return fmt.Errorf("%w: limitId %q", errUsageMonDataNotFound, limitId)
 			}
-			PreHandlePolicyDataChangeNotification(ueId, limitId, usageMonData)
-		}
-	}
-
-	if successAll {
-		smPolicyDataBsonM, pd := getDataFromDB(collName, filter)
-		if pd != nil {
-			logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataPatchProcedure err: %s", pd.Detail)
-			return pd
-		}
-		var smPolicyData models.SmPolicyData
-		if err := json.Unmarshal(util.MapToByte(smPolicyDataBsonM), &smPolicyData); err != nil {
-			logger.DataRepoLog.Warnln(err)
 		}
-
-		collName := "policyData.ues.smData.usageMonData"
-		filter := bson.M{"ueId": ueId}
-		usageMonDataMapArray, err := mongoapi.RestfulAPIGetMany(collName, filter)
-		if err != nil {
-			logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataPatchProcedure err: %+v", err)
-		}
-
-		if !reflect.DeepEqual(usageMonDataMapArray, []map[string]interface{}{}) {
-			var usageMonDataArray []models.UsageMonData
-			if err := json.Unmarshal(util.MapArrayToByte(usageMonDataMapArray), &usageMonDataArray); err != nil {
-				logger.DataRepoLog.Warnln(err)
+		patched = make(map[string]models.UsageMonData)
+		for limitId, usageMonData := range UsageMonData {
+			filterTmp := bson.M{"ueId": ueId, "limitId": limitId}
+			matched, err := database.MergePatch(ctx, collName, filterTmp, util.ToBsonM(usageMonData))
+			if err != nil {
+				return err
+			}
+			if !matched {
+				return fmt.Errorf("%w: limitId %q", errUsageMonDataNotFound, limitId)
 			}
-			smPolicyData.UmData = make(map[string]models.UsageMonData)
-			for _, element := range usageMonDataArray {
-				smPolicyData.UmData[element.LimitId] = element
+			var data []models.UsageMonData
+			if data, err = repository.FindUsageMonData(ctx, filterTmp); err != nil {
+				return err
+			}
+			if len(data) != 0 {
+				patched[limitId] = data[0]
 			}
 		}
-		PreHandlePolicyDataChangeNotification(ueId, "", smPolicyData)
-		return nil
+		var err error
+		if smPolicyData, err = repository.GetSmPolicyData(ctx, ueId, nil); err != nil {
+			return err
+		}
+		umData, err = repository.GetUsageMonDataMap(ctx, ueId)
+		return err
+	})
+	if errors.Is(err, errUsageMonDataNotFound) {
+		logger.DataRepoLog.Warnf("PolicyDataUesUeIdSmDataPatchProcedure: %+v", err)
+		problemDetails := util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+		problemDetails.Detail = err.Error()
+		return problemDetails
+	}
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataPatchProcedure err: %+v", err)
+		return util.ProblemDetailsModifyNotAllowed("")
 	}
-	return util.ProblemDetailsModifyNotAllowed("")
+
+	var changes policyDataChanges
+	for limitId, usageMonData := range patched {
+		changes.update(ueId, limitId, usageMonData)
+	}
+
+	if len(umData) == 0 {
+		logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataPatchProcedure err: no usage monitoring data")
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	if smPolicyData == nil {
+		smPolicyData = &models.SmPolicyData{}
+	}
+	smPolicyData.UmData = umData
+	changes.update(ueId, "", *smPolicyData)
+	changes.send()
+	return nil
 }
 
 func HandlePolicyDataUesUeIdSmDataUsageMonIdDelete(request *httpwrapper.Request) *httpwrapper.Response {
@@ -1427,41 +2039,52 @@ func HandlePolicyDataUesUeIdSmDataUsageMonIdDelete(request *httpwrapper.Request)
 	ueId := request.Params["ueId"]
 	usageMonId := request.Params["usageMonId"]
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId, "usageMonId": usageMonId})
 	PolicyDataUesUeIdSmDataUsageMonIdDeleteProcedure(collName, ueId, usageMonId)
+	history.record()
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 }
 
 func PolicyDataUesUeIdSmDataUsageMonIdDeleteProcedure(collName string, ueId string, usageMonId string) {
 	filter := bson.M{"ueId": ueId, "usageMonId": usageMonId}
-	deleteDataFromDB(collName, filter)
+	deleted, err := database.DeleteMany(context.TODO(), collName, filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataUsageMonIdDeleteProcedure err: %+v", err)
+		return
+	}
+	if deleted != 0 {
+		var changes policyDataChanges
+		changes.remove(ueId, fmt.Sprintf("ues/%s/sm-data/%s", ueId, usageMonId))
+		changes.send()
+	}
 }
 
 func HandlePolicyDataUesUeIdSmDataUsageMonIdGet(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle PolicyDataUesUeIdSmDataUsageMonIdGet")
 
-	collName := "policyData.ues.smData.usageMonData"
 	ueId := request.Params["ueId"]
 	usageMonId := request.Params["usageMonId"]
 
-	response := PolicyDataUesUeIdSmDataUsageMonIdGetProcedure(collName, usageMonId, ueId)
+	response, problemDetails := PolicyDataUesUeIdSmDataUsageMonIdGetProcedure(usageMonId, ueId)
 
-	if response != nil {
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	} else if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
 	} else {
 		return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 	}
 }
 
-func PolicyDataUesUeIdSmDataUsageMonIdGetProcedure(collName string, usageMonId string,
+func PolicyDataUesUeIdSmDataUsageMonIdGetProcedure(usageMonId string,
 	ueId string,
-) *map[string]interface{} {
-	filter := bson.M{"ueId": ueId, "usageMonId": usageMonId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataUsageMonIdGetProcedure err: %s", pd.Detail)
-		return nil
+) (*models.UsageMonData, *models.ProblemDetails) {
+	data, err := repository.GetUsageMonData(context.TODO(), ueId, usageMonId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataUsageMonIdGetProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &data
+	return data, nil
 }
 
 func HandlePolicyDataUesUeIdSmDataUsageMonIdPut(request *httpwrapper.Request) *httpwrapper.Response {
@@ -1472,7 +2095,9 @@ func HandlePolicyDataUesUeIdSmDataUsageMonIdPut(request *httpwrapper.Request) *h
 	usageMonData := request.Body.(models.UsageMonData)
 	collName := "policyData.ues.smData.usageMonData"
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId, "usageMonId": usageMonId})
 	response := PolicyDataUesUeIdSmDataUsageMonIdPutProcedure(collName, ueId, usageMonId, usageMonData)
+	history.record()
 
 	return httpwrapper.NewResponse(http.StatusCreated, nil, response)
 }
@@ -1496,9 +2121,8 @@ func HandlePolicyDataUesUeIdUePolicySetGet(request *httpwrapper.Request) *httpwr
 	logger.DataRepoLog.Infof("Handle PolicyDataUesUeIdUePolicySetGet")
 
 	ueId := request.Params["ueId"]
-	collName := "policyData.ues.uePolicySet"
 
-	response, problemDetails := PolicyDataUesUeIdUePolicySetGetProcedure(collName, ueId)
+	response, problemDetails := PolicyDataUesUeIdUePolicySetGetProcedure(ueId)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -1510,16 +2134,16 @@ func HandlePolicyDataUesUeIdUePolicySetGet(request *httpwrapper.Request) *httpwr
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func PolicyDataUesUeIdUePolicySetGetProcedure(collName string, ueId string) (*map[string]interface{},
-	*models.ProblemDetails,
-) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdUePolicySetGetProcedure err: %s", pd.Detail)
-		return nil, pd
+func PolicyDataUesUeIdUePolicySetGetProcedure(ueId string) (*models.UePolicySet, *models.ProblemDetails) {
+	data, err := repository.GetUePolicySet(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataUesUeIdUePolicySetGetProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &data, nil
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandlePolicyDataUesUeIdUePolicySetPatch(request *httpwrapper.Request) *httpwrapper.Response {
@@ -1529,7 +2153,9 @@ func HandlePolicyDataUesUeIdUePolicySetPatch(request *httpwrapper.Request) *http
 	ueId := request.Params["ueId"]
 	UePolicySet := request.Body.(models.UePolicySet)
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	problemDetails := PolicyDataUesUeIdUePolicySetPatchProcedure(collName, ueId, UePolicySet)
+	history.record()
 
 	if problemDetails == nil {
 		return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
@@ -1550,17 +2176,15 @@ func PolicyDataUesUeIdUePolicySetPatchProcedure(collName string, ueId string,
 		return util.ProblemDetailsModifyNotAllowed("")
 	}
 
-	var uePolicySet models.UePolicySet
-	uePolicySetBsonM, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdUePolicySetPatchProcedure err: %s", pd.Detail)
-		return pd
-	}
-	if err := json.Unmarshal(util.MapToByte(uePolicySetBsonM), &uePolicySet); err != nil {
+	uePolicySet, err := repository.GetUePolicySet(context.TODO(), ueId)
+	if err != nil {
 		logger.DataRepoLog.Errorf("PolicyDataUesUeIdUePolicySetPatchProcedure err: %+v", err)
 		return util.ProblemDetailsSystemFailure(err.Error())
 	}
-	PreHandlePolicyDataChangeNotification(ueId, "", uePolicySet)
+	if uePolicySet == nil {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	PreHandlePolicyDataChangeNotification(ueId, "", *uePolicySet)
 	return nil
 }
 
@@ -1571,7 +2195,9 @@ func HandlePolicyDataUesUeIdUePolicySetPut(request *httpwrapper.Request) *httpwr
 	ueId := request.Params["ueId"]
 	UePolicySet := request.Body.(models.UePolicySet)
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	response, status := PolicyDataUesUeIdUePolicySetPutProcedure(collName, ueId, UePolicySet)
+	history.record()
 
 	if status == http.StatusNoContent {
 		return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
@@ -1626,6 +2252,8 @@ func CreateAMFSubscriptionsProcedure(subsId string, ueId string,
 		return util.ProblemDetailsNotFound("USER_NOT_FOUND")
 	}
 	UESubsData := value.(*udr_context.UESubsData)
+	UESubsData.EeMtx.Lock()
+	defer UESubsData.EeMtx.Unlock()
 
 	_, ok = UESubsData.EeSubscriptionCollection[subsId]
 	if !ok {
@@ -1659,6 +2287,8 @@ func RemoveAmfSubscriptionsInfoProcedure(subsId string, ueId string) *models.Pro
 	}
 
 	UESubsData := value.(*udr_context.UESubsData)
+	UESubsData.EeMtx.Lock()
+	defer UESubsData.EeMtx.Unlock()
 	_, ok = UESubsData.EeSubscriptionCollection[subsId]
 
 	if !ok {
@@ -1699,6 +2329,8 @@ func ModifyAmfSubscriptionInfoProcedure(ueId string, subsId string,
 		return util.ProblemDetailsNotFound("USER_NOT_FOUND")
 	}
 	UESubsData := value.(*udr_context.UESubsData)
+	UESubsData.EeMtx.Lock()
+	defer UESubsData.EeMtx.Unlock()
 
 	_, ok = UESubsData.EeSubscriptionCollection[subsId]
 
@@ -1769,6 +2401,8 @@ func GetAmfSubscriptionInfoProcedure(subsId string, ueId string) (*[]models.AmfS
 	}
 
 	UESubsData := value.(*udr_context.UESubsData)
+	UESubsData.EeMtx.Lock()
+	defer UESubsData.EeMtx.Unlock()
 	_, ok = UESubsData.EeSubscriptionCollection[subsId]
 
 	if !ok {
@@ -1806,6 +2440,7 @@ func QueryEEDataProcedure(collName string, ueId string) (*map[string]interface{}
 		logger.DataRepoLog.Errorf("QueryEEDataProcedure err: %s", pd.Detail)
 		return nil, pd
 	}
+	repository.StripInternalFields(data)
 	return &data, nil
 }
 
@@ -1832,12 +2467,15 @@ func RemoveEeGroupSubscriptionsProcedure(ueGroupId string, subsId string) *model
 	}
 
 	UEGroupSubsData := value.(*udr_context.UEGroupSubsData)
+	UEGroupSubsData.EeMtx.Lock()
+	defer UEGroupSubsData.EeMtx.Unlock()
 	_, ok = UEGroupSubsData.EeSubscriptions[subsId]
 
 	if !ok {
 		return util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
 	}
 	delete(UEGroupSubsData.EeSubscriptions, subsId)
+	delete(UEGroupSubsData.NumOfReports, subsId)
 
 	return nil
 }
@@ -1868,6 +2506,8 @@ func UpdateEeGroupSubscriptionsProcedure(ueGroupId string, subsId string,
 	}
 
 	UEGroupSubsData := value.(*udr_context.UEGroupSubsData)
+	UEGroupSubsData.EeMtx.Lock()
+	defer UEGroupSubsData.EeMtx.Unlock()
 	_, ok = UEGroupSubsData.EeSubscriptions[subsId]
 
 	if !ok {
@@ -1894,12 +2534,10 @@ func HandleCreateEeGroupSubscriptions(request *httpwrapper.Request) *httpwrapper
 func CreateEeGroupSubscriptionsProcedure(ueGroupId string, EeSubscription models.EeSubscription) string {
 	udrSelf := udr_context.UDR_Self()
 
-	value, ok := udrSelf.UEGroupCollection.Load(ueGroupId)
-	if !ok {
-		udrSelf.UEGroupCollection.Store(ueGroupId, new(udr_context.UEGroupSubsData))
-		value, _ = udrSelf.UEGroupCollection.Load(ueGroupId)
-	}
+	value, _ := udrSelf.UEGroupCollection.LoadOrStore(ueGroupId, new(udr_context.UEGroupSubsData))
 	UEGroupSubsData := value.(*udr_context.UEGroupSubsData)
+	UEGroupSubsData.EeMtx.Lock()
+	defer UEGroupSubsData.EeMtx.Unlock()
 	if UEGroupSubsData.EeSubscriptions == nil {
 		UEGroupSubsData.EeSubscriptions = make(map[string]*models.EeSubscription)
 	}
@@ -1942,6 +2580,8 @@ func QueryEeGroupSubscriptionsProcedure(ueGroupId string) ([]models.EeSubscripti
 	}
 
 	UEGroupSubsData := value.(*udr_context.UEGroupSubsData)
+	UEGroupSubsData.EeMtx.Lock()
+	defer UEGroupSubsData.EeMtx.Unlock()
 	var eeSubscriptionSlice []models.EeSubscription
 
 	for _, v := range UEGroupSubsData.EeSubscriptions {
@@ -1973,6 +2613,8 @@ func RemoveeeSubscriptionsProcedure(ueId string, subsId string) *models.ProblemD
 	}
 
 	UESubsData := value.(*udr_context.UESubsData)
+	UESubsData.EeMtx.Lock()
+	defer UESubsData.EeMtx.Unlock()
 	_, ok = UESubsData.EeSubscriptionCollection[subsId]
 
 	if !ok {
@@ -2008,6 +2650,8 @@ func UpdateEesubscriptionsProcedure(ueId string, subsId string,
 	}
 
 	UESubsData := value.(*udr_context.UESubsData)
+	UESubsData.EeMtx.Lock()
+	defer UESubsData.EeMtx.Unlock()
 	_, ok = UESubsData.EeSubscriptionCollection[subsId]
 
 	if !ok {
@@ -2034,12 +2678,10 @@ func HandleCreateEeSubscriptions(request *httpwrapper.Request) *httpwrapper.Resp
 func CreateEeSubscriptionsProcedure(ueId string, EeSubscription models.EeSubscription) string {
 	udrSelf := udr_context.UDR_Self()
 
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
-	if !ok {
-		udrSelf.UESubsCollection.Store(ueId, new(udr_context.UESubsData))
-		value, _ = udrSelf.UESubsCollection.Load(ueId)
-	}
+	value, _ := udrSelf.UESubsCollection.LoadOrStore(ueId, new(udr_context.UESubsData))
 	UESubsData := value.(*udr_context.UESubsData)
+	UESubsData.EeMtx.Lock()
+	defer UESubsData.EeMtx.Unlock()
 	if UESubsData.EeSubscriptionCollection == nil {
 		UESubsData.EeSubscriptionCollection = make(map[string]*udr_context.EeSubscriptionCollection)
 	}
@@ -2083,6 +2725,8 @@ func QueryeesubscriptionsProcedure(ueId string) ([]models.EeSubscription, *model
 	}
 
 	UESubsData := value.(*udr_context.UESubsData)
+	UESubsData.EeMtx.Lock()
+	defer UESubsData.EeMtx.Unlock()
 	var eeSubscriptionSlice []models.EeSubscription
 
 	for _, v := range UESubsData.EeSubscriptionCollection {
@@ -2098,7 +2742,9 @@ func HandlePatchOperSpecData(request *httpwrapper.Request) *httpwrapper.Response
 	ueId := request.Params["ueId"]
 	patchItem := request.Body.([]models.PatchItem)
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	problemDetails := PatchOperSpecDataProcedure(collName, ueId, patchItem)
+	history.record()
 
 	if problemDetails == nil {
 		return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
@@ -2142,6 +2788,7 @@ func QueryOperSpecDataProcedure(collName string, ueId string) (*map[string]inter
 		logger.DataRepoLog.Errorf("QueryOperSpecDataProcedure err: %s", pd.Detail)
 		return nil, pd
 	}
+	repository.StripInternalFields(data)
 	return &data, nil
 }
 
@@ -2170,6 +2817,7 @@ func GetppDataProcedure(collName string, ueId string) (*map[string]interface{},
 		logger.DataRepoLog.Errorf("GetppDataProcedure err: %s", pd.Detail)
 		return nil, pd
 	}
+	repository.StripInternalFields(data)
 	return &data, nil
 }
 
@@ -2191,8 +2839,10 @@ func HandleQueryProvisionedData(request *httpwrapper.Request) *httpwrapper.Respo
 	var provisionedDataSets models.ProvisionedDataSets
 	ueId := request.Params["ueId"]
 	servingPlmnId := request.Params["servingPlmnId"]
+	mergeSharedData := request.Query.Get("merge-shared-data") == "true"
 
-	response, problemDetails := QueryProvisionedDataProcedure(ueId, servingPlmnId, provisionedDataSets)
+	response, problemDetails := QueryProvisionedDataProcedure(ueId, servingPlmnId, provisionedDataSets,
+		mergeSharedData)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -2204,122 +2854,45 @@ func HandleQueryProvisionedData(request *httpwrapper.Request) *httpwrapper.Respo
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
+// QueryProvisionedDataProcedure returns the provisioned data sets of the UE, with
+// the shared data they refer to merged in when mergeSharedData is set.
 func QueryProvisionedDataProcedure(ueId string, servingPlmnId string,
-	provisionedDataSets models.ProvisionedDataSets,
+	provisionedDataSets models.ProvisionedDataSets, mergeSharedData bool,
 ) (*models.ProvisionedDataSets, *models.ProblemDetails) {
-	var collName string
-	var filter bson.M
-
-	collName = "subscriptionData.provisionedData.amData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	accessAndMobilitySubscriptionData, pd := getDataFromDB(collName, filter)
-	if pd != nil && pd.Status == http.StatusInternalServerError {
-		logger.DataRepoLog.Errorf(
-			"QueryProvisionedDataProcedure get accessAndMobilitySubscriptionData err: %s", pd.Detail)
-		return nil, pd
-	}
-	if accessAndMobilitySubscriptionData != nil {
-		var tmp models.AccessAndMobilitySubscriptionData
-		if err := mapstructure.Decode(accessAndMobilitySubscriptionData, &tmp); err != nil {
-			logger.DataRepoLog.Errorf(
-				"QueryProvisionedDataProcedure accessAndMobilitySubscriptionData decode err: %+v", err)
-			return nil, util.ProblemDetailsSystemFailure(err.Error())
+	// The data sets are read in one transaction, so that they are consistent
+	// with each other when the database supports it.
+	err := database.WithTransaction(func(ctx context.Context) error {
+		var err error
+		if provisionedDataSets.AmData, err = repository.GetAmData(ctx, ueId, servingPlmnId); err != nil {
+			return err
 		}
-		provisionedDataSets.AmData = &tmp
-	}
-
-	collName = "subscriptionData.provisionedData.smfSelectionSubscriptionData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	smfSelectionSubscriptionData, pd := getDataFromDB(collName, filter)
-	if pd != nil && pd.Status == http.StatusInternalServerError {
-		logger.DataRepoLog.Errorf("QueryProvisionedDataProcedure get smfSelectionSubscriptionData err: %s", pd.Detail)
-		return nil, pd
-	}
-	if smfSelectionSubscriptionData != nil {
-		var tmp models.SmfSelectionSubscriptionData
-		if err := mapstructure.Decode(smfSelectionSubscriptionData, &tmp); err != nil {
-			logger.DataRepoLog.Errorf(
-				"QueryProvisionedDataProcedure smfSelectionSubscriptionData decode err: %+v", err)
-			return nil, util.ProblemDetailsSystemFailure(err.Error())
+		if provisionedDataSets.SmfSelData, err = repository.GetSmfSelectionData(ctx, ueId,
+			servingPlmnId); err != nil {
+			return err
 		}
-		provisionedDataSets.SmfSelData = &tmp
-	}
-
-	collName = "subscriptionData.provisionedData.smsData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	smsSubscriptionData, pd := getDataFromDB(collName, filter)
-	if pd != nil && pd.Status == http.StatusInternalServerError {
-		logger.DataRepoLog.Errorf("QueryProvisionedDataProcedure get smsSubscriptionData err: %s", pd.Detail)
-		return nil, pd
-	}
-	if smsSubscriptionData != nil {
-		var tmp models.SmsSubscriptionData
-		if err := mapstructure.Decode(smsSubscriptionData, &tmp); err != nil {
-			logger.DataRepoLog.Errorf(
-				"QueryProvisionedDataProcedure smsSubscriptionData decode err: %+v", err)
-			return nil, util.ProblemDetailsSystemFailure(err.Error())
+		if provisionedDataSets.SmsSubsData, err = repository.GetSmsData(ctx, ueId, servingPlmnId); err != nil {
+			return err
 		}
-		provisionedDataSets.SmsSubsData = &tmp
-	}
-
-	collName = "subscriptionData.provisionedData.smData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	sessionManagementSubscriptionDatas, err := mongoapi.RestfulAPIGetMany(collName, filter)
-	if err != nil {
-		logger.DataRepoLog.Errorf("QueryProvisionedDataProcedure get sessionManagementSubscriptionDatas err: %+v", err)
-		return nil, util.ProblemDetailsSystemFailure(err.Error())
-	}
-	if sessionManagementSubscriptionDatas != nil {
-		var tmp []models.SessionManagementSubscriptionData
-		if err := mapstructure.Decode(sessionManagementSubscriptionDatas, &tmp); err != nil {
-			logger.DataRepoLog.Errorf(
-				"QueryProvisionedDataProcedure sessionManagementSubscriptionDatas decode err: %+v", err)
-			return nil, util.ProblemDetailsSystemFailure(err.Error())
+		if provisionedDataSets.SmData, err = repository.GetSmData(ctx, ueId, servingPlmnId, nil, ""); err != nil {
+			return err
 		}
-		for _, smData := range tmp {
-			dnnConfigurations := smData.DnnConfigurations
-			tmpDnnConfigurations := make(map[string]models.DnnConfiguration)
-			for escapedDnn, dnnConf := range dnnConfigurations {
-				dnn := util.UnescapeDnn(escapedDnn)
-				tmpDnnConfigurations[dnn] = dnnConf
-			}
-			smData.DnnConfigurations = tmpDnnConfigurations
+		if provisionedDataSets.TraceData, err = repository.GetTraceData(ctx, ueId, servingPlmnId); err != nil {
+			return err
 		}
-		provisionedDataSets.SmData = tmp
-	}
-
-	collName = "subscriptionData.provisionedData.traceData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	traceData, pd := getDataFromDB(collName, filter)
-	if pd != nil && pd.Status == http.StatusInternalServerError {
-		logger.DataRepoLog.Errorf("QueryProvisionedDataProcedure get traceData err: %s", pd.Detail)
-		return nil, pd
-	}
-	if traceData != nil {
-		var tmp models.TraceData
-		if err := mapstructure.Decode(traceData, &tmp); err != nil {
-			logger.DataRepoLog.Errorf("QueryProvisionedDataProcedure traceData decode err: %+v", err)
-			return nil, util.ProblemDetailsSystemFailure(err.Error())
+		if provisionedDataSets.SmsMngData, err = repository.GetSmsMngData(ctx, ueId, servingPlmnId); err != nil {
+			return err
 		}
-		provisionedDataSets.TraceData = &tmp
-	}
-
-	collName = "subscriptionData.provisionedData.smsMngData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	smsManagementSubscriptionData, pd := getDataFromDB(collName, filter)
-	if pd != nil && pd.Status == http.StatusInternalServerError {
-		logger.DataRepoLog.Errorf(
-			"QueryProvisionedDataProcedure get smsManagementSubscriptionData err: %s", pd.Detail)
-		return nil, pd
-	}
-	if smsManagementSubscriptionData != nil {
-		var tmp models.SmsManagementSubscriptionData
-		if err := mapstructure.Decode(smsManagementSubscriptionData, &tmp); err != nil {
-			logger.DataRepoLog.Errorf(
-				"QueryProvisionedDataProcedure smsManagementSubscriptionData decode err: %+v", err)
-			return nil, util.ProblemDetailsSystemFailure(err.Error())
+		if mergeSharedData {
+			return dereferenceProvisionedDataSets(ctx, &provisionedDataSets)
 		}
-		provisionedDataSets.SmsMngData = &tmp
+		return nil
+	})
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryProvisionedDataProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if len(provisionedDataSets.SmData) == 0 {
+		provisionedDataSets.SmData = nil
 	}
 
 	if reflect.DeepEqual(provisionedDataSets, models.ProvisionedDataSets{}) {
@@ -2328,6 +2901,28 @@ func QueryProvisionedDataProcedure(ueId string, servingPlmnId string,
 	return &provisionedDataSets, nil
 }
 
+// dereferenceProvisionedDataSets merges the shared data the data sets refer to
+// into them.
+func dereferenceProvisionedDataSets(ctx context.Context, provisionedDataSets *models.ProvisionedDataSets) error {
+	var err error
+	if provisionedDataSets.AmData, err = repository.DereferenceAmData(ctx, provisionedDataSets.AmData); err != nil {
+		return err
+	}
+	if provisionedDataSets.SmfSelData, err = repository.DereferenceSmfSelectionData(ctx,
+		provisionedDataSets.SmfSelData); err != nil {
+		return err
+	}
+	if provisionedDataSets.SmsSubsData, err = repository.DereferenceSmsData(ctx,
+		provisionedDataSets.SmsSubsData); err != nil {
+		return err
+	}
+	if provisionedDataSets.SmData, err = repository.DereferenceSmData(ctx, provisionedDataSets.SmData); err != nil {
+		return err
+	}
+	provisionedDataSets.SmsMngData, err = repository.DereferenceSmsMngData(ctx, provisionedDataSets.SmsMngData)
+	return err
+}
+
 func HandleModifyPpData(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle ModifyPpData")
 
@@ -2335,7 +2930,9 @@ func HandleModifyPpData(request *httpwrapper.Request) *httpwrapper.Response {
 	patchItem := request.Body.([]models.PatchItem)
 	ueId := request.Params["ueId"]
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	problemDetails := ModifyPpDataProcedure(collName, ueId, patchItem)
+	history.record()
 	if problemDetails == nil {
 		return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 	} else {
@@ -2356,9 +2953,8 @@ func HandleGetIdentityData(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle GetIdentityData")
 
 	ueId := request.Params["ueId"]
-	collName := "subscriptionData.identityData"
 
-	response, problemDetails := GetIdentityDataProcedure(collName, ueId)
+	response, problemDetails := GetIdentityDataProcedure(ueId)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -2370,14 +2966,49 @@ func HandleGetIdentityData(request *httpwrapper.Request) *httpwrapper.Response {
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func GetIdentityDataProcedure(collName string, ueId string) (*map[string]interface{}, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("GetIdentityDataProcedure err: %s", pd.Detail)
-		return nil, pd
+// appendMissing appends the values that list does not hold yet.
+func appendMissing(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, v := range list {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
 	}
-	return &data, nil
+	return list
+}
+
+// GetIdentityDataProcedure returns the SUPI and the GPSIs of the UE ueId,
+// which is resolved to its SUPI already, from its stored identity data and
+// from the identity map.
+func GetIdentityDataProcedure(ueId string) (*models.IdentityData, *models.ProblemDetails) {
+	data, err := repository.GetIdentityData(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("GetIdentityDataProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	gpsis, err := identity.Gpsis(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("GetIdentityDataProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if data == nil && len(gpsis) == 0 {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+
+	if data == nil {
+		data = &models.IdentityData{}
+	}
+	if identity.IsSupi(ueId) {
+		data.SupiList = appendMissing(data.SupiList, ueId)
+	}
+	data.GpsiList = appendMissing(data.GpsiList, gpsis...)
+	return data, nil
 }
 
 func HandleGetOdbData(request *httpwrapper.Request) *httpwrapper.Response {
@@ -2405,6 +3036,7 @@ func GetOdbDataProcedure(collName string, ueId string) (*map[string]interface{},
 		logger.DataRepoLog.Errorf("GetOdbDataProcedure err: %s", pd.Detail)
 		return nil, pd
 	}
+	repository.StripInternalFields(data)
 	return &data, nil
 }
 
@@ -2418,9 +3050,8 @@ func HandleGetSharedData(request *httpwrapper.Request) *httpwrapper.Response {
 			sharedDataIds = strings.Split(sharedDataIds[0], ",")
 		}
 	}
-	collName := "subscriptionData.sharedData"
 
-	response, problemDetails := GetSharedDataProcedure(collName, sharedDataIds)
+	response, problemDetails := GetSharedDataProcedure(sharedDataIds)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -2432,26 +3063,23 @@ func HandleGetSharedData(request *httpwrapper.Request) *httpwrapper.Response {
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func GetSharedDataProcedure(collName string, sharedDataIds []string) (*[]map[string]interface{},
-	*models.ProblemDetails,
-) {
-	var sharedDataArray []map[string]interface{}
+func GetSharedDataProcedure(sharedDataIds []string) ([]models.SharedData, *models.ProblemDetails) {
+	var sharedDataArray []models.SharedData
 	for _, sharedDataId := range sharedDataIds {
-		filter := bson.M{"sharedDataId": sharedDataId}
-		sharedData, pd := getDataFromDB(collName, filter)
-		if pd != nil && pd.Status == http.StatusInternalServerError {
-			logger.DataRepoLog.Errorf("GetSharedDataProcedure err: %s", pd.Detail)
-			return nil, pd
+		sharedData, err := repository.GetSharedData(context.TODO(), sharedDataId)
+		if err != nil {
+			logger.DataRepoLog.Errorf("GetSharedDataProcedure err: %+v", err)
+			return nil, util.ProblemDetailsSystemFailure(err.Error())
 		}
 		if sharedData != nil {
-			sharedDataArray = append(sharedDataArray, sharedData)
+			sharedDataArray = append(sharedDataArray, *sharedData)
 		}
 	}
 
 	if sharedDataArray == nil {
 		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
 	}
-	return &sharedDataArray, nil
+	return sharedDataArray, nil
 }
 
 func HandleRemovesdmSubscriptions(request *httpwrapper.Request) *httpwrapper.Response {
@@ -2603,7 +3231,6 @@ func QuerysdmsubscriptionsProcedure(ueId string) (*[]models.SdmSubscription, *mo
 func HandleQuerySmData(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle QuerySmData")
 
-	collName := "subscriptionData.provisionedData.smData"
 	ueId := request.Params["ueId"]
 	servingPlmnId := request.Params["servingPlmnId"]
 	singleNssai := models.Snssai{}
@@ -2614,51 +3241,42 @@ func HandleQuerySmData(request *httpwrapper.Request) *httpwrapper.Response {
 	}
 
 	dnn := request.Query.Get("dnn")
-	response := QuerySmDataProcedure(collName, ueId, servingPlmnId, singleNssai, dnn)
-
+	mergeSharedData := request.Query.Get("merge-shared-data") == "true"
+	response, problemDetails := QuerySmDataProcedure(ueId, servingPlmnId, singleNssai, dnn, mergeSharedData)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
 	return httpwrapper.NewResponse(http.StatusOK, nil, response)
 }
 
-func QuerySmDataProcedure(collName string, ueId string, servingPlmnId string,
-	singleNssai models.Snssai, dnn string,
-) *[]map[string]interface{} {
-	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-
+// QuerySmDataProcedure returns the session management subscription data of the
+// UE, with the shared DNN configurations it refers to merged in when
+// mergeSharedData is set.
+func QuerySmDataProcedure(ueId string, servingPlmnId string,
+	singleNssai models.Snssai, dnn string, mergeSharedData bool,
+) ([]models.SessionManagementSubscriptionData, *models.ProblemDetails) {
+	var snssai *models.Snssai
 	if !reflect.DeepEqual(singleNssai, models.Snssai{}) {
-		if singleNssai.Sd == "" {
-			filter["singleNssai.sst"] = singleNssai.Sst
-		} else {
-			filter["singleNssai.sst"] = singleNssai.Sst
-			filter["singleNssai.sd"] = singleNssai.Sd
-		}
+		snssai = &singleNssai
 	}
-
-	if dnn != "" {
-		dnnKey := util.EscapeDnn(dnn)
-		filter["dnnConfigurations."+dnnKey] = bson.M{"$exists": true}
+	var data []models.SessionManagementSubscriptionData
+	var err error
+	if mergeSharedData {
+		err = database.WithTransaction(func(ctx context.Context) error {
+			if data, err = repository.GetSmData(ctx, ueId, servingPlmnId, snssai, dnn); err != nil {
+				return err
+			}
+			data, err = repository.DereferenceSmData(ctx, data)
+			return err
+		})
+	} else {
+		data, err = repository.GetSmData(context.TODO(), ueId, servingPlmnId, snssai, dnn)
 	}
-
-	sessionManagementSubscriptionDatas, err := mongoapi.RestfulAPIGetMany(collName, filter)
 	if err != nil {
 		logger.DataRepoLog.Errorf("QuerySmDataProcedure err: %+v", err)
-		return nil
-	}
-	for _, smData := range sessionManagementSubscriptionDatas {
-		var tmpSmData models.SessionManagementSubscriptionData
-		err := json.Unmarshal(util.MapToByte(smData), &tmpSmData)
-		if err != nil {
-			logger.DataRepoLog.Debug("SmData Unmarshal error")
-			continue
-		}
-		dnnConfigurations := tmpSmData.DnnConfigurations
-		tmpDnnConfigurations := make(map[string]models.DnnConfiguration)
-		for escapedDnn, dnnConf := range dnnConfigurations {
-			dnn := util.UnescapeDnn(escapedDnn)
-			tmpDnnConfigurations[dnn] = dnnConf
-		}
-		smData["DnnConfigurations"] = tmpDnnConfigurations
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &sessionManagementSubscriptionDatas
+	return data, nil
 }
 
 func HandleCreateSmfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.Response {
@@ -2672,7 +3290,9 @@ func HandleCreateSmfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.Re
 		logger.DataRepoLog.Warnln(err)
 	}
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId, "pduSessionId": pduSessionId})
 	response, status := CreateSmfContextNon3gppProcedure(SmfRegistration, collName, ueId, pduSessionId)
+	history.record()
 
 	if status == http.StatusCreated {
 		return httpwrapper.NewResponse(http.StatusCreated, nil, response)
@@ -2711,7 +3331,12 @@ func HandleDeleteSmfContext(request *httpwrapper.Request) *httpwrapper.Response
 	ueId := request.Params["ueId"]
 	pduSessionId := request.Params["pduSessionId"]
 
+	var history *historyRecorder
+	if pduSessionIdInt, err := strconv.ParseInt(pduSessionId, 10, 32); err == nil {
+		history = newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId, "pduSessionId": pduSessionIdInt})
+	}
 	DeleteSmfContextProcedure(collName, ueId, pduSessionId)
+	history.record()
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 }
 
@@ -2729,9 +3354,8 @@ func HandleQuerySmfRegistration(request *httpwrapper.Request) *httpwrapper.Respo
 
 	ueId := request.Params["ueId"]
 	pduSessionId := request.Params["pduSessionId"]
-	collName := "subscriptionData.contextData.smfRegistrations"
 
-	response, problemDetails := QuerySmfRegistrationProcedure(collName, ueId, pduSessionId)
+	response, problemDetails := QuerySmfRegistrationProcedure(ueId, pduSessionId)
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
 	} else if problemDetails != nil {
@@ -2742,58 +3366,53 @@ func HandleQuerySmfRegistration(request *httpwrapper.Request) *httpwrapper.Respo
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func QuerySmfRegistrationProcedure(collName string, ueId string,
+func QuerySmfRegistrationProcedure(ueId string,
 	pduSessionId string,
-) (*map[string]interface{}, *models.ProblemDetails) {
+) (*models.SmfRegistration, *models.ProblemDetails) {
 	pduSessionIdInt, err := strconv.ParseInt(pduSessionId, 10, 32)
 	if err != nil {
 		logger.DataRepoLog.Error(err)
 	}
 
-	filter := bson.M{"ueId": ueId, "pduSessionId": pduSessionIdInt}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QuerySmfRegistrationProcedure err: %s", pd.Detail)
-		return nil, pd
+	data, err := repository.GetSmfRegistration(context.TODO(), ueId, pduSessionIdInt)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QuerySmfRegistrationProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &data, nil
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandleQuerySmfRegList(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle QuerySmfRegList")
 
-	collName := "subscriptionData.contextData.smfRegistrations"
 	ueId := request.Params["ueId"]
-	response := QuerySmfRegListProcedure(collName, ueId)
+	response, problemDetails := QuerySmfRegListProcedure(ueId)
 
-	if response == nil {
-		return httpwrapper.NewResponse(http.StatusOK, nil, []map[string]interface{}{})
-	} else {
-		return httpwrapper.NewResponse(http.StatusOK, nil, response)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
 	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
 }
 
-func QuerySmfRegListProcedure(collName string, ueId string) *[]map[string]interface{} {
-	filter := bson.M{"ueId": ueId}
-	smfRegList, err := mongoapi.RestfulAPIGetMany(collName, filter)
+func QuerySmfRegListProcedure(ueId string) ([]models.SmfRegistration, *models.ProblemDetails) {
+	data, err := repository.GetSmfRegistrations(context.TODO(), ueId)
 	if err != nil {
 		logger.DataRepoLog.Errorf("QuerySmfRegListProcedure err: %+v", err)
-		return nil
-	}
-
-	if smfRegList != nil {
-		return &smfRegList
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return nil
+	return data, nil
 }
 
 func HandleQuerySmfSelectData(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle QuerySmfSelectData")
 
-	collName := "subscriptionData.provisionedData.smfSelectionSubscriptionData"
 	ueId := request.Params["ueId"]
 	servingPlmnId := request.Params["servingPlmnId"]
-	response, problemDetails := QuerySmfSelectDataProcedure(collName, ueId, servingPlmnId)
+	mergeSharedData := request.Query.Get("merge-shared-data") == "true"
+	response, problemDetails := QuerySmfSelectDataProcedure(ueId, servingPlmnId, mergeSharedData)
 
 	if problemDetails == nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -2802,16 +3421,33 @@ func HandleQuerySmfSelectData(request *httpwrapper.Request) *httpwrapper.Respons
 	}
 }
 
-func QuerySmfSelectDataProcedure(collName string, ueId string,
-	servingPlmnId string,
-) (*map[string]interface{}, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QuerySmfSelectDataProcedure err: %s", pd.Detail)
-		return nil, pd
+// QuerySmfSelectDataProcedure returns the SMF selection subscription data of the
+// UE, with the shared S-NSSAI infos it refers to merged in when mergeSharedData
+// is set.
+func QuerySmfSelectDataProcedure(ueId string, servingPlmnId string,
+	mergeSharedData bool,
+) (*models.SmfSelectionSubscriptionData, *models.ProblemDetails) {
+	var data *models.SmfSelectionSubscriptionData
+	var err error
+	if mergeSharedData {
+		err = database.WithTransaction(func(ctx context.Context) error {
+			if data, err = repository.GetSmfSelectionData(ctx, ueId, servingPlmnId); err != nil {
+				return err
+			}
+			data, err = repository.DereferenceSmfSelectionData(ctx, data)
+			return err
+		})
+	} else {
+		data, err = repository.GetSmfSelectionData(context.TODO(), ueId, servingPlmnId)
 	}
-	return &data, nil
+	if err != nil {
+		logger.DataRepoLog.Errorf("QuerySmfSelectDataProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandleCreateSmsfContext3gpp(request *httpwrapper.Request) *httpwrapper.Response {
@@ -2821,7 +3457,9 @@ func HandleCreateSmsfContext3gpp(request *httpwrapper.Request) *httpwrapper.Resp
 	collName := "subscriptionData.contextData.smsf3gppAccess"
 	ueId := request.Params["ueId"]
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	CreateSmsfContext3gppProcedure(collName, ueId, SmsfRegistration)
+	history.record()
 
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 }
@@ -2831,7 +3469,10 @@ func CreateSmsfContext3gppProcedure(collName string, ueId string, SmsfRegistrati
 	putData["ueId"] = ueId
 	filter := bson.M{"ueId": ueId}
 
-	_, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+	err := observeSmsfRegistration(ueId, repository.GetSmsf3GppAccessRegistration, func() error {
+		_, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+		return err
+	})
 	if err != nil {
 		logger.DataRepoLog.Errorf("CreateSmsfContext3gppProcedure err: %+v", err)
 	}
@@ -2843,7 +3484,9 @@ func HandleDeleteSmsfContext3gpp(request *httpwrapper.Request) *httpwrapper.Resp
 	collName := "subscriptionData.contextData.smsf3gppAccess"
 	ueId := request.Params["ueId"]
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	DeleteSmsfContext3gppProcedure(collName, ueId)
+	history.record()
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 }
 
@@ -2855,10 +3498,9 @@ func DeleteSmsfContext3gppProcedure(collName string, ueId string) {
 func HandleQuerySmsfContext3gpp(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle QuerySmsfContext3gpp")
 
-	collName := "subscriptionData.contextData.smsf3gppAccess"
 	ueId := request.Params["ueId"]
 
-	response, problemDetails := QuerySmsfContext3gppProcedure(collName, ueId)
+	response, problemDetails := QuerySmsfContext3gppProcedure(ueId)
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
 	} else if problemDetails != nil {
@@ -2869,14 +3511,16 @@ func HandleQuerySmsfContext3gpp(request *httpwrapper.Request) *httpwrapper.Respo
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func QuerySmsfContext3gppProcedure(collName string, ueId string) (*map[string]interface{}, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QuerySmsfContext3gppProcedure err: %s", pd.Detail)
-		return nil, pd
+func QuerySmsfContext3gppProcedure(ueId string) (*models.SmsfRegistration, *models.ProblemDetails) {
+	data, err := repository.GetSmsf3GppAccessRegistration(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QuerySmsfContext3gppProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &data, nil
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandleCreateSmsfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.Response {
@@ -2886,7 +3530,9 @@ func HandleCreateSmsfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.R
 	collName := "subscriptionData.contextData.smsfNon3gppAccess"
 	ueId := request.Params["ueId"]
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	CreateSmsfContextNon3gppProcedure(SmsfRegistration, collName, ueId)
+	history.record()
 
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 }
@@ -2896,7 +3542,10 @@ func CreateSmsfContextNon3gppProcedure(SmsfRegistration models.SmsfRegistration,
 	putData["ueId"] = ueId
 	filter := bson.M{"ueId": ueId}
 
-	_, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+	err := observeSmsfRegistration(ueId, repository.GetSmsfNon3GppAccessRegistration, func() error {
+		_, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+		return err
+	})
 	if err != nil {
 		logger.DataRepoLog.Errorf("CreateSmsfContextNon3gppProcedure err: %+v", err)
 	}
@@ -2908,7 +3557,9 @@ func HandleDeleteSmsfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.R
 	collName := "subscriptionData.contextData.smsfNon3gppAccess"
 	ueId := request.Params["ueId"]
 
+	history := newHistoryRecorder(request, collName, ueId, bson.M{"ueId": ueId})
 	DeleteSmsfContextNon3gppProcedure(collName, ueId)
+	history.record()
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
 }
 
@@ -2921,9 +3572,8 @@ func HandleQuerySmsfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.Re
 	logger.DataRepoLog.Infof("Handle QuerySmsfContextNon3gpp")
 
 	ueId := request.Params["ueId"]
-	collName := "subscriptionData.contextData.smsfNon3gppAccess"
 
-	response, problemDetails := QuerySmsfContextNon3gppProcedure(collName, ueId)
+	response, problemDetails := QuerySmsfContextNon3gppProcedure(ueId)
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
 	} else if problemDetails != nil {
@@ -2934,23 +3584,25 @@ func HandleQuerySmsfContextNon3gpp(request *httpwrapper.Request) *httpwrapper.Re
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func QuerySmsfContextNon3gppProcedure(collName string, ueId string) (*map[string]interface{}, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QuerySmsfContextNon3gppProcedure err: %s", pd.Detail)
-		return nil, pd
+func QuerySmsfContextNon3gppProcedure(ueId string) (*models.SmsfRegistration, *models.ProblemDetails) {
+	data, err := repository.GetSmsfNon3GppAccessRegistration(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QuerySmsfContextNon3gppProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &data, nil
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandleQuerySmsMngData(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle QuerySmsMngData")
 
-	collName := "subscriptionData.provisionedData.smsMngData"
 	ueId := request.Params["ueId"]
 	servingPlmnId := request.Params["servingPlmnId"]
-	response, problemDetails := QuerySmsMngDataProcedure(collName, ueId, servingPlmnId)
+	mergeSharedData := request.Query.Get("merge-shared-data") == "true"
+	response, problemDetails := QuerySmsMngDataProcedure(ueId, servingPlmnId, mergeSharedData)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -2962,16 +3614,32 @@ func HandleQuerySmsMngData(request *httpwrapper.Request) *httpwrapper.Response {
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func QuerySmsMngDataProcedure(collName string, ueId string,
-	servingPlmnId string,
-) (*map[string]interface{}, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QuerySmsMngDataProcedure err: %s", pd.Detail)
-		return nil, pd
+// QuerySmsMngDataProcedure returns the SMS management subscription data of the
+// UE, with the shared data it refers to merged in when mergeSharedData is set.
+func QuerySmsMngDataProcedure(ueId string, servingPlmnId string,
+	mergeSharedData bool,
+) (*models.SmsManagementSubscriptionData, *models.ProblemDetails) {
+	var data *models.SmsManagementSubscriptionData
+	var err error
+	if mergeSharedData {
+		err = database.WithTransaction(func(ctx context.Context) error {
+			if data, err = repository.GetSmsMngData(ctx, ueId, servingPlmnId); err != nil {
+				return err
+			}
+			data, err = repository.DereferenceSmsMngData(ctx, data)
+			return err
+		})
+	} else {
+		data, err = repository.GetSmsMngData(context.TODO(), ueId, servingPlmnId)
 	}
-	return &data, nil
+	if err != nil {
+		logger.DataRepoLog.Errorf("QuerySmsMngDataProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandleQuerySmsData(request *httpwrapper.Request) *httpwrapper.Response {
@@ -2979,9 +3647,9 @@ func HandleQuerySmsData(request *httpwrapper.Request) *httpwrapper.Response {
 
 	ueId := request.Params["ueId"]
 	servingPlmnId := request.Params["servingPlmnId"]
-	collName := "subscriptionData.provisionedData.smsData"
+	mergeSharedData := request.Query.Get("merge-shared-data") == "true"
 
-	response, problemDetails := QuerySmsDataProcedure(collName, ueId, servingPlmnId)
+	response, problemDetails := QuerySmsDataProcedure(ueId, servingPlmnId, mergeSharedData)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -2993,16 +3661,32 @@ func HandleQuerySmsData(request *httpwrapper.Request) *httpwrapper.Response {
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func QuerySmsDataProcedure(collName string, ueId string,
-	servingPlmnId string,
-) (*map[string]interface{}, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QuerySmsDataProcedure err: %s", pd.Detail)
-		return nil, pd
+// QuerySmsDataProcedure returns the SMS subscription data of the UE, with the
+// shared data it refers to merged in when mergeSharedData is set.
+func QuerySmsDataProcedure(ueId string, servingPlmnId string,
+	mergeSharedData bool,
+) (*models.SmsSubscriptionData, *models.ProblemDetails) {
+	var data *models.SmsSubscriptionData
+	var err error
+	if mergeSharedData {
+		err = database.WithTransaction(func(ctx context.Context) error {
+			if data, err = repository.GetSmsData(ctx, ueId, servingPlmnId); err != nil {
+				return err
+			}
+			data, err = repository.DereferenceSmsData(ctx, data)
+			return err
+		})
+	} else {
+		data, err = repository.GetSmsData(context.TODO(), ueId, servingPlmnId)
 	}
-	return &data, nil
+	if err != nil {
+		logger.DataRepoLog.Errorf("QuerySmsDataProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
 
 func HandlePostSubscriptionDataSubscriptions(request *httpwrapper.Request) *httpwrapper.Response {
@@ -3022,9 +3706,11 @@ func PostSubscriptionDataSubscriptionsProcedure(
 ) string {
 	udrSelf := udr_context.UDR_Self()
 
+	udrSelf.SubscriptionDataSubscriptionsMtx.Lock()
 	newSubscriptionID := strconv.Itoa(udrSelf.SubscriptionDataSubscriptionIDGenerator)
 	udrSelf.SubscriptionDataSubscriptions[newSubscriptionID] = &SubscriptionDataSubscriptions
 	udrSelf.SubscriptionDataSubscriptionIDGenerator++
+	udrSelf.SubscriptionDataSubscriptionsMtx.Unlock()
 
 	/* Contains the URI of the newly created resource, according
 	   to the structure: {apiRoot}/subscription-data/subs-to-notify/{subsId} */
@@ -3050,6 +3736,8 @@ func HandleRemovesubscriptionDataSubscriptions(request *httpwrapper.Request) *ht
 
 func RemovesubscriptionDataSubscriptionsProcedure(subsId string) *models.ProblemDetails {
 	udrSelf := udr_context.UDR_Self()
+	udrSelf.SubscriptionDataSubscriptionsMtx.Lock()
+	defer udrSelf.SubscriptionDataSubscriptionsMtx.Unlock()
 	_, ok := udrSelf.SubscriptionDataSubscriptions[subsId]
 	if !ok {
 		return util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
@@ -3061,11 +3749,10 @@ func RemovesubscriptionDataSubscriptionsProcedure(subsId string) *models.Problem
 func HandleQueryTraceData(request *httpwrapper.Request) *httpwrapper.Response {
 	logger.DataRepoLog.Infof("Handle QueryTraceData")
 
-	collName := "subscriptionData.provisionedData.traceData"
 	ueId := request.Params["ueId"]
 	servingPlmnId := request.Params["servingPlmnId"]
 
-	response, problemDetails := QueryTraceDataProcedure(collName, ueId, servingPlmnId)
+	response, problemDetails := QueryTraceDataProcedure(ueId, servingPlmnId)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -3077,14 +3764,14 @@ func HandleQueryTraceData(request *httpwrapper.Request) *httpwrapper.Response {
 	return httpwrapper.NewResponse(int(pd.Status), nil, pd)
 }
 
-func QueryTraceDataProcedure(collName string, ueId string,
-	servingPlmnId string,
-) (*map[string]interface{}, *models.ProblemDetails) {
-	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	data, pd := getDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QueryTraceDataProcedure err: %s", pd.Detail)
-		return nil, pd
+func QueryTraceDataProcedure(ueId string, servingPlmnId string) (*models.TraceData, *models.ProblemDetails) {
+	data, err := repository.GetTraceData(context.TODO(), ueId, servingPlmnId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryTraceDataProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
 	}
-	return &data, nil
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
 }
@@ -0,0 +1,74 @@
+package producer
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+)
+
+func TestInfluenceDataFilter(t *testing.T) {
+	anyUe := []bson.M{
+		{"anyUeInd": true},
+		{"supi": bson.M{"$exists": false}, "interGroupId": bson.M{"$exists": false}},
+	}
+	testCases := []struct {
+		name        string
+		influIDs    []string
+		dnns        []string
+		snssais     []models.Snssai
+		intGroupIDs []string
+		supis       []string
+		want        bson.M
+	}{
+		{
+			name: "no parameters",
+			want: bson.M{},
+		},
+		{
+			name:     "influence IDs and DNNs",
+			influIDs: []string{"influ1"},
+			dnns:     []string{"internet"},
+			want: bson.M{"$and": []bson.M{
+				{"influenceId": bson.M{"$in": []string{"influ1"}}},
+				{"dnn": bson.M{"$in": []string{"internet"}}},
+			}},
+		},
+		{
+			name:    "S-NSSAIs with and without SD",
+			snssais: []models.Snssai{{Sst: 1, Sd: "010203"}, {Sst: 2}},
+			want: bson.M{"$and": []bson.M{
+				{"$or": []bson.M{
+					{"snssai.sst": int32(1), "snssai.sd": "010203"},
+					{"snssai.sst": int32(2), "snssai.sd": bson.M{"$exists": false}},
+				}},
+			}},
+		},
+		{
+			name:  "SUPIs also match data for any UE",
+			supis: []string{"imsi-208930000000001"},
+			want: bson.M{"$and": []bson.M{
+				{"$or": append(append([]bson.M{}, anyUe...),
+					bson.M{"supi": bson.M{"$in": []string{"imsi-208930000000001"}}})},
+			}},
+		},
+		{
+			name:        "internal group IDs and SUPIs",
+			intGroupIDs: []string{"group1"},
+			supis:       []string{"imsi-208930000000001"},
+			want: bson.M{"$and": []bson.M{
+				{"$or": append(append([]bson.M{}, anyUe...),
+					bson.M{"interGroupId": bson.M{"$in": []string{"group1"}}},
+					bson.M{"supi": bson.M{"$in": []string{"imsi-208930000000001"}}})},
+			}},
+		},
+	}
+	for _, tc := range testCases {
+		got := influenceDataFilter(tc.influIDs, tc.dnns, tc.snssais, tc.intGroupIDs, tc.supis)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: influenceDataFilter() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
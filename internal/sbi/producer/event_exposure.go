@@ -0,0 +1,353 @@
+package producer
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/repository"
+	"github.com/free5gc/udr/internal/sbi/producer/callback"
+)
+
+// eeEvent is a monitoring event the UDR observed in a change of the data of a
+// UE.
+type eeEvent struct {
+	eventType models.EventType
+	report    *models.Report
+}
+
+// ueHasEeSubscriptions reports whether ueId may have EE subscriptions, its
+// own or those of a group, so that the changes of UEs nobody monitors are not
+// evaluated. Group membership is only looked up when reporting.
+func ueHasEeSubscriptions(ueId string) bool {
+	udrSelf := udr_context.UDR_Self()
+	if value, ok := udrSelf.UESubsCollection.Load(ueId); ok {
+		ueSubsData := value.(*udr_context.UESubsData)
+		ueSubsData.EeMtx.Lock()
+		hasSubscriptions := len(ueSubsData.EeSubscriptionCollection) != 0
+		ueSubsData.EeMtx.Unlock()
+		if hasSubscriptions {
+			return true
+		}
+	}
+	hasGroupSubscriptions := false
+	udrSelf.UEGroupCollection.Range(func(key, value interface{}) bool {
+		ueGroupSubsData := value.(*udr_context.UEGroupSubsData)
+		ueGroupSubsData.EeMtx.Lock()
+		hasGroupSubscriptions = len(ueGroupSubsData.EeSubscriptions) != 0
+		ueGroupSubsData.EeMtx.Unlock()
+		return !hasGroupSubscriptions
+	})
+	return hasGroupSubscriptions
+}
+
+// monitoringReportsToSend are the monitoring reports of an event, collected
+// while the subscriptions are locked and sent once they are unlocked.
+type monitoringReportsToSend struct {
+	callbackReference string
+	reports           []models.MonitoringReport
+}
+
+func (r monitoringReportsToSend) send() {
+	go callback.SendMonitoringReports(r.callbackReference, r.reports)
+}
+
+// isRoaming reports whether servingPlmn is not the home PLMN of the IMSI based
+// SUPI ueId. The home PLMN of other UE identities is unknown, so they are never
+// reported as roaming.
+func isRoaming(ueId string, servingPlmn *models.PlmnId) bool {
+	imsi := strings.TrimPrefix(ueId, "imsi-")
+	if imsi == ueId {
+		return false
+	}
+	return !strings.HasPrefix(imsi, servingPlmn.Mcc+servingPlmn.Mnc)
+}
+
+func servingPlmn(registration *models.Amf3GppAccessRegistration) *models.PlmnId {
+	if registration == nil || registration.Guami == nil {
+		return nil
+	}
+	return registration.Guami.PlmnId
+}
+
+// amf3GppAccessEvents returns the events of the change of the AMF 3GPP access
+// registration of ueId from orig to registration. orig is nil when the UE was
+// not registered.
+func amf3GppAccessEvents(ueId string, orig *models.Amf3GppAccessRegistration,
+	registration *models.Amf3GppAccessRegistration,
+) []eeEvent {
+	if registration == nil {
+		return nil
+	}
+
+	var events []eeEvent
+	if registration.Pei != "" && (orig == nil || orig.Pei != registration.Pei) {
+		events = append(events, eeEvent{
+			eventType: models.EventType_CHANGE_OF_SUPI_PEI_ASSOCIATION,
+			report:    &models.Report{NewPei: registration.Pei},
+		})
+	}
+	newPlmn, origPlmn := servingPlmn(registration), servingPlmn(orig)
+	if newPlmn != nil && (origPlmn == nil || *origPlmn != *newPlmn) {
+		events = append(events, eeEvent{
+			eventType: models.EventType_ROAMING_STATUS,
+			report: &models.Report{
+				Roaming:        isRoaming(ueId, newPlmn),
+				NewServingPlmn: newPlmn,
+			},
+		})
+	}
+	return events
+}
+
+// smsfRegistrationEvents returns the events of the change of an SMSF
+// registration from orig to registration. The UE becomes reachable for SMS when
+// it registers to an SMSF.
+func smsfRegistrationEvents(orig *models.SmsfRegistration, registration *models.SmsfRegistration) []eeEvent {
+	if orig != nil || registration == nil {
+		return nil
+	}
+	return []eeEvent{{eventType: models.EventType_UE_REACHABILITY_FOR_SMS}}
+}
+
+// observeAmf3GppAccess runs write, which changes the AMF 3GPP access
+// registration of ueId, and reports the monitoring events of the change.
+func observeAmf3GppAccess(ueId string, write func() error) error {
+	if !ueHasEeSubscriptions(ueId) {
+		return write()
+	}
+
+	orig, err := repository.GetAmf3GppAccessRegistration(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Warnf("observeAmf3GppAccess err: %+v", err)
+		return write()
+	}
+	if err = write(); err != nil {
+		return err
+	}
+	registration, err := repository.GetAmf3GppAccessRegistration(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Warnf("observeAmf3GppAccess err: %+v", err)
+		return nil
+	}
+	reportEeEvents(ueId, amf3GppAccessEvents(ueId, orig, registration))
+	return nil
+}
+
+// observeSmsfRegistration runs write, which changes the SMSF registration of
+// ueId that get reads, and reports the monitoring events of the change.
+func observeSmsfRegistration(ueId string,
+	get func(ctx context.Context, ueId string) (*models.SmsfRegistration, error),
+	write func() error,
+) error {
+	if !ueHasEeSubscriptions(ueId) {
+		return write()
+	}
+
+	orig, err := get(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Warnf("observeSmsfRegistration err: %+v", err)
+		return write()
+	}
+	if err = write(); err != nil {
+		return err
+	}
+	registration, err := get(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Warnf("observeSmsfRegistration err: %+v", err)
+		return nil
+	}
+	reportEeEvents(ueId, smsfRegistrationEvents(orig, registration))
+	return nil
+}
+
+// eeSubscriptionExpired reports whether the monitoring duration of
+// eeSubscription ended at now.
+func eeSubscriptionExpired(eeSubscription *models.EeSubscription, now time.Time) bool {
+	reportingOptions := eeSubscription.ReportingOptions
+	return reportingOptions != nil && reportingOptions.Expiry != nil && !now.Before(*reportingOptions.Expiry)
+}
+
+// maxNumOfReports returns the maximum number of reports of eeSubscription, or
+// 0 when it is unlimited.
+func maxNumOfReports(eeSubscription *models.EeSubscription) int32 {
+	if eeSubscription.ReportingOptions == nil || eeSubscription.ReportingOptions.MaxNumOfReports < 0 {
+		return 0
+	}
+	return eeSubscription.ReportingOptions.MaxNumOfReports
+}
+
+// limitReports returns the reports that can still be sent once numOfReports of
+// maxNum reports were sent.
+func limitReports(reports []models.MonitoringReport, numOfReports int32,
+	maxNum int32,
+) []models.MonitoringReport {
+	if maxNum == 0 {
+		return reports
+	}
+	remaining := maxNum - numOfReports
+	if remaining < 0 {
+		remaining = 0
+	}
+	if int32(len(reports)) > remaining {
+		return reports[:remaining]
+	}
+	return reports
+}
+
+// monitoringReports returns the reports of events for the monitoring
+// configurations of eeSubscription, in the order of their reference IDs. gpsi
+// identifies the member UE in the reports of a group subscription.
+func monitoringReports(eeSubscription *models.EeSubscription, events []eeEvent, gpsi string,
+	timeStamp time.Time,
+) []models.MonitoringReport {
+	var reports []models.MonitoringReport
+	for key, monitoringConfiguration := range eeSubscription.MonitoringConfigurations {
+		// The monitoring configurations are keyed by their reference ID
+		referenceId, err := strconv.ParseInt(key, 10, 32)
+		if err != nil {
+			logger.DataRepoLog.Warnf("Invalid monitoring configuration reference ID %q", key)
+			continue
+		}
+		for _, event := range events {
+			if event.eventType != monitoringConfiguration.EventType {
+				continue
+			}
+			reports = append(reports, models.MonitoringReport{
+				ReferenceId: int32(referenceId),
+				EventType:   event.eventType,
+				Report:      event.report,
+				Gpsi:        gpsi,
+				TimeStamp:   &timeStamp,
+			})
+		}
+	}
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].ReferenceId < reports[j].ReferenceId
+	})
+	return reports
+}
+
+// reportEeEvents sends the monitoring reports of events to the EE subscriptions
+// of ueId and of the groups ueId is a member of that monitor them.
+func reportEeEvents(ueId string, events []eeEvent) {
+	if len(events) == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	reportUeEeEvents(ueId, events, now)
+	reportGroupEeEvents(ueId, events, now)
+}
+
+// reportUeEeEvents reports events to the EE subscriptions of ueId. A
+// subscription ends when it expires or when it reaches its maximum number of
+// reports.
+func reportUeEeEvents(ueId string, events []eeEvent, now time.Time) {
+	value, ok := udr_context.UDR_Self().UESubsCollection.Load(ueId)
+	if !ok {
+		return
+	}
+	ueSubsData := value.(*udr_context.UESubsData)
+
+	// The reports are counted, and the subscriptions ended, under the lock
+	var toSend []monitoringReportsToSend
+	ueSubsData.EeMtx.Lock()
+	for subsId, eeSubscriptionCollection := range ueSubsData.EeSubscriptionCollection {
+		eeSubscription := eeSubscriptionCollection.EeSubscriptions
+		if eeSubscriptionExpired(eeSubscription, now) {
+			logger.DataRepoLog.Infof("EE subscription %s of %s expired", subsId, ueId)
+			delete(ueSubsData.EeSubscriptionCollection, subsId)
+			continue
+		}
+
+		maxNum := maxNumOfReports(eeSubscription)
+		reports := limitReports(monitoringReports(eeSubscription, events, "", now),
+			eeSubscriptionCollection.NumOfReports, maxNum)
+		eeSubscriptionCollection.NumOfReports += int32(len(reports))
+		if maxNum > 0 && eeSubscriptionCollection.NumOfReports >= maxNum {
+			logger.DataRepoLog.Infof("EE subscription %s of %s reached its maximum number of reports",
+				subsId, ueId)
+			delete(ueSubsData.EeSubscriptionCollection, subsId)
+		}
+		if len(reports) != 0 {
+			toSend = append(toSend, monitoringReportsToSend{eeSubscription.CallbackReference, reports})
+		}
+	}
+	ueSubsData.EeMtx.Unlock()
+
+	for _, r := range toSend {
+		r.send()
+	}
+}
+
+// reportGroupEeEvents reports events to the EE subscriptions of the groups
+// ueId is a member of. A group subscription ends when it expires, while the
+// maximum number of reports only stops the reports of the member reaching it.
+func reportGroupEeEvents(ueId string, events []eeEvent, now time.Time) {
+	udrSelf := udr_context.UDR_Self()
+
+	groups, err := repository.GetUeGroupIdentifiers(context.TODO(), ueId)
+	if err != nil {
+		logger.DataRepoLog.Warnf("reportGroupEeEvents err: %+v", err)
+		return
+	}
+	var toSend []monitoringReportsToSend
+	for i := range groups {
+		member := groups[i].Member(ueId)
+		if member == nil {
+			continue
+		}
+		var gpsi string
+		if len(member.GpsiList) != 0 {
+			gpsi = member.GpsiList[0]
+		}
+
+		// A group EE subscription is created for the internal or the external
+		// group identifier
+		for _, ueGroupId := range []string{groups[i].IntGroupId, groups[i].ExtGroupId} {
+			if ueGroupId == "" {
+				continue
+			}
+			value, ok := udrSelf.UEGroupCollection.Load(ueGroupId)
+			if !ok {
+				continue
+			}
+			ueGroupSubsData := value.(*udr_context.UEGroupSubsData)
+			ueGroupSubsData.EeMtx.Lock()
+			if ueGroupSubsData.NumOfReports == nil {
+				ueGroupSubsData.NumOfReports = make(map[string]map[string]int32)
+			}
+
+			for subsId, eeSubscription := range ueGroupSubsData.EeSubscriptions {
+				if eeSubscriptionExpired(eeSubscription, now) {
+					logger.DataRepoLog.Infof("EE subscription %s of group %s expired", subsId, ueGroupId)
+					delete(ueGroupSubsData.EeSubscriptions, subsId)
+					delete(ueGroupSubsData.NumOfReports, subsId)
+					continue
+				}
+
+				numOfReports := ueGroupSubsData.NumOfReports[subsId]
+				if numOfReports == nil {
+					numOfReports = make(map[string]int32)
+					ueGroupSubsData.NumOfReports[subsId] = numOfReports
+				}
+				reports := limitReports(monitoringReports(eeSubscription, events, gpsi, now),
+					numOfReports[member.Supi], maxNumOfReports(eeSubscription))
+				numOfReports[member.Supi] += int32(len(reports))
+				if len(reports) != 0 {
+					toSend = append(toSend, monitoringReportsToSend{eeSubscription.CallbackReference, reports})
+				}
+			}
+			ueGroupSubsData.EeMtx.Unlock()
+		}
+	}
+
+	for _, r := range toSend {
+		r.send()
+	}
+}
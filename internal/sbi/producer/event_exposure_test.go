@@ -0,0 +1,37 @@
+package producer
+
+import (
+	"testing"
+
+	"github.com/free5gc/openapi/models"
+)
+
+func TestLimitReports(t *testing.T) {
+	reports := []models.MonitoringReport{{ReferenceId: 1}, {ReferenceId: 2}, {ReferenceId: 3}}
+	testCases := []struct {
+		name         string
+		numOfReports int32
+		maxNum       int32
+		want         int
+	}{
+		{"unlimited", 10, 0, 3},
+		{"none sent", 0, 5, 3},
+		{"some left", 3, 5, 2},
+		{"limit reached", 5, 5, 0},
+		{"limit passed", 7, 5, 0},
+	}
+	for _, tc := range testCases {
+		got := limitReports(reports, tc.numOfReports, tc.maxNum)
+		if len(got) != tc.want {
+			t.Errorf("%s: limitReports(%d, %d) returned %d reports, want %d",
+				tc.name, tc.numOfReports, tc.maxNum, len(got), tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i].ReferenceId != reports[i].ReferenceId {
+				t.Errorf("%s: report %d has reference ID %d, want %d",
+					tc.name, i, got[i].ReferenceId, reports[i].ReferenceId)
+			}
+		}
+	}
+}
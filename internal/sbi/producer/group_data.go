@@ -0,0 +1,161 @@
+package producer
+
+import (
+	"context"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/repository"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+func HandleQueryGroupIdentifiers(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle QueryGroupIdentifiers")
+
+	extGroupId := request.Query.Get("ext-group-id")
+	intGroupId := request.Query.Get("int-group-id")
+	ueIdInd := request.Query.Get("ue-id-ind") == "true"
+
+	response, problemDetails := QueryGroupIdentifiersProcedure(extGroupId, intGroupId, ueIdInd)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+// QueryGroupIdentifiersProcedure resolves the external group identifier
+// extGroupId or the internal group identifier intGroupId to both identifiers
+// of the group, and to its members when ueIdInd is set.
+func QueryGroupIdentifiersProcedure(extGroupId string, intGroupId string,
+	ueIdInd bool,
+) (*repository.GroupIdentifiers, *models.ProblemDetails) {
+	if (extGroupId == "") == (intGroupId == "") {
+		return nil, util.ProblemDetailsMalformedReqSyntax("either ext-group-id or int-group-id must be given")
+	}
+
+	var groupIdentifiers *repository.GroupIdentifiers
+	var err error
+	if extGroupId != "" {
+		groupIdentifiers, err = repository.GetGroupIdentifiersByExtGroupId(context.TODO(), extGroupId)
+	} else {
+		groupIdentifiers, err = repository.GetGroupIdentifiers(context.TODO(), intGroupId)
+	}
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryGroupIdentifiersProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if groupIdentifiers == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	if !ueIdInd {
+		groupIdentifiers.UeIdList = nil
+	}
+	return groupIdentifiers, nil
+}
+
+func HandleQueryGroupMembers(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle QueryGroupMembers")
+
+	intGroupId := request.Params["intGroupId"]
+
+	response, problemDetails := QueryGroupMembersProcedure(intGroupId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+func QueryGroupMembersProcedure(intGroupId string) (*repository.GroupIdentifiers, *models.ProblemDetails) {
+	groupIdentifiers, err := repository.GetGroupIdentifiers(context.TODO(), intGroupId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryGroupMembersProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if groupIdentifiers == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return groupIdentifiers, nil
+}
+
+func HandlePutGroupMembers(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PutGroupMembers")
+
+	intGroupId := request.Params["intGroupId"]
+	groupIdentifiers := request.Body.(repository.GroupIdentifiers)
+
+	created, problemDetails := PutGroupMembersProcedure(intGroupId, &groupIdentifiers)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	if created {
+		return httpwrapper.NewResponse(http.StatusCreated, nil, groupIdentifiers)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+// PutGroupMembersProcedure replaces the membership of the group intGroupId.
+// An external group identifier can only name one group. It reports whether
+// the group was created.
+func PutGroupMembersProcedure(intGroupId string,
+	groupIdentifiers *repository.GroupIdentifiers,
+) (bool, *models.ProblemDetails) {
+	if groupIdentifiers.IntGroupId == "" {
+		groupIdentifiers.IntGroupId = intGroupId
+	} else if groupIdentifiers.IntGroupId != intGroupId {
+		return false, util.ProblemDetailsMalformedReqSyntax("intGroupId does not match the URI")
+	}
+	for _, ueId := range groupIdentifiers.UeIdList {
+		if ueId.Supi == "" {
+			return false, util.ProblemDetailsMalformedReqSyntax("supi of a member must be given")
+		}
+	}
+
+	if groupIdentifiers.ExtGroupId != "" {
+		other, err := repository.GetGroupIdentifiersByExtGroupId(context.TODO(), groupIdentifiers.ExtGroupId)
+		if err != nil {
+			logger.DataRepoLog.Errorf("PutGroupMembersProcedure err: %+v", err)
+			return false, util.ProblemDetailsSystemFailure(err.Error())
+		}
+		if other != nil && other.IntGroupId != intGroupId {
+			return false, util.ProblemDetailsConflict("extGroupId is used by group " + other.IntGroupId)
+		}
+	}
+
+	existed, err := database.ReplaceOne(context.TODO(), repository.GroupIdentifiersCollName,
+		bson.M{"intGroupId": intGroupId}, util.ToBsonM(groupIdentifiers))
+	if err != nil {
+		logger.DataRepoLog.Errorf("PutGroupMembersProcedure err: %+v", err)
+		return false, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	return !existed, nil
+}
+
+func HandleDeleteGroupMembers(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle DeleteGroupMembers")
+
+	intGroupId := request.Params["intGroupId"]
+
+	problemDetails := DeleteGroupMembersProcedure(intGroupId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func DeleteGroupMembersProcedure(intGroupId string) *models.ProblemDetails {
+	deleted, err := database.DeleteMany(context.TODO(), repository.GroupIdentifiersCollName,
+		bson.M{"intGroupId": intGroupId})
+	if err != nil {
+		logger.DataRepoLog.Errorf("DeleteGroupMembersProcedure err: %+v", err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if deleted == 0 {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return nil
+}
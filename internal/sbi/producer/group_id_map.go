@@ -0,0 +1,311 @@
+package producer
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/identity"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/repository"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+// NfGroupIdMapResult is the TS 29.504 result of a Nudr_GroupIDmap lookup,
+// which gives the NF group serving the subscriber for each requested NF type.
+type NfGroupIdMapResult struct {
+	NfGroupIds map[models.NfType]string `json:"nfGroupIds"`
+}
+
+// identityDigits returns the number of an IMSI based SUPI or an MSISDN based
+// GPSI, to which the start and end of identity ranges apply.
+func identityDigits(ueId string) string {
+	for _, prefix := range []string{"imsi-", "msisdn-"} {
+		if strings.HasPrefix(ueId, prefix) {
+			return strings.TrimPrefix(ueId, prefix)
+		}
+	}
+	return ""
+}
+
+// compileRangePattern compiles the pattern of an identity range, which an
+// identity has to match fully.
+func compileRangePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// rangePatterns caches the compiled patterns of the identity ranges, so that a
+// lookup does not compile the pattern of every range it checks.
+var rangePatterns = struct {
+	sync.RWMutex
+	compiled map[string]*regexp.Regexp
+}{compiled: make(map[string]*regexp.Regexp)}
+
+// rangePattern returns the compiled pattern of an identity range from the cache,
+// compiling it on the first use.
+func rangePattern(pattern string) (*regexp.Regexp, error) {
+	rangePatterns.RLock()
+	re, ok := rangePatterns.compiled[pattern]
+	rangePatterns.RUnlock()
+	if ok {
+		return re, nil
+	}
+	re, err := compileRangePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	rangePatterns.Lock()
+	rangePatterns.compiled[pattern] = re
+	rangePatterns.Unlock()
+	return re, nil
+}
+
+func compareDigits(a string, b string) int {
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// inIdentityRange reports whether ueId is in the range given by its pattern or,
+// when the range has no pattern, by its numeric start and end.
+func inIdentityRange(ueId string, identityRange models.IdentityRange) bool {
+	if identityRange.Pattern != "" {
+		re, err := rangePattern(identityRange.Pattern)
+		if err != nil {
+			logger.DataRepoLog.Warnf("Invalid range pattern %q: %+v", identityRange.Pattern, err)
+			return false
+		}
+		return re.MatchString(ueId)
+	}
+	digits := identityDigits(ueId)
+	if digits == "" || identityRange.Start == "" || identityRange.End == "" {
+		return false
+	}
+	return compareDigits(digits, identityRange.Start) >= 0 && compareDigits(digits, identityRange.End) <= 0
+}
+
+// inMappingRanges reports whether the SUPI or GPSI subscriberId is in one of
+// the ranges of mapping.
+func inMappingRanges(mapping *repository.NfGroupIdMapping, subscriberId string) bool {
+	if identity.IsGpsi(subscriberId) {
+		for _, gpsiRange := range mapping.GpsiRanges {
+			if inIdentityRange(subscriberId, gpsiRange) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, supiRange := range mapping.SupiRanges {
+		if inIdentityRange(subscriberId, models.IdentityRange(supiRange)) {
+			return true
+		}
+	}
+	return false
+}
+
+// servingNfGroupId returns the NF group of mappings that serves subscriberId.
+// A mapping listing the identity takes precedence over the ranges.
+func servingNfGroupId(mappings []repository.NfGroupIdMapping, subscriberId string) (string, bool) {
+	for i := range mappings {
+		for _, id := range mappings[i].SubscriberIds {
+			if id == subscriberId {
+				return mappings[i].NfGroupId, true
+			}
+		}
+	}
+	for i := range mappings {
+		if inMappingRanges(&mappings[i], subscriberId) {
+			return mappings[i].NfGroupId, true
+		}
+	}
+	return "", false
+}
+
+func HandleQueryNfGroupIds(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle QueryNfGroupIds")
+
+	// nf-type is an array serialized as comma separated values
+	var nfTypes []models.NfType
+	for _, value := range request.Query["nf-type"] {
+		for _, nfType := range strings.Split(value, ",") {
+			if nfType != "" {
+				nfTypes = append(nfTypes, models.NfType(nfType))
+			}
+		}
+	}
+	subscriberId := request.Query.Get("subscriberId")
+
+	response, problemDetails := QueryNfGroupIdsProcedure(nfTypes, subscriberId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+// QueryNfGroupIdsProcedure returns the NF groups of nfTypes that serve the
+// SUPI or GPSI subscriberId. NF types without a serving group are left out.
+func QueryNfGroupIdsProcedure(nfTypes []models.NfType,
+	subscriberId string,
+) (*NfGroupIdMapResult, *models.ProblemDetails) {
+	if len(nfTypes) == 0 || subscriberId == "" {
+		return nil, util.ProblemDetailsMalformedReqSyntax("nf-type and subscriberId must be given")
+	}
+
+	result := NfGroupIdMapResult{NfGroupIds: make(map[models.NfType]string)}
+	for _, nfType := range nfTypes {
+		mappings, err := repository.GetNfGroupIdMappings(context.TODO(), nfType)
+		if err != nil {
+			logger.DataRepoLog.Errorf("QueryNfGroupIdsProcedure err: %+v", err)
+			return nil, util.ProblemDetailsSystemFailure(err.Error())
+		}
+		if nfGroupId, ok := servingNfGroupId(mappings, subscriberId); ok {
+			result.NfGroupIds[nfType] = nfGroupId
+		}
+	}
+	if len(result.NfGroupIds) == 0 {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return &result, nil
+}
+
+func HandleQueryNfGroupIdMappings(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle QueryNfGroupIdMappings")
+
+	nfType := models.NfType(request.Query.Get("nf-type"))
+
+	mappings, err := repository.GetNfGroupIdMappings(context.TODO(), nfType)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryNfGroupIdMappings err: %+v", err)
+		problemDetails := util.ProblemDetailsSystemFailure(err.Error())
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, mappings)
+}
+
+func HandleQueryNfGroupIdMapping(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle QueryNfGroupIdMapping")
+
+	nfType := models.NfType(request.Params["nfType"])
+	nfGroupId := request.Params["nfGroupId"]
+
+	response, problemDetails := QueryNfGroupIdMappingProcedure(nfType, nfGroupId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+func QueryNfGroupIdMappingProcedure(nfType models.NfType,
+	nfGroupId string,
+) (*repository.NfGroupIdMapping, *models.ProblemDetails) {
+	mapping, err := repository.GetNfGroupIdMapping(context.TODO(), nfType, nfGroupId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryNfGroupIdMappingProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if mapping == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return mapping, nil
+}
+
+func HandlePutNfGroupIdMapping(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PutNfGroupIdMapping")
+
+	nfType := models.NfType(request.Params["nfType"])
+	nfGroupId := request.Params["nfGroupId"]
+	mapping := request.Body.(repository.NfGroupIdMapping)
+
+	created, problemDetails := PutNfGroupIdMappingProcedure(nfType, nfGroupId, &mapping)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	if created {
+		return httpwrapper.NewResponse(http.StatusCreated, nil, mapping)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+// validateIdentityRange checks that an identity range is given by a valid
+// pattern or by a start and an end.
+func validateIdentityRange(identityRange models.IdentityRange) *models.ProblemDetails {
+	if identityRange.Pattern != "" {
+		if _, err := rangePattern(identityRange.Pattern); err != nil {
+			return util.ProblemDetailsMalformedReqSyntax("invalid range pattern: " + err.Error())
+		}
+		return nil
+	}
+	if identityRange.Start == "" || identityRange.End == "" {
+		return util.ProblemDetailsMalformedReqSyntax("a range needs a pattern or a start and an end")
+	}
+	return nil
+}
+
+// PutNfGroupIdMappingProcedure replaces the mapping of the NF group nfGroupId
+// of nfType. It reports whether the mapping was created.
+func PutNfGroupIdMappingProcedure(nfType models.NfType, nfGroupId string,
+	mapping *repository.NfGroupIdMapping,
+) (bool, *models.ProblemDetails) {
+	if mapping.NfType == "" {
+		mapping.NfType = nfType
+	}
+	if mapping.NfGroupId == "" {
+		mapping.NfGroupId = nfGroupId
+	}
+	if mapping.NfType != nfType || mapping.NfGroupId != nfGroupId {
+		return false, util.ProblemDetailsMalformedReqSyntax("nfType and nfGroupId do not match the URI")
+	}
+	for _, supiRange := range mapping.SupiRanges {
+		if problemDetails := validateIdentityRange(models.IdentityRange(supiRange)); problemDetails != nil {
+			return false, problemDetails
+		}
+	}
+	for _, gpsiRange := range mapping.GpsiRanges {
+		if problemDetails := validateIdentityRange(gpsiRange); problemDetails != nil {
+			return false, problemDetails
+		}
+	}
+
+	existed, err := database.ReplaceOne(context.TODO(), repository.NfGroupIdMapCollName,
+		repository.NfGroupIdFilter(nfType, nfGroupId), util.ToBsonM(mapping))
+	if err != nil {
+		logger.DataRepoLog.Errorf("PutNfGroupIdMappingProcedure err: %+v", err)
+		return false, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	return !existed, nil
+}
+
+func HandleDeleteNfGroupIdMapping(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle DeleteNfGroupIdMapping")
+
+	nfType := models.NfType(request.Params["nfType"])
+	nfGroupId := request.Params["nfGroupId"]
+
+	problemDetails := DeleteNfGroupIdMappingProcedure(nfType, nfGroupId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func DeleteNfGroupIdMappingProcedure(nfType models.NfType, nfGroupId string) *models.ProblemDetails {
+	deleted, err := database.DeleteMany(context.TODO(), repository.NfGroupIdMapCollName,
+		repository.NfGroupIdFilter(nfType, nfGroupId))
+	if err != nil {
+		logger.DataRepoLog.Errorf("DeleteNfGroupIdMappingProcedure err: %+v", err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if deleted == 0 {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return nil
+}
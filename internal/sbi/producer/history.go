@@ -0,0 +1,310 @@
+package producer
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/repository"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/httpwrapper"
+	"github.com/free5gc/util/mongoapi"
+)
+
+const HISTORY_DB_COLLECTION_NAME = "udr.history"
+
+// historyRecorder keeps the image of a resource taken before a write, so that
+// the change can be stored in the history collection once the write is done.
+type historyRecorder struct {
+	request  *httpwrapper.Request
+	collName string
+	ueId     string
+	// sharedDataId is set instead of ueId for the changes of shared data
+	sharedDataId string
+	// load returns the current image of the resource
+	load   func() (interface{}, error)
+	before interface{}
+}
+
+// newHistoryRecorder records the document matching filter. It returns nil when
+// the change history is disabled.
+func newHistoryRecorder(request *httpwrapper.Request, collName string, ueId string,
+	filter bson.M,
+) *historyRecorder {
+	return startHistoryRecorder(request, collName, ueId, func() (interface{}, error) {
+		return mongoapi.RestfulAPIGetOne(collName, filter)
+	})
+}
+
+// newSharedDataHistoryRecorder records the shared data sharedDataId, whose
+// changes are recorded under its sharedDataId as it belongs to no UE.
+func newSharedDataHistoryRecorder(request *httpwrapper.Request, sharedDataId string) *historyRecorder {
+	h := newHistoryRecorder(request, repository.SharedDataCollName, "", repository.SharedDataFilter(sharedDataId))
+	if h != nil {
+		h.sharedDataId = sharedDataId
+	}
+	return h
+}
+
+// newListHistoryRecorder records the documents matching filter, which make up
+// one resource, as a single image. It returns nil when the change history is
+// disabled.
+func newListHistoryRecorder(request *httpwrapper.Request, collName string, ueId string,
+	filter bson.M,
+) *historyRecorder {
+	return startHistoryRecorder(request, collName, ueId, func() (interface{}, error) {
+		list, err := mongoapi.RestfulAPIGetMany(collName, filter)
+		if err != nil || len(list) == 0 {
+			return nil, err
+		}
+		return list, nil
+	})
+}
+
+func startHistoryRecorder(request *httpwrapper.Request, collName string, ueId string,
+	load func() (interface{}, error),
+) *historyRecorder {
+	if !udr_context.UDR_Self().EnableHistory {
+		return nil
+	}
+
+	before, err := load()
+	if err != nil {
+		logger.DataRepoLog.Errorf("newHistoryRecorder err: %+v", err)
+		return nil
+	}
+	return &historyRecorder{
+		request:  request,
+		collName: collName,
+		ueId:     ueId,
+		load:     load,
+		before:   before,
+	}
+}
+
+// redact returns image without the attributes of the collection that hold key
+// material, which must not be kept in the history.
+func (h *historyRecorder) redact(image interface{}) interface{} {
+	if doc, ok := image.(map[string]interface{}); ok {
+		return repository.WithoutSecrets(h.collName, doc)
+	}
+	return image
+}
+
+// record stores the before and after images of the resource, unless the write
+// left it unchanged. A change of the redacted attributes alone is recorded with
+// equal images.
+func (h *historyRecorder) record() {
+	if h == nil {
+		return
+	}
+
+	after, err := h.load()
+	if err != nil {
+		logger.DataRepoLog.Errorf("historyRecorder record err: %+v", err)
+		return
+	}
+	if reflect.DeepEqual(h.before, after) {
+		return
+	}
+
+	callerNfType, callerNfInstanceId := util.CallerNf(h.request.Header)
+	historyData := bson.M{
+		"collection":         h.collName,
+		"callerNfType":       callerNfType,
+		"callerNfInstanceId": callerNfInstanceId,
+		"timestamp":          time.Now().UTC(),
+		"before":             h.redact(h.before),
+		"after":              h.redact(after),
+	}
+	if h.sharedDataId != "" {
+		historyData["sharedDataId"] = h.sharedDataId
+	} else {
+		historyData["ueId"] = h.ueId
+	}
+	if h.request.URL != nil {
+		historyData["resourceUri"] = h.request.URL.Path
+	}
+	if err := mongoapi.RestfulAPIPostMany(HISTORY_DB_COLLECTION_NAME, nil,
+		[]interface{}{historyData}); err != nil {
+		logger.DataRepoLog.Errorf("historyRecorder record err: %+v", err)
+	}
+}
+
+// historyOwner selects the history records of the UE ueId or, for the shared
+// data history, of the shared data sharedDataId.
+func historyOwner(request *httpwrapper.Request) bson.M {
+	if sharedDataId, ok := request.Params["sharedDataId"]; ok {
+		return bson.M{"sharedDataId": sharedDataId}
+	}
+	return bson.M{"ueId": request.Params["ueId"]}
+}
+
+func HandleQueryHistory(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle QueryHistory")
+
+	owner := historyOwner(request)
+	resourceUri := request.Query.Get("resource-uri")
+
+	from, to, problemDetails := parseHistoryTimeRange(request.Query.Get("from"), request.Query.Get("to"))
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+
+	response, problemDetails := QueryHistoryProcedure(owner, resourceUri, from, to)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+func parseHistoryTimeRange(fromQuery, toQuery string) (*time.Time, *time.Time, *models.ProblemDetails) {
+	var from, to *time.Time
+	if fromQuery != "" {
+		t, err := time.Parse(time.RFC3339, fromQuery)
+		if err != nil {
+			return nil, nil, util.ProblemDetailsMalformedReqSyntax("Invalid from: " + err.Error())
+		}
+		from = &t
+	}
+	if toQuery != "" {
+		t, err := time.Parse(time.RFC3339, toQuery)
+		if err != nil {
+			return nil, nil, util.ProblemDetailsMalformedReqSyntax("Invalid to: " + err.Error())
+		}
+		to = &t
+	}
+	return from, to, nil
+}
+
+// QueryHistoryProcedure returns the history records selected by owner, the
+// ueId or the sharedDataId they are recorded under.
+func QueryHistoryProcedure(owner bson.M, resourceUri string, from *time.Time,
+	to *time.Time,
+) ([]map[string]interface{}, *models.ProblemDetails) {
+	filter := bson.M{}
+	for k, v := range owner {
+		filter[k] = v
+	}
+	if resourceUri != "" {
+		filter["resourceUri"] = resourceUri
+	}
+	timeRange := bson.M{}
+	if from != nil {
+		timeRange["$gte"] = *from
+	}
+	if to != nil {
+		timeRange["$lte"] = *to
+	}
+	if len(timeRange) != 0 {
+		filter["timestamp"] = timeRange
+	}
+
+	historyList, err := getHistoryFromDB(filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryHistoryProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	for _, history := range historyList {
+		history["timestamp"] = historyTimestamp(history).Format(time.RFC3339Nano)
+	}
+	return historyList, nil
+}
+
+// getHistoryFromDB returns the matching history records, oldest first.
+func getHistoryFromDB(filter bson.M) ([]map[string]interface{}, error) {
+	historyList, err := mongoapi.RestfulAPIGetMany(HISTORY_DB_COLLECTION_NAME, filter)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(historyList, func(i, j int) bool {
+		return historyTimestamp(historyList[i]).Before(historyTimestamp(historyList[j]))
+	})
+	if historyList == nil {
+		historyList = []map[string]interface{}{}
+	}
+	return historyList, nil
+}
+
+func historyTimestamp(history map[string]interface{}) time.Time {
+	switch t := history["timestamp"].(type) {
+	case primitive.DateTime:
+		return t.Time().UTC()
+	case time.Time:
+		return t
+	default:
+		return time.Time{}
+	}
+}
+
+func HandleQueryHistorySnapshot(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle QueryHistorySnapshot")
+
+	owner := historyOwner(request)
+	resourceUri := request.Query.Get("resource-uri")
+	if resourceUri == "" {
+		pd := util.ProblemDetailsMalformedReqSyntax("Missing resource-uri")
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+	timestamp, err := time.Parse(time.RFC3339, request.Query.Get("timestamp"))
+	if err != nil {
+		pd := util.ProblemDetailsMalformedReqSyntax("Invalid timestamp: " + err.Error())
+		return httpwrapper.NewResponse(int(pd.Status), nil, pd)
+	}
+
+	response, problemDetails := QueryHistorySnapshotProcedure(owner, resourceUri, timestamp)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+// QueryHistorySnapshotProcedure reconstructs a resource as it was at timestamp:
+// the after image of the last change up to timestamp or, when the resource was
+// only changed later, the before image of the first change after it. owner
+// selects the ueId or the sharedDataId the changes are recorded under.
+func QueryHistorySnapshotProcedure(owner bson.M, resourceUri string,
+	timestamp time.Time,
+) (interface{}, *models.ProblemDetails) {
+	filter := bson.M{"resourceUri": resourceUri}
+	for k, v := range owner {
+		filter[k] = v
+	}
+	historyList, err := getHistoryFromDB(filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QueryHistorySnapshotProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if len(historyList) == 0 {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+
+	var image interface{}
+	if i := sort.Search(len(historyList), func(i int) bool {
+		return historyTimestamp(historyList[i]).After(timestamp)
+	}); i > 0 {
+		image = historyList[i-1]["after"]
+	} else {
+		image = historyList[0]["before"]
+	}
+
+	// The image of a list resource, like sm-data, is an array
+	switch data := image.(type) {
+	case map[string]interface{}:
+		if data != nil {
+			return data, nil
+		}
+	case primitive.A:
+		return []interface{}(data), nil
+	case []interface{}:
+		return data, nil
+	}
+	return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+}
@@ -0,0 +1,135 @@
+package producer
+
+import (
+	"encoding/json"
+	"runtime/debug"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/mongoapi"
+)
+
+// Traffic influence data documents keep their validity window as BSON dates
+// beside the validStartTime and validEndTime strings, so that it can be queried.
+const (
+	INFLUDATA_VALID_START_FIELD = "validStartDate"
+	INFLUDATA_VALID_END_FIELD   = "validEndDate"
+)
+
+// How often the validity scheduler looks for influence data that became valid
+// or expired.
+var InfluenceDataValidityCheckInterval = 5 * time.Second
+
+// influenceDataToDB adds the attributes the UDR keeps in an influence data
+// document beside the TrafficInfluData itself.
+func influenceDataToDB(influID string, trInfluData *models.TrafficInfluData, data map[string]interface{}) {
+	data["influenceId"] = influID
+	delete(data, INFLUDATA_VALID_START_FIELD)
+	delete(data, INFLUDATA_VALID_END_FIELD)
+	if trInfluData.ValidStartTime != nil {
+		data[INFLUDATA_VALID_START_FIELD] = trInfluData.ValidStartTime.UTC()
+	}
+	if trInfluData.ValidEndTime != nil {
+		data[INFLUDATA_VALID_END_FIELD] = trInfluData.ValidEndTime.UTC()
+	}
+}
+
+// influenceDataFromDB removes the attributes added by influenceDataToDB.
+func influenceDataFromDB(data map[string]interface{}) {
+	delete(data, "influenceId")
+	delete(data, INFLUDATA_VALID_START_FIELD)
+	delete(data, INFLUDATA_VALID_END_FIELD)
+}
+
+// influenceDataValidAt matches the influence data whose validity window
+// contains t. A missing start or end leaves the window open on that side.
+func influenceDataValidAt(t time.Time) bson.M {
+	return bson.M{"$and": []bson.M{
+		{"$or": []bson.M{
+			{INFLUDATA_VALID_START_FIELD: bson.M{"$exists": false}},
+			{INFLUDATA_VALID_START_FIELD: bson.M{"$lte": t}},
+		}},
+		{"$or": []bson.M{
+			{INFLUDATA_VALID_END_FIELD: bson.M{"$exists": false}},
+			{INFLUDATA_VALID_END_FIELD: bson.M{"$gt": t}},
+		}},
+	}}
+}
+
+func isInfluenceDataValidAt(trInfluData *models.TrafficInfluData, t time.Time) bool {
+	if trInfluData.ValidStartTime != nil && trInfluData.ValidStartTime.After(t) {
+		return false
+	}
+	if trInfluData.ValidEndTime != nil && !trInfluData.ValidEndTime.After(t) {
+		return false
+	}
+	return true
+}
+
+// StartInfluenceDataValidityScheduler notifies the influence data subscribers
+// whenever stored influence data becomes valid or expires. Transitions that
+// happen while the UDR is not running are not notified.
+func StartInfluenceDataValidityScheduler() {
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Print stack for panic to log. Fatalf() will let program exit.
+				logger.DataRepoLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+		}()
+
+		ticker := time.NewTicker(InfluenceDataValidityCheckInterval)
+		defer ticker.Stop()
+		last := time.Now().UTC()
+		for range ticker.C {
+			now := time.Now().UTC()
+			checkInfluenceDataValidity(last, now)
+			last = now
+		}
+	}()
+}
+
+// checkInfluenceDataValidity notifies the validity transitions in (from, to].
+func checkInfluenceDataValidity(from, to time.Time) {
+	becameValid := bson.M{"$and": []bson.M{
+		{INFLUDATA_VALID_START_FIELD: bson.M{"$gt": from, "$lte": to}},
+		influenceDataValidAt(to),
+	}}
+	for influID, trInfluData := range getInfluenceDataForValidity(becameValid) {
+		logger.DataRepoLog.Infof("Traffic influence data %q became valid", influID)
+		PreHandleInfluenceDataUpdateNotification(influID, trInfluData)
+	}
+
+	expired := bson.M{INFLUDATA_VALID_END_FIELD: bson.M{"$gt": from, "$lte": to}}
+	for influID, trInfluData := range getInfluenceDataForValidity(expired) {
+		logger.DataRepoLog.Infof("Traffic influence data %q expired", influID)
+		PreHandleInfluenceDataDeleteNotification(influID, trInfluData)
+	}
+}
+
+func getInfluenceDataForValidity(filter bson.M) map[string]models.TrafficInfluData {
+	influDatas, err := mongoapi.RestfulAPIGetMany(APPDATA_INFLUDATA_DB_COLLECTION_NAME, filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("getInfluenceDataForValidity err: %+v", err)
+		return nil
+	}
+	matched := make(map[string]models.TrafficInfluData, len(influDatas))
+	for _, influData := range influDatas {
+		influID, ok := influData["influenceId"].(string)
+		if !ok {
+			continue
+		}
+		influenceDataFromDB(influData)
+		var trInfluData models.TrafficInfluData
+		if err := json.Unmarshal(util.MapToByte(influData), &trInfluData); err != nil {
+			logger.DataRepoLog.Warnln(err)
+			continue
+		}
+		matched[influID] = trInfluData
+	}
+	return matched
+}
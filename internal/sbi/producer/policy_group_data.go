@@ -0,0 +1,216 @@
+package producer
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/repository"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+// smPolicyDataHolds reports whether smPolicyData has data for snssai and, when
+// dnn is given too, for dnn in snssai. An empty snssai matches any data.
+func smPolicyDataHolds(smPolicyData *models.SmPolicyData, snssai models.Snssai, dnn string) bool {
+	if reflect.DeepEqual(snssai, models.Snssai{}) {
+		return true
+	}
+	snssaiData, ok := smPolicyData.SmPolicySnssaiData[util.SnssaiModelsToHex(snssai)]
+	if !ok {
+		return false
+	}
+	if dnn == "" {
+		return true
+	}
+	_, ok = snssaiData.SmPolicyDnnData[dnn]
+	return ok
+}
+
+// escapeSmPolicyDnnKeys returns the stored representation of smPolicyData, in
+// which the DNN keys are escaped.
+func escapeSmPolicyDnnKeys(smPolicyData models.SmPolicyData) bson.M {
+	data := util.ToBsonM(smPolicyData)
+	snssaiDataMap, ok := data["smPolicySnssaiData"].(map[string]interface{})
+	if !ok {
+		return data
+	}
+	for _, snssaiData := range snssaiDataMap {
+		snssaiDataObject, ok := snssaiData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if dnnData, ok := snssaiDataObject["smPolicyDnnData"].(map[string]interface{}); ok {
+			snssaiDataObject["smPolicyDnnData"] = convertDnnKeys(dnnData, util.EscapeDnn)
+		}
+	}
+	return data
+}
+
+// putGroupPolicyData replaces the policy data of the group in collName. It
+// reports whether the data was created.
+func putGroupPolicyData(collName string, intGroupId string, putData bson.M) (bool, error) {
+	putData["intGroupId"] = intGroupId
+	existed, err := database.ReplaceOne(context.TODO(), collName, bson.M{"intGroupId": intGroupId}, putData)
+	return !existed, err
+}
+
+func deleteGroupPolicyData(collName string, intGroupId string) *models.ProblemDetails {
+	deleted, err := database.DeleteMany(context.TODO(), collName, bson.M{"intGroupId": intGroupId})
+	if err != nil {
+		logger.DataRepoLog.Errorf("deleteGroupPolicyData err: %+v", err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if deleted == 0 {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return nil
+}
+
+func HandlePolicyDataGroupsIntGroupIdAmDataGet(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataGroupsIntGroupIdAmDataGet")
+
+	intGroupId := request.Params["intGroupId"]
+
+	response, problemDetails := PolicyDataGroupsIntGroupIdAmDataGetProcedure(intGroupId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+func PolicyDataGroupsIntGroupIdAmDataGetProcedure(intGroupId string) (*models.AmPolicyData,
+	*models.ProblemDetails,
+) {
+	data, err := repository.GetGroupAmPolicyData(context.TODO(), intGroupId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataGroupsIntGroupIdAmDataGetProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
+}
+
+func HandlePolicyDataGroupsIntGroupIdAmDataPut(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataGroupsIntGroupIdAmDataPut")
+
+	intGroupId := request.Params["intGroupId"]
+	amPolicyData := request.Body.(models.AmPolicyData)
+
+	created, problemDetails := PolicyDataGroupsIntGroupIdAmDataPutProcedure(intGroupId, amPolicyData)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	if created {
+		return httpwrapper.NewResponse(http.StatusCreated, nil, amPolicyData)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+// PolicyDataGroupsIntGroupIdAmDataPutProcedure replaces the AM policy data
+// that applies to every member of the group. It reports whether the data was
+// created.
+func PolicyDataGroupsIntGroupIdAmDataPutProcedure(intGroupId string,
+	amPolicyData models.AmPolicyData,
+) (bool, *models.ProblemDetails) {
+	created, err := putGroupPolicyData(repository.GroupAmPolicyDataCollName, intGroupId,
+		util.ToBsonM(amPolicyData))
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataGroupsIntGroupIdAmDataPutProcedure err: %+v", err)
+		return false, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	return created, nil
+}
+
+func HandlePolicyDataGroupsIntGroupIdAmDataDelete(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataGroupsIntGroupIdAmDataDelete")
+
+	intGroupId := request.Params["intGroupId"]
+
+	problemDetails := deleteGroupPolicyData(repository.GroupAmPolicyDataCollName, intGroupId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func HandlePolicyDataGroupsIntGroupIdSmDataGet(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataGroupsIntGroupIdSmDataGet")
+
+	intGroupId := request.Params["intGroupId"]
+
+	response, problemDetails := PolicyDataGroupsIntGroupIdSmDataGetProcedure(intGroupId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+func PolicyDataGroupsIntGroupIdSmDataGetProcedure(intGroupId string) (*models.SmPolicyData,
+	*models.ProblemDetails,
+) {
+	data, err := repository.GetGroupSmPolicyData(context.TODO(), intGroupId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataGroupsIntGroupIdSmDataGetProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if data == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return data, nil
+}
+
+func HandlePolicyDataGroupsIntGroupIdSmDataPut(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataGroupsIntGroupIdSmDataPut")
+
+	intGroupId := request.Params["intGroupId"]
+	smPolicyData := request.Body.(models.SmPolicyData)
+
+	created, problemDetails := PolicyDataGroupsIntGroupIdSmDataPutProcedure(intGroupId, smPolicyData)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	if created {
+		return httpwrapper.NewResponse(http.StatusCreated, nil, smPolicyData)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+// PolicyDataGroupsIntGroupIdSmDataPutProcedure replaces the SM policy data
+// that applies to every member of the group. Usage monitoring data is kept per
+// UE, so the group data cannot hold any. It reports whether the data was
+// created.
+func PolicyDataGroupsIntGroupIdSmDataPutProcedure(intGroupId string,
+	smPolicyData models.SmPolicyData,
+) (bool, *models.ProblemDetails) {
+	if len(smPolicyData.UmData) != 0 {
+		return false, util.ProblemDetailsMalformedReqSyntax("umData is not allowed in group policy data")
+	}
+
+	created, err := putGroupPolicyData(repository.GroupSmPolicyDataCollName, intGroupId,
+		escapeSmPolicyDnnKeys(smPolicyData))
+	if err != nil {
+		logger.DataRepoLog.Errorf("PolicyDataGroupsIntGroupIdSmDataPutProcedure err: %+v", err)
+		return false, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	return created, nil
+}
+
+func HandlePolicyDataGroupsIntGroupIdSmDataDelete(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PolicyDataGroupsIntGroupIdSmDataDelete")
+
+	intGroupId := request.Params["intGroupId"]
+
+	problemDetails := deleteGroupPolicyData(repository.GroupSmPolicyDataCollName, intGroupId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
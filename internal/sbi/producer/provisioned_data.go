@@ -0,0 +1,478 @@
+package producer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/identity"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/repository"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/httpwrapper"
+	"github.com/free5gc/util/mongoapi"
+)
+
+// provisionedDataSet describes a subscription data set that is written as a
+// whole. It is stored one document per UE or, when perPlmn is set, per UE and
+// serving PLMN. The sm-data set is a list stored one document per S-NSSAI.
+type provisionedDataSet struct {
+	name     string
+	collName string
+	perPlmn  bool
+	list     bool
+	// model returns a pointer to the model the data set must decode as
+	model func() interface{}
+	// derive, when set, updates the data derived from the stored data set of
+	// ueId after it was written
+	derive func(ctx context.Context, ueId string) error
+}
+
+var (
+	amDataSet = &provisionedDataSet{
+		name:     "am-data",
+		collName: repository.AmDataCollName,
+		perPlmn:  true,
+		model:    func() interface{} { return &models.AccessAndMobilitySubscriptionData{} },
+		derive:   identity.RecordAmData,
+	}
+	smfSelectDataSet = &provisionedDataSet{
+		name:     "smf-selection-subscription-data",
+		collName: repository.SmfSelDataCollName,
+		perPlmn:  true,
+		model:    func() interface{} { return &models.SmfSelectionSubscriptionData{} },
+	}
+	smDataSet = &provisionedDataSet{
+		name:     "sm-data",
+		collName: repository.SmDataCollName,
+		perPlmn:  true,
+		list:     true,
+		model:    func() interface{} { return &[]models.SessionManagementSubscriptionData{} },
+	}
+	smsDataSet = &provisionedDataSet{
+		name:     "sms-data",
+		collName: repository.SmsDataCollName,
+		perPlmn:  true,
+		model:    func() interface{} { return &models.SmsSubscriptionData{} },
+	}
+	smsMngDataSet = &provisionedDataSet{
+		name:     "sms-mng-data",
+		collName: repository.SmsMngDataCollName,
+		perPlmn:  true,
+		model:    func() interface{} { return &models.SmsManagementSubscriptionData{} },
+	}
+	traceDataSet = &provisionedDataSet{
+		name:     "trace-data",
+		collName: repository.TraceDataCollName,
+		perPlmn:  true,
+		model:    func() interface{} { return &models.TraceData{} },
+	}
+	authSubsDataSet = &provisionedDataSet{
+		name:     "authentication-subscription",
+		collName: repository.AuthSubsCollName,
+		model:    func() interface{} { return &models.AuthenticationSubscription{} },
+	}
+)
+
+func (d *provisionedDataSet) filter(ueId string, servingPlmnId string) bson.M {
+	if d.perPlmn {
+		return bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
+	}
+	return bson.M{"ueId": ueId}
+}
+
+// decode checks raw against the model of the data set, rejecting unknown
+// attributes, and returns it as a map, or a list of maps for a list data set.
+func (d *provisionedDataSet) decode(raw []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	v := d.model()
+	if err := decoder.Decode(v); err != nil {
+		return nil, err
+	}
+	return d.normalize(v)
+}
+
+// normalize converts a decoded model to its stored representation.
+func (d *provisionedDataSet) normalize(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if d.list {
+		var list []map[string]interface{}
+		if err = json.Unmarshal(raw, &list); err != nil {
+			return nil, err
+		}
+		if list == nil {
+			list = []map[string]interface{}{}
+		}
+		return list, nil
+	}
+	data := map[string]interface{}{}
+	if err = json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// load returns the stored data set in its Nudr representation, or nil when
+// there is none.
+func (d *provisionedDataSet) load(filter bson.M) (interface{}, error) {
+	if !d.list {
+		data, err := mongoapi.RestfulAPIGetOne(d.collName, filter)
+		if err != nil || len(data) == 0 {
+			return nil, err
+		}
+		for k := range filter {
+			delete(data, k)
+		}
+		return data, nil
+	}
+
+	list, err := mongoapi.RestfulAPIGetMany(d.collName, filter)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	for _, data := range list {
+		for k := range filter {
+			delete(data, k)
+		}
+		if dnnConfigurations, ok := data["dnnConfigurations"].(map[string]interface{}); ok {
+			data["dnnConfigurations"] = convertDnnKeys(dnnConfigurations, util.UnescapeDnn)
+		}
+	}
+	return list, nil
+}
+
+// store replaces the stored data set with value.
+func (d *provisionedDataSet) store(ctx context.Context, filter bson.M, value interface{}) error {
+	if !d.list {
+		putData := bson.M{}
+		for k, v := range value.(map[string]interface{}) {
+			putData[k] = v
+		}
+		for k, v := range filter {
+			putData[k] = v
+		}
+		_, err := database.ReplaceOne(ctx, d.collName, filter, putData)
+		return err
+	}
+
+	if _, err := database.DeleteMany(ctx, d.collName, filter); err != nil {
+		return err
+	}
+	list := value.([]map[string]interface{})
+	postDataArray := make([]interface{}, 0, len(list))
+	for _, data := range list {
+		postData := bson.M{}
+		for k, v := range data {
+			postData[k] = v
+		}
+		if dnnConfigurations, ok := data["dnnConfigurations"].(map[string]interface{}); ok {
+			postData["dnnConfigurations"] = convertDnnKeys(dnnConfigurations, util.EscapeDnn)
+		}
+		for k, v := range filter {
+			postData[k] = v
+		}
+		postDataArray = append(postDataArray, postData)
+	}
+	return database.InsertMany(ctx, d.collName, postDataArray)
+}
+
+// write stores value in place of the data set of ueId, or removes the data set
+// when value is nil, and updates the data derived from it, in one transaction
+// when the database supports it.
+func (d *provisionedDataSet) write(ueId string, filter bson.M, value interface{}) error {
+	return database.WithTransaction(func(ctx context.Context) error {
+		var err error
+		if value == nil {
+			_, err = database.DeleteMany(ctx, d.collName, filter)
+		} else {
+			err = d.store(ctx, filter, value)
+		}
+		if err != nil || d.derive == nil {
+			return err
+		}
+		return d.derive(ctx, ueId)
+	})
+}
+
+// historyRecorder records the change of the data set. The documents of a list
+// data set are recorded together as one image of the list.
+func (d *provisionedDataSet) historyRecorder(request *httpwrapper.Request, ueId string,
+	filter bson.M,
+) *historyRecorder {
+	if d.list {
+		return newListHistoryRecorder(request, d.collName, ueId, filter)
+	}
+	return newHistoryRecorder(request, d.collName, ueId, filter)
+}
+
+// notifyValue returns value, a stored data set, without the attributes that
+// hold key material, which are not sent in data change notifications.
+func (d *provisionedDataSet) notifyValue(value interface{}) interface{} {
+	if doc, ok := value.(map[string]interface{}); ok {
+		return repository.WithoutSecrets(d.collName, doc)
+	}
+	return value
+}
+
+func convertDnnKeys(m map[string]interface{}, conv func(string) string) map[string]interface{} {
+	converted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		converted[conv(k)] = v
+	}
+	return converted
+}
+
+func requestResourceUri(request *httpwrapper.Request) string {
+	if request.URL == nil {
+		return ""
+	}
+	return udr_context.UDR_Self().GetIPv4Uri() + request.URL.Path
+}
+
+// createProvisionedDataProcedure stores body, a model of the data set, in
+// place of the stored data set. It returns whether the data set was created.
+func createProvisionedDataProcedure(request *httpwrapper.Request, d *provisionedDataSet,
+	body interface{},
+) (interface{}, bool, *models.ProblemDetails) {
+	ueId := request.Params["ueId"]
+	filter := d.filter(ueId, request.Params["servingPlmnId"])
+
+	value, err := d.normalize(body)
+	if err != nil {
+		return nil, false, util.ProblemDetailsMalformedReqSyntax(err.Error())
+	}
+	origValue, err := d.load(filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("create %s err: %+v", d.name, err)
+		return nil, false, util.ProblemDetailsSystemFailure(err.Error())
+	}
+
+	history := d.historyRecorder(request, ueId, filter)
+	if err = d.write(ueId, filter, value); err != nil {
+		logger.DataRepoLog.Errorf("create %s err: %+v", d.name, err)
+		return nil, false, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	history.record()
+
+	op := models.PatchOperation_REPLACE
+	if origValue == nil {
+		op = models.PatchOperation_ADD
+	}
+	PreHandleOnDataChangeNotify(ueId, requestResourceUri(request),
+		[]models.PatchItem{{Op: op, Path: ""}}, d.notifyValue(origValue), d.notifyValue(value))
+	return value, origValue == nil, nil
+}
+
+// modifyProvisionedDataProcedure applies the JSON patch patchItems to the
+// stored data set, which must still match the model afterwards.
+func modifyProvisionedDataProcedure(request *httpwrapper.Request, d *provisionedDataSet,
+	patchItems []models.PatchItem,
+) *models.ProblemDetails {
+	ueId := request.Params["ueId"]
+	filter := d.filter(ueId, request.Params["servingPlmnId"])
+
+	origValue, err := d.load(filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("modify %s err: %+v", d.name, err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if origValue == nil {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+
+	original, err := json.Marshal(origValue)
+	if err != nil {
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	patchJSON, err := json.Marshal(patchItems)
+	if err != nil {
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return util.ProblemDetailsMalformedReqSyntax(err.Error())
+	}
+	modified, err := patch.Apply(original)
+	if err != nil {
+		return util.ProblemDetailsModifyNotAllowed(err.Error())
+	}
+	value, err := d.decode(modified)
+	if err != nil {
+		return util.ProblemDetailsModifyNotAllowed(fmt.Sprintf("patched %s is invalid: %+v", d.name, err))
+	}
+
+	history := d.historyRecorder(request, ueId, filter)
+	if err = d.write(ueId, filter, value); err != nil {
+		logger.DataRepoLog.Errorf("modify %s err: %+v", d.name, err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	history.record()
+
+	PreHandleOnDataChangeNotify(ueId, requestResourceUri(request), patchItems, d.notifyValue(origValue),
+		d.notifyValue(value))
+	return nil
+}
+
+func deleteProvisionedDataProcedure(request *httpwrapper.Request, d *provisionedDataSet) *models.ProblemDetails {
+	ueId := request.Params["ueId"]
+	filter := d.filter(ueId, request.Params["servingPlmnId"])
+
+	origValue, err := d.load(filter)
+	if err != nil {
+		logger.DataRepoLog.Errorf("delete %s err: %+v", d.name, err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if origValue == nil {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+
+	history := d.historyRecorder(request, ueId, filter)
+	if err = d.write(ueId, filter, nil); err != nil {
+		logger.DataRepoLog.Errorf("delete %s err: %+v", d.name, err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	history.record()
+
+	PreHandleOnDataChangeNotify(ueId, requestResourceUri(request),
+		[]models.PatchItem{{Op: models.PatchOperation_REMOVE, Path: ""}}, d.notifyValue(origValue), nil)
+	return nil
+}
+
+func handleCreateProvisionedData(request *httpwrapper.Request, d *provisionedDataSet) *httpwrapper.Response {
+	response, created, problemDetails := createProvisionedDataProcedure(request, d, request.Body)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	if created {
+		return httpwrapper.NewResponse(http.StatusCreated, nil, response)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func handleModifyProvisionedData(request *httpwrapper.Request, d *provisionedDataSet) *httpwrapper.Response {
+	problemDetails := modifyProvisionedDataProcedure(request, d, request.Body.([]models.PatchItem))
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func handleDeleteProvisionedData(request *httpwrapper.Request, d *provisionedDataSet) *httpwrapper.Response {
+	problemDetails := deleteProvisionedDataProcedure(request, d)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+func HandleCreateAmData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle CreateAmData")
+	return handleCreateProvisionedData(request, amDataSet)
+}
+
+func HandleModifyAmData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ModifyAmData")
+	return handleModifyProvisionedData(request, amDataSet)
+}
+
+func HandleDeleteAmData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle DeleteAmData")
+	return handleDeleteProvisionedData(request, amDataSet)
+}
+
+func HandleCreateSmfSelectData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle CreateSmfSelectData")
+	return handleCreateProvisionedData(request, smfSelectDataSet)
+}
+
+func HandleModifySmfSelectData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ModifySmfSelectData")
+	return handleModifyProvisionedData(request, smfSelectDataSet)
+}
+
+func HandleDeleteSmfSelectData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle DeleteSmfSelectData")
+	return handleDeleteProvisionedData(request, smfSelectDataSet)
+}
+
+func HandleCreateSmData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle CreateSmData")
+	return handleCreateProvisionedData(request, smDataSet)
+}
+
+func HandleModifySmData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ModifySmData")
+	return handleModifyProvisionedData(request, smDataSet)
+}
+
+func HandleDeleteSmData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle DeleteSmData")
+	return handleDeleteProvisionedData(request, smDataSet)
+}
+
+func HandleCreateSmsData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle CreateSmsData")
+	return handleCreateProvisionedData(request, smsDataSet)
+}
+
+func HandleModifySmsData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ModifySmsData")
+	return handleModifyProvisionedData(request, smsDataSet)
+}
+
+func HandleDeleteSmsData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle DeleteSmsData")
+	return handleDeleteProvisionedData(request, smsDataSet)
+}
+
+func HandleCreateSmsMngData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle CreateSmsMngData")
+	return handleCreateProvisionedData(request, smsMngDataSet)
+}
+
+func HandleModifySmsMngData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ModifySmsMngData")
+	return handleModifyProvisionedData(request, smsMngDataSet)
+}
+
+func HandleDeleteSmsMngData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle DeleteSmsMngData")
+	return handleDeleteProvisionedData(request, smsMngDataSet)
+}
+
+func HandleCreateTraceData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle CreateTraceData")
+	return handleCreateProvisionedData(request, traceDataSet)
+}
+
+func HandleModifyTraceData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle ModifyTraceData")
+	return handleModifyProvisionedData(request, traceDataSet)
+}
+
+func HandleDeleteTraceData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle DeleteTraceData")
+	return handleDeleteProvisionedData(request, traceDataSet)
+}
+
+func HandleCreateAuthSubsData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle CreateAuthSubsData")
+	return handleCreateProvisionedData(request, authSubsDataSet)
+}
+
+func HandleDeleteAuthSubsData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle DeleteAuthSubsData")
+	return handleDeleteProvisionedData(request, authSubsDataSet)
+}
@@ -0,0 +1,284 @@
+package producer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/repository"
+	"github.com/free5gc/udr/internal/sbi/producer/callback"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+// sharedDataNotifyWorkers bounds the goroutines sending the notifications of a
+// shared data change, which may concern many UEs.
+const sharedDataNotifyWorkers = 8
+
+// notifySharedDataChange notifies the subscribers of every UE whose data
+// refers to the shared data sharedDataId of its change.
+func notifySharedDataChange(request *httpwrapper.Request, sharedDataId string, patchItems []models.PatchItem,
+	origValue interface{}, newValue interface{},
+) {
+	// The subscriptions are collected in one pass, by UE
+	udrSelf := udr_context.UDR_Self()
+	subscriptionsOf := make(map[string][]*models.SubscriptionDataSubscriptions)
+	udrSelf.SubscriptionDataSubscriptionsMtx.RLock()
+	for _, subscriptionDataSubscription := range udrSelf.SubscriptionDataSubscriptions {
+		ueId := subscriptionDataSubscription.UeId
+		subscriptionsOf[ueId] = append(subscriptionsOf[ueId], subscriptionDataSubscription)
+	}
+	udrSelf.SubscriptionDataSubscriptionsMtx.RUnlock()
+	if len(subscriptionsOf) == 0 {
+		return
+	}
+
+	ueIds, err := repository.GetSharedDataUeIds(context.TODO(), sharedDataId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("notifySharedDataChange err: %+v", err)
+		return
+	}
+	pending := make(chan string, len(ueIds))
+	for _, ueId := range ueIds {
+		if len(subscriptionsOf[ueId]) != 0 {
+			pending <- ueId
+		}
+	}
+	close(pending)
+
+	notifyItems := dataChangeNotifyItems(requestResourceUri(request), patchItems, origValue, newValue)
+	workers := sharedDataNotifyWorkers
+	if len(pending) < workers {
+		workers = len(pending)
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for ueId := range pending {
+				callback.SendOnDataChangeNotifyTo(subscriptionsOf[ueId], ueId, notifyItems)
+			}
+		}()
+	}
+}
+
+// storeSharedData replaces the stored shared data with sharedData, escaping
+// the keys of its DNN configurations.
+func storeSharedData(ctx context.Context, sharedData *models.SharedData) error {
+	putData := util.ToBsonM(sharedData)
+	if dnnConfigurations, ok := putData["sharedDnnConfigurations"].(map[string]interface{}); ok {
+		putData["sharedDnnConfigurations"] = convertDnnKeys(dnnConfigurations, util.EscapeDnn)
+	}
+	_, err := database.ReplaceOne(ctx, repository.SharedDataCollName,
+		repository.SharedDataFilter(sharedData.SharedDataId), putData)
+	return err
+}
+
+func HandleGetIndividualSharedData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle GetIndividualSharedData")
+
+	sharedDataId := request.Params["sharedDataId"]
+
+	response, problemDetails := GetIndividualSharedDataProcedure(sharedDataId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+func GetIndividualSharedDataProcedure(sharedDataId string) (*models.SharedData, *models.ProblemDetails) {
+	sharedData, err := repository.GetSharedData(context.TODO(), sharedDataId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("GetIndividualSharedDataProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if sharedData == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return sharedData, nil
+}
+
+func HandlePutSharedData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PutSharedData")
+
+	sharedDataId := request.Params["sharedDataId"]
+	sharedData := request.Body.(models.SharedData)
+
+	created, problemDetails := PutSharedDataProcedure(request, sharedDataId, &sharedData)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	if created {
+		return httpwrapper.NewResponse(http.StatusCreated, nil, sharedData)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+// PutSharedDataProcedure replaces the shared data sharedDataId and notifies the
+// subscribers of the UEs that refer to it. It reports whether the shared data
+// was created.
+func PutSharedDataProcedure(request *httpwrapper.Request, sharedDataId string,
+	sharedData *models.SharedData,
+) (bool, *models.ProblemDetails) {
+	if sharedData.SharedDataId == "" {
+		sharedData.SharedDataId = sharedDataId
+	} else if sharedData.SharedDataId != sharedDataId {
+		return false, util.ProblemDetailsMalformedReqSyntax("sharedDataId does not match the URI")
+	}
+
+	history := newSharedDataHistoryRecorder(request, sharedDataId)
+	var orig *models.SharedData
+	err := database.WithTransaction(func(ctx context.Context) error {
+		var err error
+		if orig, err = repository.GetSharedData(ctx, sharedDataId); err != nil {
+			return err
+		}
+		return storeSharedData(ctx, sharedData)
+	})
+	if err != nil {
+		logger.DataRepoLog.Errorf("PutSharedDataProcedure err: %+v", err)
+		return false, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	history.record()
+
+	var origValue interface{}
+	op := models.PatchOperation_ADD
+	if orig != nil {
+		origValue = orig
+		op = models.PatchOperation_REPLACE
+	}
+	notifySharedDataChange(request, sharedDataId, []models.PatchItem{{Op: op, Path: ""}}, origValue, sharedData)
+	return orig == nil, nil
+}
+
+func HandlePatchSharedData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle PatchSharedData")
+
+	sharedDataId := request.Params["sharedDataId"]
+	patchItems := request.Body.([]models.PatchItem)
+
+	problemDetails := PatchSharedDataProcedure(request, sharedDataId, patchItems)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+// PatchSharedDataProcedure applies the JSON patch patchItems to the shared
+// data sharedDataId, which must still be valid shared data with the same
+// identifier afterwards, and notifies the subscribers of the UEs that refer to
+// it.
+func PatchSharedDataProcedure(request *httpwrapper.Request, sharedDataId string,
+	patchItems []models.PatchItem,
+) *models.ProblemDetails {
+	history := newSharedDataHistoryRecorder(request, sharedDataId)
+	var orig *models.SharedData
+	var sharedData models.SharedData
+	var problemDetails *models.ProblemDetails
+	err := database.WithTransaction(func(ctx context.Context) error {
+		var err error
+		if orig, err = repository.GetSharedData(ctx, sharedDataId); err != nil {
+			return err
+		}
+		// A rejected patch is reported once the transaction ends, nothing
+		// being written before
+		sharedData, problemDetails = patchSharedData(orig, sharedDataId, patchItems)
+		if problemDetails != nil {
+			return nil
+		}
+		return storeSharedData(ctx, &sharedData)
+	})
+	if err != nil {
+		logger.DataRepoLog.Errorf("PatchSharedDataProcedure err: %+v", err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if problemDetails != nil {
+		return problemDetails
+	}
+	history.record()
+
+	notifySharedDataChange(request, sharedDataId, patchItems, orig, &sharedData)
+	return nil
+}
+
+// patchSharedData returns orig, the shared data sharedDataId, patched with
+// the JSON patch patchItems.
+func patchSharedData(orig *models.SharedData, sharedDataId string,
+	patchItems []models.PatchItem,
+) (models.SharedData, *models.ProblemDetails) {
+	var sharedData models.SharedData
+	if orig == nil {
+		return sharedData, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+
+	original, err := json.Marshal(orig)
+	if err != nil {
+		return sharedData, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	patchJSON, err := json.Marshal(patchItems)
+	if err != nil {
+		return sharedData, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return sharedData, util.ProblemDetailsMalformedReqSyntax(err.Error())
+	}
+	modified, err := patch.Apply(original)
+	if err != nil {
+		return sharedData, util.ProblemDetailsModifyNotAllowed(err.Error())
+	}
+	decoder := json.NewDecoder(bytes.NewReader(modified))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&sharedData); err != nil {
+		return sharedData, util.ProblemDetailsModifyNotAllowed(fmt.Sprintf("patched shared data is invalid: %+v", err))
+	}
+	if sharedData.SharedDataId != sharedDataId {
+		return sharedData, util.ProblemDetailsModifyNotAllowed("sharedDataId cannot be modified")
+	}
+	return sharedData, nil
+}
+
+func HandleDeleteSharedData(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle DeleteSharedData")
+
+	sharedDataId := request.Params["sharedDataId"]
+
+	problemDetails := DeleteSharedDataProcedure(request, sharedDataId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+// DeleteSharedDataProcedure deletes the shared data sharedDataId and notifies
+// the subscribers of the UEs that still refer to it.
+func DeleteSharedDataProcedure(request *httpwrapper.Request, sharedDataId string) *models.ProblemDetails {
+	history := newSharedDataHistoryRecorder(request, sharedDataId)
+	var orig *models.SharedData
+	err := database.WithTransaction(func(ctx context.Context) error {
+		var err error
+		if orig, err = repository.GetSharedData(ctx, sharedDataId); err != nil || orig == nil {
+			return err
+		}
+		_, err = database.DeleteMany(ctx, repository.SharedDataCollName, repository.SharedDataFilter(sharedDataId))
+		return err
+	})
+	if err != nil {
+		logger.DataRepoLog.Errorf("DeleteSharedDataProcedure err: %+v", err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if orig == nil {
+		return util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	history.record()
+
+	notifySharedDataChange(request, sharedDataId,
+		[]models.PatchItem{{Op: models.PatchOperation_REMOVE, Path: ""}}, orig, nil)
+	return nil
+}
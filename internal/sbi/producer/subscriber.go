@@ -0,0 +1,141 @@
+package producer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/sbi/producer/callback"
+	"github.com/free5gc/udr/internal/subscriber"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+func HandleCreateSubscriber(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle CreateSubscriber")
+
+	s := request.Body.(subscriber.Subscriber)
+
+	problemDetails := CreateSubscriberProcedure(&s)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	headers := http.Header{}
+	headers.Set("Location", fmt.Sprintf("%s/subscribers/%s", udr_context.UDR_Self().GetAdminUri(), s.UeId))
+	return httpwrapper.NewResponse(http.StatusCreated, headers, nil)
+}
+
+func CreateSubscriberProcedure(s *subscriber.Subscriber) *models.ProblemDetails {
+	if err := s.Validate(); err != nil {
+		return util.ProblemDetailsMalformedReqSyntax(err.Error())
+	}
+	if err := subscriber.Create(s); err != nil {
+		if errors.Is(err, subscriber.ErrExists) {
+			return util.ProblemDetailsConflict(err.Error())
+		}
+		logger.DataRepoLog.Errorf("CreateSubscriberProcedure err: %+v", err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+	return nil
+}
+
+func HandleQuerySubscriber(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle QuerySubscriber")
+
+	ueId := request.Params["ueId"]
+
+	response, problemDetails := QuerySubscriberProcedure(ueId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+func QuerySubscriberProcedure(ueId string) (*subscriber.Subscriber, *models.ProblemDetails) {
+	exists, err := subscriber.Exists(ueId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QuerySubscriberProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if !exists {
+		return nil, util.ProblemDetailsNotFound("USER_NOT_FOUND")
+	}
+	s, err := subscriber.Load(ueId)
+	if err != nil {
+		logger.DataRepoLog.Errorf("QuerySubscriberProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	return s, nil
+}
+
+func HandleDeleteSubscriber(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle DeleteSubscriber")
+
+	ueId := request.Params["ueId"]
+
+	problemDetails := DeleteSubscriberProcedure(ueId)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusNoContent, nil, map[string]interface{}{})
+}
+
+// DeleteSubscriberProcedure removes all stored data of ueId together with the
+// EE, SDM and data change subscriptions of the UE. The data change subscribers
+// are notified of every removed resource, and the policy data subscribers of
+// the removed policy data.
+func DeleteSubscriberProcedure(ueId string) *models.ProblemDetails {
+	udrSelf := udr_context.UDR_Self()
+
+	removed, err := subscriber.Delete(ueId)
+	notFound := errors.Is(err, subscriber.ErrNotFound)
+	if err != nil && !notFound {
+		logger.DataRepoLog.Errorf("DeleteSubscriberProcedure err: %+v", err)
+		return util.ProblemDetailsSystemFailure(err.Error())
+	}
+
+	_, hadSubs := udrSelf.UESubsCollection.Load(ueId)
+	udrSelf.UESubsCollection.Delete(ueId)
+
+	var subscriptions []*models.SubscriptionDataSubscriptions
+	udrSelf.SubscriptionDataSubscriptionsMtx.Lock()
+	for subsId, subscriptionDataSubscription := range udrSelf.SubscriptionDataSubscriptions {
+		if subscriptionDataSubscription.UeId == ueId {
+			subscriptions = append(subscriptions, subscriptionDataSubscription)
+			delete(udrSelf.SubscriptionDataSubscriptions, subsId)
+		}
+	}
+	udrSelf.SubscriptionDataSubscriptionsMtx.Unlock()
+
+	if len(removed) != 0 && len(subscriptions) != 0 {
+		notifyItems := make([]models.NotifyItem, 0, len(removed))
+		for _, resource := range removed {
+			notifyItems = append(notifyItems, models.NotifyItem{
+				ResourceId: udrSelf.GetIPv4GroupUri(udr_context.NUDR_DR) + "/" + resource.Resource,
+				Changes: []models.ChangeItem{{
+					Op: models.ChangeType(models.PatchOperation_REMOVE),
+				}},
+			})
+		}
+		go callback.SendOnDataChangeNotifyTo(subscriptions, ueId, notifyItems)
+	}
+
+	var policyDataPaths []string
+	for _, resource := range removed {
+		if strings.HasPrefix(resource.Resource, "policy-data/") {
+			policyDataPaths = append(policyDataPaths, strings.TrimPrefix(resource.Resource, "policy-data/"))
+		}
+	}
+	var changes policyDataChanges
+	changes.remove(ueId, policyDataPaths...)
+	changes.send()
+
+	if notFound && !hadSubs && len(subscriptions) == 0 {
+		return util.ProblemDetailsNotFound("USER_NOT_FOUND")
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package producer
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/repository"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/httpwrapper"
+)
+
+// UsageMonIncrement is the body of the custom operation adding to the usage
+// monitoring counters of a limit. The attributes of AllowedUsage and UsedUsage
+// are added to the stored ones, so concurrent reports of the same limit do not
+// overwrite each other. Negative values subtract.
+type UsageMonIncrement struct {
+	AllowedUsage *models.UsageThreshold `json:"allowedUsage,omitempty"`
+	UsedUsage    *models.UsageThreshold `json:"usedUsage,omitempty"`
+	// UsedThreshold makes the UDR notify the policy data subscribers when the
+	// increment makes an attribute of the used usage reach the threshold set
+	// for it.
+	UsedThreshold *models.UsageThreshold `json:"usedThreshold,omitempty"`
+}
+
+func isZeroUsage(usage *models.UsageThreshold) bool {
+	return usage == nil || *usage == models.UsageThreshold{}
+}
+
+// usageCrossed reports whether adding inc to a counter made it reach threshold.
+func usageCrossed(after int64, inc int64, threshold int64) bool {
+	return threshold > 0 && after >= threshold && after-inc < threshold
+}
+
+// usedThresholdCrossed reports whether adding inc to the used usage made any
+// attribute of it reach the threshold set for it.
+func usedThresholdCrossed(used *models.UsageThreshold, inc *models.UsageThreshold,
+	threshold *models.UsageThreshold,
+) bool {
+	if used == nil || isZeroUsage(inc) || isZeroUsage(threshold) {
+		return false
+	}
+	return usageCrossed(int64(used.Duration), int64(inc.Duration), int64(threshold.Duration)) ||
+		usageCrossed(used.TotalVolume, inc.TotalVolume, threshold.TotalVolume) ||
+		usageCrossed(used.DownlinkVolume, inc.DownlinkVolume, threshold.DownlinkVolume) ||
+		usageCrossed(used.UplinkVolume, inc.UplinkVolume, threshold.UplinkVolume)
+}
+
+func HandleIncrementUsageMonCounters(request *httpwrapper.Request) *httpwrapper.Response {
+	logger.DataRepoLog.Infof("Handle IncrementUsageMonCounters")
+
+	ueId := request.Params["ueId"]
+	limitId := request.Params["limitId"]
+	increment := request.Body.(UsageMonIncrement)
+
+	response, problemDetails := IncrementUsageMonCountersProcedure(ueId, limitId, increment)
+	if problemDetails != nil {
+		return httpwrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return httpwrapper.NewResponse(http.StatusOK, nil, response)
+}
+
+// IncrementUsageMonCountersProcedure adds increment to the counters of the
+// usage monitoring data of limitId in a single database update and returns the
+// updated counters.
+func IncrementUsageMonCountersProcedure(ueId string, limitId string,
+	increment UsageMonIncrement,
+) (*repository.UsageMonCounters, *models.ProblemDetails) {
+	if isZeroUsage(increment.AllowedUsage) && isZeroUsage(increment.UsedUsage) {
+		return nil, util.ProblemDetailsMalformedReqSyntax("allowedUsage or usedUsage must be given")
+	}
+
+	counters, err := repository.IncrementUsageMonCounters(context.TODO(), ueId, limitId,
+		increment.AllowedUsage, increment.UsedUsage)
+	if err != nil {
+		logger.DataRepoLog.Errorf("IncrementUsageMonCountersProcedure err: %+v", err)
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if counters == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+
+	if usedThresholdCrossed(counters.UsedUsage, increment.UsedUsage, increment.UsedThreshold) {
+		usageMonId := counters.UsageMonId
+		if usageMonId == "" {
+			usageMonId = limitId
+		}
+		PreHandlePolicyDataChangeNotification(ueId, usageMonId, counters.UsageMonData)
+	}
+	return counters, nil
+}
@@ -0,0 +1,26 @@
+package producer
+
+import "testing"
+
+func TestUsageCrossed(t *testing.T) {
+	testCases := []struct {
+		name      string
+		after     int64
+		inc       int64
+		threshold int64
+		want      bool
+	}{
+		{"below threshold", 90, 10, 100, false},
+		{"reaches threshold", 100, 10, 100, true},
+		{"passes threshold", 120, 30, 100, true},
+		{"already past threshold", 150, 10, 100, false},
+		{"no increment at threshold", 100, 0, 100, false},
+		{"no threshold", 100, 10, 0, false},
+	}
+	for _, tc := range testCases {
+		if got := usageCrossed(tc.after, tc.inc, tc.threshold); got != tc.want {
+			t.Errorf("%s: usageCrossed(%d, %d, %d) = %v, want %v",
+				tc.name, tc.after, tc.inc, tc.threshold, got, tc.want)
+		}
+	}
+}
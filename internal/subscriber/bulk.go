@@ -0,0 +1,128 @@
+package subscriber
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	MODE_UPSERT        = "upsert"
+	MODE_SKIP_EXISTING = "skip-existing"
+)
+
+type ImportOptions struct {
+	// Mode is MODE_UPSERT to overwrite the data sets of stored UEs, or
+	// MODE_SKIP_EXISTING to leave UEs that have any stored data untouched.
+	Mode string
+	// DryRun validates the input without writing to the database.
+	DryRun bool
+}
+
+type ImportResult struct {
+	Imported int
+	Skipped  int
+	Failed   int
+}
+
+func CheckMode(mode string) error {
+	switch mode {
+	case MODE_UPSERT, MODE_SKIP_EXISTING:
+		return nil
+	default:
+		return fmt.Errorf("unknown mode %q, expected %s or %s", mode, MODE_UPSERT, MODE_SKIP_EXISTING)
+	}
+}
+
+// Import stores every record read from r, each in one transaction when the
+// database supports it. A record that cannot be read, validated or stored is
+// reported on report with its line number, and the import goes on with the
+// next record.
+func Import(r Reader, opts ImportOptions, report io.Writer) (ImportResult, error) {
+	var result ImportResult
+	if err := CheckMode(opts.Mode); err != nil {
+		return result, err
+	}
+
+	reportf := func(line int, ueId string, err error) {
+		result.Failed++
+		if ueId == "" {
+			fmt.Fprintf(report, "line %d: %v\n", line, err)
+		} else {
+			fmt.Fprintf(report, "line %d: %s: %v\n", line, ueId, err)
+		}
+	}
+
+	for {
+		line, s, err := r.Next()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			var recordErr *RecordError
+			if !errors.As(err, &recordErr) {
+				return result, err
+			}
+			reportf(line, "", recordErr.Err)
+			continue
+		}
+		if err := s.Validate(); err != nil {
+			reportf(line, s.UeId, err)
+			continue
+		}
+
+		if opts.DryRun {
+			if opts.Mode == MODE_SKIP_EXISTING {
+				exists, err := Exists(s.UeId)
+				if err != nil {
+					reportf(line, s.UeId, err)
+					continue
+				}
+				if exists {
+					result.Skipped++
+					continue
+				}
+			}
+			result.Imported++
+			continue
+		}
+
+		if opts.Mode == MODE_SKIP_EXISTING {
+			err = Create(s)
+		} else {
+			err = Store(s)
+		}
+		if errors.Is(err, ErrExists) {
+			result.Skipped++
+			continue
+		}
+		if err != nil {
+			reportf(line, s.UeId, err)
+			continue
+		}
+		result.Imported++
+	}
+}
+
+// Export writes the records of ueIds, or of every stored UE when ueIds is empty.
+func Export(w Writer, ueIds []string) (int, error) {
+	if len(ueIds) == 0 {
+		var err error
+		if ueIds, err = ListUeIds(); err != nil {
+			return 0, err
+		}
+	}
+
+	count := 0
+	for _, ueId := range ueIds {
+		s, err := Load(ueId)
+		if err != nil {
+			return count, err
+		}
+		if err := w.Write(s); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, w.Flush()
+}
@@ -0,0 +1,193 @@
+package subscriber
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+var csvHeader = []string{"ueId", "authenticationSubscription", "provisionedData", "policyData"}
+
+// RecordError reports a record that cannot be decoded. Reading may go on with
+// the next record.
+type RecordError struct {
+	Line int
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads records one by one. Next returns io.EOF after the last record,
+// a *RecordError for a record that cannot be decoded, and any other error when
+// the input cannot be read further.
+type Reader interface {
+	Next() (line int, s *Subscriber, err error)
+}
+
+type Writer interface {
+	Write(s *Subscriber) error
+	Flush() error
+}
+
+func NewReader(format string, r io.Reader) Reader {
+	if format == FORMAT_CSV {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		return &csvReader{reader: reader}
+	}
+	return &jsonlReader{reader: bufio.NewReader(r)}
+}
+
+func NewWriter(format string, w io.Writer) Writer {
+	if format == FORMAT_CSV {
+		return &csvWriter{writer: csv.NewWriter(w)}
+	}
+	return &jsonlWriter{writer: bufio.NewWriter(w)}
+}
+
+type jsonlReader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+func (r *jsonlReader) Next() (int, *Subscriber, error) {
+	for {
+		raw, err := r.reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(raw) == 0) {
+			return r.line, nil, err
+		}
+		r.line++
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+		s := &Subscriber{}
+		if err := json.Unmarshal(raw, s); err != nil {
+			return r.line, nil, &RecordError{Line: r.line, Err: err}
+		}
+		return r.line, s, nil
+	}
+}
+
+type jsonlWriter struct {
+	writer *bufio.Writer
+}
+
+func (w *jsonlWriter) Write(s *Subscriber) error {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if _, err := w.writer.Write(append(raw, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	line    int
+	columns map[string]int
+}
+
+func (r *csvReader) Next() (int, *Subscriber, error) {
+	if r.columns == nil {
+		header, err := r.reader.Read()
+		r.line++
+		if err != nil {
+			return r.line, nil, err
+		}
+		r.columns = make(map[string]int)
+		for i, name := range header {
+			r.columns[name] = i
+		}
+		if _, ok := r.columns["ueId"]; !ok {
+			return r.line, nil, fmt.Errorf("CSV header lacks the ueId column")
+		}
+	}
+
+	row, err := r.reader.Read()
+	if err == io.EOF {
+		return r.line, nil, err
+	}
+	r.line++
+	if err != nil {
+		if _, ok := err.(*csv.ParseError); ok {
+			return r.line, nil, &RecordError{Line: r.line, Err: err}
+		}
+		return r.line, nil, err
+	}
+
+	cell := func(name string) string {
+		if i, ok := r.columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	s := &Subscriber{UeId: cell("ueId")}
+	for name, v := range map[string]interface{}{
+		"authenticationSubscription": &s.AuthenticationSubscription,
+		"provisionedData":            &s.ProvisionedData,
+		"policyData":                 &s.PolicyData,
+	} {
+		if value := cell(name); value != "" {
+			if err := json.Unmarshal([]byte(value), v); err != nil {
+				return r.line, nil, &RecordError{Line: r.line, Err: fmt.Errorf("%s: %w", name, err)}
+			}
+		}
+	}
+	return r.line, s, nil
+}
+
+type csvWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func jsonCell(v interface{}, empty bool) (string, error) {
+	if empty {
+		return "", nil
+	}
+	raw, err := json.Marshal(v)
+	return string(raw), err
+}
+
+func (w *csvWriter) Write(s *Subscriber) error {
+	if !w.headerWritten {
+		if err := w.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+
+	row := []string{s.UeId, "", "", ""}
+	var err error
+	if row[1], err = jsonCell(s.AuthenticationSubscription, s.AuthenticationSubscription == nil); err != nil {
+		return err
+	}
+	if row[2], err = jsonCell(s.ProvisionedData, s.ProvisionedData == nil); err != nil {
+		return err
+	}
+	if row[3], err = jsonCell(s.PolicyData, s.PolicyData == nil); err != nil {
+		return err
+	}
+	return w.writer.Write(row)
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
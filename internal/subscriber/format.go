@@ -0,0 +1,148 @@
+// Package subscriber converts the data the UDR stores for a UE to and from a
+// self-contained per-UE record, for bulk provisioning and backup.
+//
+// A record holds:
+//
+//	{
+//	  "ueId": "imsi-208930000000001",
+//	  "authenticationSubscription": { AuthenticationSubscription },
+//	  "provisionedData": {
+//	    "<servingPlmnId>": {
+//	      "amData":           { AccessAndMobilitySubscriptionData },
+//	      "smfSelectionData": { SmfSelectionSubscriptionData },
+//	      "smData":           [ SessionManagementSubscriptionData, ... ],
+//	      "smsData":          { SmsSubscriptionData },
+//	      "smsMngData":       { SmsManagementSubscriptionData }
+//	    }
+//	  },
+//	  "policyData": {
+//	    "amPolicyData": { AmPolicyData },
+//	    "uePolicySet":  { UePolicySet },
+//	    "smPolicyData": { SmPolicyData }
+//	  }
+//	}
+//
+// Every data set is optional and is written in its Nudr representation, so DNN
+// keys are plain DNNs. In JSON Lines files every line is one record. CSV files
+// start with the header row "ueId,authenticationSubscription,provisionedData,
+// policyData" and every other row is one record whose cells hold the JSON
+// encoding of the corresponding attribute; empty cells are absent data sets.
+package subscriber
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/free5gc/openapi/models"
+)
+
+const (
+	FORMAT_JSONL = "jsonl"
+	FORMAT_CSV   = "csv"
+)
+
+type Subscriber struct {
+	UeId                       string                      `json:"ueId"`
+	AuthenticationSubscription map[string]interface{}      `json:"authenticationSubscription,omitempty"`
+	ProvisionedData            map[string]*ProvisionedData `json:"provisionedData,omitempty"`
+	PolicyData                 *PolicyData                 `json:"policyData,omitempty"`
+}
+
+// ProvisionedData holds the subscription data of one serving PLMN.
+type ProvisionedData struct {
+	AmData           map[string]interface{}   `json:"amData,omitempty"`
+	SmfSelectionData map[string]interface{}   `json:"smfSelectionData,omitempty"`
+	SmData           []map[string]interface{} `json:"smData,omitempty"`
+	SmsData          map[string]interface{}   `json:"smsData,omitempty"`
+	SmsMngData       map[string]interface{}   `json:"smsMngData,omitempty"`
+}
+
+type PolicyData struct {
+	AmPolicyData map[string]interface{} `json:"amPolicyData,omitempty"`
+	UePolicySet  map[string]interface{} `json:"uePolicySet,omitempty"`
+	SmPolicyData map[string]interface{} `json:"smPolicyData,omitempty"`
+}
+
+// FormatOf returns the format named by format, or guessed from the extension of
+// path when format is empty.
+func FormatOf(format string, path string) (string, error) {
+	if format == "" {
+		if strings.HasSuffix(strings.ToLower(path), ".csv") {
+			return FORMAT_CSV, nil
+		}
+		return FORMAT_JSONL, nil
+	}
+	switch format {
+	case FORMAT_JSONL, FORMAT_CSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, expected %s or %s", format, FORMAT_JSONL, FORMAT_CSV)
+	}
+}
+
+// checkDataSet makes sure data decodes as the model v points to.
+func checkDataSet(name string, data interface{}, v interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Validate checks the mandatory ueId and that every data set matches its model.
+func (s *Subscriber) Validate() error {
+	if s.UeId == "" {
+		return fmt.Errorf("missing ueId")
+	}
+	if s.AuthenticationSubscription != nil {
+		if err := checkDataSet("authenticationSubscription", s.AuthenticationSubscription,
+			&models.AuthenticationSubscription{}); err != nil {
+			return err
+		}
+	}
+	for servingPlmnId, provisionedData := range s.ProvisionedData {
+		if provisionedData == nil {
+			continue
+		}
+		prefix := "provisionedData." + servingPlmnId + "."
+		if err := checkDataSet(prefix+"amData", provisionedData.AmData,
+			&models.AccessAndMobilitySubscriptionData{}); err != nil {
+			return err
+		}
+		if err := checkDataSet(prefix+"smfSelectionData", provisionedData.SmfSelectionData,
+			&models.SmfSelectionSubscriptionData{}); err != nil {
+			return err
+		}
+		if err := checkDataSet(prefix+"smData", provisionedData.SmData,
+			&[]models.SessionManagementSubscriptionData{}); err != nil {
+			return err
+		}
+		if err := checkDataSet(prefix+"smsData", provisionedData.SmsData,
+			&models.SmsSubscriptionData{}); err != nil {
+			return err
+		}
+		if err := checkDataSet(prefix+"smsMngData", provisionedData.SmsMngData,
+			&models.SmsManagementSubscriptionData{}); err != nil {
+			return err
+		}
+	}
+	if policyData := s.PolicyData; policyData != nil {
+		if err := checkDataSet("policyData.amPolicyData", policyData.AmPolicyData,
+			&models.AmPolicyData{}); err != nil {
+			return err
+		}
+		if err := checkDataSet("policyData.uePolicySet", policyData.UePolicySet,
+			&models.UePolicySet{}); err != nil {
+			return err
+		}
+		if err := checkDataSet("policyData.smPolicyData", policyData.SmPolicyData,
+			&models.SmPolicyData{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
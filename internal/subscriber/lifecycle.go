@@ -0,0 +1,169 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/identity"
+	"github.com/free5gc/udr/internal/repository"
+)
+
+var (
+	ErrExists   = errors.New("subscriber already exists")
+	ErrNotFound = errors.New("subscriber not found")
+)
+
+// ueDataSet is a collection holding documents keyed to a UE. resource is the
+// Nudr resource path of its documents, in which {name} stands for the document
+// attribute name.
+type ueDataSet struct {
+	collName string
+	resource string
+}
+
+// ueDataSets lists every collection whose documents are removed together with
+// the subscriber.
+var ueDataSets = []ueDataSet{
+	{repository.AuthSubsCollName, "subscription-data/{ueId}/authentication-data/authentication-subscription"},
+	{repository.AuthStatusCollName,
+		"subscription-data/{ueId}/authentication-data/authentication-status"},
+	{repository.AmDataCollName, "subscription-data/{ueId}/{servingPlmnId}/provisioned-data/am-data"},
+	{repository.SmfSelDataCollName,
+		"subscription-data/{ueId}/{servingPlmnId}/provisioned-data/smf-selection-subscription-data"},
+	{repository.SmDataCollName, "subscription-data/{ueId}/{servingPlmnId}/provisioned-data/sm-data"},
+	{repository.SmsDataCollName, "subscription-data/{ueId}/{servingPlmnId}/provisioned-data/sms-data"},
+	{repository.SmsMngDataCollName, "subscription-data/{ueId}/{servingPlmnId}/provisioned-data/sms-mng-data"},
+	{repository.TraceDataCollName,
+		"subscription-data/{ueId}/{servingPlmnId}/provisioned-data/trace-data"},
+	{repository.Amf3gppAccessCollName, "subscription-data/{ueId}/context-data/amf-3gpp-access"},
+	{repository.AmfNon3gppAccessCollName, "subscription-data/{ueId}/context-data/amf-non-3gpp-access"},
+	{repository.SmfRegistrationsCollName,
+		"subscription-data/{ueId}/context-data/smf-registrations/{pduSessionId}"},
+	{repository.Smsf3gppAccessCollName, "subscription-data/{ueId}/context-data/smsf-3gpp-access"},
+	{repository.SmsfNon3gppAccessCollName, "subscription-data/{ueId}/context-data/smsf-non-3gpp-access"},
+	{repository.EeProfileDataCollName, "subscription-data/{ueId}/ee-profile-data"},
+	{repository.IdentityDataCollName, "subscription-data/{ueId}/identity-data"},
+	{repository.OdbDataCollName, "subscription-data/{ueId}/operator-determined-barring-data"},
+	{repository.OperatorSpecDataCollName, "subscription-data/{ueId}/operator-specific-data"},
+	{repository.PpDataCollName, "subscription-data/{ueId}/pp-data"},
+	{repository.SorDataCollName, "subscription-data/{ueId}/ue-update-confirmation-data/sor-data"},
+	{repository.AmPolicyDataCollName, "policy-data/ues/{ueId}/am-data"},
+	{repository.UePolicySetCollName, "policy-data/ues/{ueId}/ue-policy-set"},
+	{repository.SmPolicyDataCollName, "policy-data/ues/{ueId}/sm-data"},
+	{repository.UsageMonDataCollName, "policy-data/ues/{ueId}/sm-data/{usageMonId}"},
+	{repository.PolicyOperatorSpecDataCollName, "policy-data/ues/{ueId}/operator-specific-data"},
+}
+
+var resourceAttrPattern = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+
+func (d *ueDataSet) resourceOf(doc map[string]interface{}) string {
+	return resourceAttrPattern.ReplaceAllStringFunc(d.resource, func(attr string) string {
+		if value, ok := doc[attr[1:len(attr)-1]]; ok {
+			return fmt.Sprint(value)
+		}
+		return attr
+	})
+}
+
+// RemovedResource is a resource removed by Delete, with its path relative to
+// the Nudr_DataRepository API root.
+type RemovedResource struct {
+	CollName string
+	Resource string
+}
+
+// Create stores a subscriber that has no stored data yet. The check and the
+// writes are done in one transaction when the database supports it.
+func Create(s *Subscriber) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	escapeDnnKeys(s)
+	return database.WithTransaction(func(ctx context.Context) error {
+		exists, err := exists(ctx, s.UeId)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrExists
+		}
+		return store(ctx, s)
+	})
+}
+
+// Delete removes every document keyed to ueId and the UE from the groups it is
+// a member of, in one transaction when the database supports it, and returns
+// the removed resources. It returns
+// ErrNotFound when nothing was stored for ueId.
+func Delete(ueId string) ([]RemovedResource, error) {
+	var removed []RemovedResource
+	err := database.WithTransaction(func(ctx context.Context) error {
+		var err error
+		removed, err = deleteAll(ctx, ueId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(removed) == 0 {
+		return nil, ErrNotFound
+	}
+	return removed, nil
+}
+
+func deleteAll(ctx context.Context, ueId string) ([]RemovedResource, error) {
+	// The GPSIs are looked up before the data that records them is removed
+	gpsis, err := identity.Gpsis(ctx, ueId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"ueId": ueId}
+	var removed []RemovedResource
+	for i := range ueDataSets {
+		d := &ueDataSets[i]
+		docs, err := database.GetMany(ctx, d.collName, filter)
+		if err != nil {
+			return nil, err
+		}
+		if len(docs) == 0 {
+			continue
+		}
+		if _, err = database.DeleteMany(ctx, d.collName, filter); err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, doc := range docs {
+			resource := d.resourceOf(doc)
+			if !seen[resource] {
+				seen[resource] = true
+				removed = append(removed, RemovedResource{CollName: d.collName, Resource: resource})
+			}
+		}
+	}
+	if err = leaveGroups(ctx, ueId, gpsis); err != nil {
+		return nil, err
+	}
+	if err = identity.Forget(ctx, ueId); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// leaveGroups removes the UE known by supi and gpsis from the member lists of
+// every group.
+func leaveGroups(ctx context.Context, supi string, gpsis []string) error {
+	member := bson.M{"supi": supi}
+	filter := bson.M{"ueIdList.supi": supi}
+	if len(gpsis) != 0 {
+		member = bson.M{"$or": []bson.M{member, {"gpsiList": bson.M{"$in": gpsis}}}}
+		filter = bson.M{"$or": []bson.M{filter, {"ueIdList.gpsiList": bson.M{"$in": gpsis}}}}
+	}
+	_, err := database.Pull(ctx, repository.GroupIdentifiersCollName, filter, bson.M{"ueIdList": member})
+	return err
+}
@@ -0,0 +1,307 @@
+package subscriber
+
+import (
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/identity"
+	"github.com/free5gc/udr/internal/repository"
+	"github.com/free5gc/udr/internal/util"
+)
+
+var ueCollNames = []string{
+	repository.AuthSubsCollName,
+	repository.AmDataCollName,
+	repository.SmfSelDataCollName,
+	repository.SmDataCollName,
+	repository.SmsDataCollName,
+	repository.SmsMngDataCollName,
+	repository.AmPolicyDataCollName,
+	repository.UePolicySetCollName,
+	repository.SmPolicyDataCollName,
+}
+
+var plmnCollNames = []string{
+	repository.AmDataCollName,
+	repository.SmfSelDataCollName,
+	repository.SmDataCollName,
+	repository.SmsDataCollName,
+	repository.SmsMngDataCollName,
+}
+
+// ListUeIds returns every UE that has data in one of the collections covered by
+// the record format, sorted.
+func ListUeIds() ([]string, error) {
+	ueIdSet := make(map[string]bool)
+	for _, collName := range ueCollNames {
+		values, err := database.Distinct(context.TODO(), collName, "ueId", bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			if ueId, ok := value.(string); ok {
+				ueIdSet[ueId] = true
+			}
+		}
+	}
+	ueIds := make([]string, 0, len(ueIdSet))
+	for ueId := range ueIdSet {
+		ueIds = append(ueIds, ueId)
+	}
+	sort.Strings(ueIds)
+	return ueIds, nil
+}
+
+// Exists reports whether any data of ueId is stored.
+func Exists(ueId string) (bool, error) {
+	return exists(context.TODO(), ueId)
+}
+
+func exists(ctx context.Context, ueId string) (bool, error) {
+	for _, collName := range ueCollNames {
+		count, err := database.Count(ctx, collName, bson.M{"ueId": ueId})
+		if err != nil {
+			return false, err
+		}
+		if count != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// stripKeys removes the lookup attributes the UDR adds to stored documents.
+func stripKeys(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+	delete(data, "ueId")
+	delete(data, "servingPlmnId")
+	return data
+}
+
+func getOne(ctx context.Context, collName string, filter bson.M) (map[string]interface{}, error) {
+	data, err := database.GetOne(ctx, collName, filter)
+	if err != nil {
+		return nil, err
+	}
+	return stripKeys(data), nil
+}
+
+func renameDnnKeys(m map[string]interface{}, conv func(string) string) map[string]interface{} {
+	renamed := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		renamed[conv(k)] = v
+	}
+	return renamed
+}
+
+func convertSmDataDnnKeys(smData map[string]interface{}, conv func(string) string) {
+	if dnnConfigurations, ok := smData["dnnConfigurations"].(map[string]interface{}); ok {
+		smData["dnnConfigurations"] = renameDnnKeys(dnnConfigurations, conv)
+	}
+}
+
+func convertSmPolicyDataDnnKeys(smPolicyData map[string]interface{}, conv func(string) string) {
+	smPolicySnssaiData, ok := smPolicyData["smPolicySnssaiData"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, value := range smPolicySnssaiData {
+		snssaiData, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if dnnData, ok := snssaiData["smPolicyDnnData"].(map[string]interface{}); ok {
+			snssaiData["smPolicyDnnData"] = renameDnnKeys(dnnData, conv)
+		}
+	}
+}
+
+// Load reads all data of ueId covered by the record format, as of one point in
+// time when the database supports transactions.
+func Load(ueId string) (*Subscriber, error) {
+	var s *Subscriber
+	err := database.WithTransaction(func(ctx context.Context) error {
+		var err error
+		s, err = load(ctx, ueId)
+		return err
+	})
+	return s, err
+}
+
+func load(ctx context.Context, ueId string) (*Subscriber, error) {
+	s := &Subscriber{UeId: ueId}
+	ueFilter := bson.M{"ueId": ueId}
+
+	var err error
+	if s.AuthenticationSubscription, err = getOne(ctx, repository.AuthSubsCollName, ueFilter); err != nil {
+		return nil, err
+	}
+
+	servingPlmnIds := make(map[string]bool)
+	for _, collName := range plmnCollNames {
+		values, err := database.Distinct(ctx, collName, "servingPlmnId", ueFilter)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			if servingPlmnId, ok := value.(string); ok {
+				servingPlmnIds[servingPlmnId] = true
+			}
+		}
+	}
+	for servingPlmnId := range servingPlmnIds {
+		filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
+		provisionedData := &ProvisionedData{}
+		if provisionedData.AmData, err = getOne(ctx, repository.AmDataCollName, filter); err != nil {
+			return nil, err
+		}
+		if provisionedData.SmfSelectionData, err = getOne(ctx, repository.SmfSelDataCollName, filter); err != nil {
+			return nil, err
+		}
+		if provisionedData.SmsData, err = getOne(ctx, repository.SmsDataCollName, filter); err != nil {
+			return nil, err
+		}
+		if provisionedData.SmsMngData, err = getOne(ctx, repository.SmsMngDataCollName, filter); err != nil {
+			return nil, err
+		}
+		smDatas, err := database.GetMany(ctx, repository.SmDataCollName, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, smData := range smDatas {
+			convertSmDataDnnKeys(stripKeys(smData), util.UnescapeDnn)
+		}
+		provisionedData.SmData = smDatas
+		if s.ProvisionedData == nil {
+			s.ProvisionedData = make(map[string]*ProvisionedData)
+		}
+		s.ProvisionedData[servingPlmnId] = provisionedData
+	}
+
+	policyData := &PolicyData{}
+	if policyData.AmPolicyData, err = getOne(ctx, repository.AmPolicyDataCollName, ueFilter); err != nil {
+		return nil, err
+	}
+	if policyData.UePolicySet, err = getOne(ctx, repository.UePolicySetCollName, ueFilter); err != nil {
+		return nil, err
+	}
+	if policyData.SmPolicyData, err = getOne(ctx, repository.SmPolicyDataCollName, ueFilter); err != nil {
+		return nil, err
+	}
+	if policyData.SmPolicyData != nil {
+		convertSmPolicyDataDnnKeys(policyData.SmPolicyData, util.UnescapeDnn)
+	}
+	if policyData.AmPolicyData != nil || policyData.UePolicySet != nil || policyData.SmPolicyData != nil {
+		s.PolicyData = policyData
+	}
+	return s, nil
+}
+
+func putOne(ctx context.Context, collName string, filter bson.M, data map[string]interface{}) error {
+	if data == nil {
+		return nil
+	}
+	putData := make(map[string]interface{}, len(data)+len(filter))
+	for k, v := range data {
+		putData[k] = v
+	}
+	for k, v := range filter {
+		putData[k] = v
+	}
+	_, err := database.PutOne(ctx, collName, filter, putData)
+	return err
+}
+
+// Store writes every data set present in s, replacing the stored data set,
+// in one transaction when the database supports it. Data sets absent from s
+// are left as they are. DNN keys of s are escaped in place.
+func Store(s *Subscriber) error {
+	escapeDnnKeys(s)
+	return database.WithTransaction(func(ctx context.Context) error {
+		return store(ctx, s)
+	})
+}
+
+// escapeDnnKeys is done once before the transaction, which can be retried.
+func escapeDnnKeys(s *Subscriber) {
+	for _, provisionedData := range s.ProvisionedData {
+		if provisionedData == nil {
+			continue
+		}
+		for _, smData := range provisionedData.SmData {
+			convertSmDataDnnKeys(smData, util.EscapeDnn)
+		}
+	}
+	if s.PolicyData != nil && s.PolicyData.SmPolicyData != nil {
+		convertSmPolicyDataDnnKeys(s.PolicyData.SmPolicyData, util.EscapeDnn)
+	}
+}
+
+// store expects the DNN keys of s to be escaped.
+func store(ctx context.Context, s *Subscriber) error {
+	ueFilter := bson.M{"ueId": s.UeId}
+	if err := putOne(ctx, repository.AuthSubsCollName, ueFilter, s.AuthenticationSubscription); err != nil {
+		return err
+	}
+
+	var gpsis []string
+	for servingPlmnId, provisionedData := range s.ProvisionedData {
+		if provisionedData == nil {
+			continue
+		}
+		gpsis = append(gpsis, identity.GpsisOf(provisionedData.AmData)...)
+		filter := bson.M{"ueId": s.UeId, "servingPlmnId": servingPlmnId}
+		if err := putOne(ctx, repository.AmDataCollName, filter, provisionedData.AmData); err != nil {
+			return err
+		}
+		if err := putOne(ctx, repository.SmfSelDataCollName, filter, provisionedData.SmfSelectionData); err != nil {
+			return err
+		}
+		if err := putOne(ctx, repository.SmsDataCollName, filter, provisionedData.SmsData); err != nil {
+			return err
+		}
+		if err := putOne(ctx, repository.SmsMngDataCollName, filter, provisionedData.SmsMngData); err != nil {
+			return err
+		}
+		if provisionedData.SmData != nil {
+			if _, err := database.DeleteMany(ctx, repository.SmDataCollName, filter); err != nil {
+				return err
+			}
+			postDataArray := make([]interface{}, 0, len(provisionedData.SmData))
+			for _, smData := range provisionedData.SmData {
+				postData := bson.M{"ueId": s.UeId, "servingPlmnId": servingPlmnId}
+				for k, v := range smData {
+					postData[k] = v
+				}
+				postDataArray = append(postDataArray, postData)
+			}
+			if err := database.InsertMany(ctx, repository.SmDataCollName, postDataArray); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.ProvisionedData != nil {
+		if err := identity.Record(ctx, s.UeId, gpsis); err != nil {
+			return err
+		}
+	}
+
+	if policyData := s.PolicyData; policyData != nil {
+		if err := putOne(ctx, repository.AmPolicyDataCollName, ueFilter, policyData.AmPolicyData); err != nil {
+			return err
+		}
+		if err := putOne(ctx, repository.UePolicySetCollName, ueFilter, policyData.UePolicySet); err != nil {
+			return err
+		}
+		if err := putOne(ctx, repository.SmPolicyDataCollName, ueFilter, policyData.SmPolicyData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
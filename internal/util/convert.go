@@ -66,10 +66,35 @@ func SnssaiModelsToHex(snssai models.Snssai) string {
 	return sst + snssai.Sd
 }
 
+// PlmnIdStringToModels parses a PLMN identity given as its MCC followed by its
+// two or three digit MNC.
+func PlmnIdStringToModels(plmnId string) (*models.PlmnId, error) {
+	if len(plmnId) != 5 && len(plmnId) != 6 {
+		return nil, fmt.Errorf("invalid PLMN ID %q", plmnId)
+	}
+	for _, c := range plmnId {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("invalid PLMN ID %q", plmnId)
+		}
+	}
+	return &models.PlmnId{
+		Mcc: plmnId[:3],
+		Mnc: plmnId[3:],
+	}, nil
+}
+
+var (
+	dnnKeyEscaper   = strings.NewReplacer("%", "%25", ".", "%2E", "$", "%24")
+	dnnKeyUnescaper = strings.NewReplacer("%25", "%", "%2E", ".", "%24", "$")
+)
+
+// EscapeDnn encodes a DNN so that it can be used as a MongoDB document key.
+// '%', '.' and '$' are percent-encoded, so the encoding is reversible for any DNN.
 func EscapeDnn(dnn string) string {
-	return strings.ReplaceAll(dnn, ".", "_")
+	return dnnKeyEscaper.Replace(dnn)
 }
 
+// UnescapeDnn decodes a document key produced by EscapeDnn.
 func UnescapeDnn(dnnKey string) string {
-	return strings.ReplaceAll(dnnKey, "_", ".")
+	return dnnKeyUnescaper.Replace(dnnKey)
 }
@@ -0,0 +1,28 @@
+package util
+
+import "testing"
+
+func TestEscapeDnnRoundTrip(t *testing.T) {
+	testCases := []struct {
+		dnn    string
+		escape string
+	}{
+		{"internet", "internet"},
+		{"ims.mnc001.mcc001.gprs", "ims%2Emnc001%2Emcc001%2Egprs"},
+		{"a_b", "a_b"},
+		{"a_b.c", "a_b%2Ec"},
+		{"$dnn", "%24dnn"},
+		{"100%", "100%25"},
+		{"%2E", "%252E"},
+		{"a_b.c$d%e", "a_b%2Ec%24d%25e"},
+	}
+	for _, tc := range testCases {
+		escaped := EscapeDnn(tc.dnn)
+		if escaped != tc.escape {
+			t.Errorf("EscapeDnn(%q) = %q, want %q", tc.dnn, escaped, tc.escape)
+		}
+		if unescaped := UnescapeDnn(escaped); unescaped != tc.dnn {
+			t.Errorf("UnescapeDnn(%q) = %q, want %q", escaped, unescaped, tc.dnn)
+		}
+	}
+}
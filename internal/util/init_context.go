@@ -39,6 +39,19 @@ func InitUdrContext(context *udr_context.UDRContext) {
 			}
 		}
 	}
+	if history := configuration.History; history != nil {
+		context.EnableHistory = history.Enable
+	}
+	if bdtData := configuration.BdtData; bdtData != nil {
+		context.ArchiveExpiredBdtData = bdtData.Archive
+	}
+	if admin := configuration.Admin; admin != nil {
+		context.AdminBindingIPv4 = admin.BindingIPv4
+		if context.AdminBindingIPv4 == "" {
+			context.AdminBindingIPv4 = factory.UDR_DEFAULT_ADMIN_IPV4
+		}
+		context.AdminPort = admin.Port
+	}
 	if configuration.NrfUri != "" {
 		context.NrfUri = configuration.NrfUri
 	} else {
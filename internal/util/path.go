@@ -5,4 +5,5 @@ var (
 	UdrDefaultPemPath    = "./config/TLS/udr.pem"
 	UdrDefaultKeyPath    = "./config/TLS/udr.key"
 	UdrDefaultConfigPath = "./config/udrcfg.yaml"
+	UdrDefaultAuditPath  = "./log/udraudit.log"
 )
@@ -0,0 +1,28 @@
+package util
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ParseUserAgent splits a TS 29.500 User-Agent header value of the form
+// "<NF type>-<NF instance id> [<FQDN>]" into the NF type and instance ID.
+// Values that do not follow the convention are returned as the NF type.
+func ParseUserAgent(userAgent string) (nfType string, nfInstanceId string) {
+	userAgent = strings.TrimSpace(userAgent)
+	if i := strings.IndexByte(userAgent, ' '); i >= 0 {
+		userAgent = userAgent[:i]
+	}
+	if i := strings.IndexByte(userAgent, '-'); i >= 0 {
+		return userAgent[:i], userAgent[i+1:]
+	}
+	return userAgent, ""
+}
+
+// CallerNf returns the NF type and instance ID of the consumer that sent header.
+func CallerNf(header http.Header) (nfType string, nfInstanceId string) {
+	if header == nil {
+		return "", ""
+	}
+	return ParseUserAgent(header.Get("User-Agent"))
+}
@@ -58,3 +58,11 @@ func ProblemDetailsUpspecified(detail string) *models.ProblemDetails {
 		Detail: detail,
 	}
 }
+
+func ProblemDetailsConflict(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Conflict",
+		Status: http.StatusConflict,
+		Detail: detail,
+	}
+}
@@ -59,12 +59,18 @@ const (
 	UDR_DEFAULT_IPV4     = "127.0.0.4"
 	UDR_DEFAULT_PORT     = "8000"
 	UDR_DEFAULT_PORT_INT = 8000
+
+	UDR_DEFAULT_ADMIN_IPV4 = "127.0.0.1"
 )
 
 type Configuration struct {
 	Sbi     *Sbi     `yaml:"sbi" valid:"required"`
 	Mongodb *Mongodb `yaml:"mongodb" valid:"required"`
 	NrfUri  string   `yaml:"nrfUri" valid:"url,required"`
+	History *History `yaml:"history,omitempty" valid:"optional"`
+	Audit   *Audit   `yaml:"audit,omitempty" valid:"optional"`
+	BdtData *BdtData `yaml:"bdtData,omitempty" valid:"optional"`
+	Admin   *Admin   `yaml:"admin,omitempty" valid:"optional"`
 }
 
 func (c *Configuration) validate() (bool, error) {
@@ -90,8 +96,29 @@ type Tls struct {
 }
 
 type Mongodb struct {
-	Name string `yaml:"name" valid:"type(string),required"`
-	Url  string `yaml:"url" valid:"requrl,required"`
+	Name        string `yaml:"name" valid:"type(string),required"`
+	Url         string `yaml:"url" valid:"requrl,required"`
+	AutoMigrate bool   `yaml:"autoMigrate,omitempty" valid:"optional"` // Apply pending schema migrations on start.
+}
+
+type History struct {
+	Enable bool `yaml:"enable,omitempty" valid:"optional"` // Record before/after images of every UE data write.
+}
+
+type Audit struct {
+	Enable bool   `yaml:"enable,omitempty" valid:"optional"` // Record every write request in the audit log.
+	File   string `yaml:"file,omitempty" valid:"type(string),optional"`
+}
+
+type BdtData struct {
+	Archive bool `yaml:"archive,omitempty" valid:"optional"` // Keep BDT data whose transfer window ended in an archive.
+}
+
+// Admin is the listener of the administration API, which is kept off the SBI.
+// The API is not served without it.
+type Admin struct {
+	BindingIPv4 string `yaml:"bindingIPv4,omitempty" valid:"host,optional"` // Defaults to 127.0.0.1.
+	Port        int    `yaml:"port" valid:"port,required"`
 }
 
 func appendInvalid(err error) error {
@@ -4,9 +4,11 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"runtime/debug"
 	"sync"
 	"syscall"
@@ -14,10 +16,14 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 
+	"github.com/free5gc/udr/internal/audit"
 	udr_context "github.com/free5gc/udr/internal/context"
 	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/migration"
+	"github.com/free5gc/udr/internal/sbi/admin"
 	"github.com/free5gc/udr/internal/sbi/consumer"
 	"github.com/free5gc/udr/internal/sbi/datarepository"
+	"github.com/free5gc/udr/internal/sbi/producer"
 	"github.com/free5gc/udr/internal/util"
 	"github.com/free5gc/udr/pkg/factory"
 	"github.com/free5gc/util/httpwrapper"
@@ -122,6 +128,32 @@ func (udr *UDR) FilterCli(c *cli.Context) (args []string) {
 	return args
 }
 
+func (udr *UDR) ConnectMongoDB() error {
+	mongodb := factory.UdrConfig.Configuration.Mongodb
+	return mongoapi.SetMongoDB(mongodb.Name, mongodb.Url)
+}
+
+// initAudit installs the audit log file sink when auditing is enabled.
+func (udr *UDR) initAudit() error {
+	auditConfig := factory.UdrConfig.Configuration.Audit
+	if auditConfig == nil || !auditConfig.Enable {
+		return nil
+	}
+	path := auditConfig.File
+	if path == "" {
+		path = util.UdrDefaultAuditPath
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o775); err != nil {
+		return err
+	}
+	sink, err := audit.NewFileSink(path)
+	if err != nil {
+		return err
+	}
+	logger.InitLog.Infof("Audit log: %s", path)
+	return audit.SetSink(sink)
+}
+
 func (udr *UDR) Start() {
 	// get config file info
 	config := factory.UdrConfig
@@ -130,11 +162,24 @@ func (udr *UDR) Start() {
 	logger.InitLog.Infof("UDR Config Info: Version[%s] Description[%s]", config.Info.Version, config.Info.Description)
 
 	// Connect to MongoDB
-	if err := mongoapi.SetMongoDB(mongodb.Name, mongodb.Url); err != nil {
+	if err := udr.ConnectMongoDB(); err != nil {
+		logger.InitLog.Errorf("UDR start err: %+v", err)
+		return
+	}
+
+	if err := migration.CheckOnStartup(mongodb.AutoMigrate); err != nil {
+		logger.InitLog.Errorf("UDR start err: %+v", err)
+		return
+	}
+
+	if err := udr.initAudit(); err != nil {
 		logger.InitLog.Errorf("UDR start err: %+v", err)
 		return
 	}
 
+	producer.StartInfluenceDataValidityScheduler()
+	producer.StartBdtDataExpiryScheduler()
+
 	logger.InitLog.Infoln("Server started")
 
 	router := logger_util.NewGinWithLogrus(logger.GinLog)
@@ -151,6 +196,7 @@ func (udr *UDR) Start() {
 
 	self := udr_context.UDR_Self()
 	util.InitUdrContext(self)
+	udr.startAdminServer(self)
 
 	addr := fmt.Sprintf("%s:%d", self.BindingIPv4, self.SBIPort)
 	profile := consumer.BuildNFInstance(self)
@@ -200,6 +246,36 @@ func (udr *UDR) Start() {
 	}
 }
 
+// startAdminServer serves the administration API on its own plain HTTP
+// listener, so that it is not reachable through the SBI. The API is not served
+// without an admin configuration.
+func (udr *UDR) startAdminServer(self *udr_context.UDRContext) {
+	if self.AdminPort == 0 {
+		logger.InitLog.Infoln("Administration API is not configured")
+		return
+	}
+
+	router := logger_util.NewGinWithLogrus(logger.GinLog)
+	admin.AddService(router)
+	server := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", self.AdminBindingIPv4, self.AdminPort),
+		Handler: router,
+	}
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Print stack for panic to log. Fatalf() will let program exit.
+				logger.InitLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+		}()
+
+		logger.InitLog.Infof("Administration API listening on %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil {
+			logger.InitLog.Errorf("Administration API server err: %+v", err)
+		}
+	}()
+}
+
 func (udr *UDR) Exec(c *cli.Context) error {
 	// UDR.Initialize(cfgPath, c)
 
@@ -287,5 +363,8 @@ func (udr *UDR) Terminate() {
 	} else {
 		logger.InitLog.Infof("Deregister from NRF successfully")
 	}
+	if err := audit.SetSink(nil); err != nil {
+		logger.InitLog.Errorf("Close audit log Error[%+v]", err)
+	}
 	logger.InitLog.Infof("UDR terminated")
 }